@@ -0,0 +1,101 @@
+package slogtripper
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// WithAsyncLogging hands records to a background worker through a queue of
+// queueSize, so slow handlers don't hold up round trips. Records are built
+// straight away, only written later. When the queue is full records are
+// dropped and counted, see DroppedLogs; the worker logs an "HTTP Logs
+// Dropped" record once it catches up. Call Close to flush the queue and stop
+// the worker.
+func WithAsyncLogging(queueSize int) Option {
+	return func(st *SlogTripper) {
+		if queueSize <= 0 {
+			st.invalid("non-positive async log queue size %d", queueSize)
+			return
+		}
+
+		st.async = &asyncLogger{
+			queue: make(chan asyncRecord, queueSize),
+			done:  make(chan struct{}),
+		}
+	}
+}
+
+type asyncRecord struct {
+	ctx     context.Context
+	handler slog.Handler
+	record  slog.Record
+}
+
+type asyncLogger struct {
+	queue chan asyncRecord
+	done  chan struct{}
+
+	dropped atomic.Uint64
+	// unreported counts the records dropped since the worker last said so
+	unreported atomic.Uint64
+}
+
+func (a *asyncLogger) log(ctx context.Context, logger *slog.Logger, level slog.Level, msg string, args ...any) {
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	r := slog.NewRecord(time.Now(), level, msg, 0)
+	r.Add(args...)
+
+	select {
+	case a.queue <- asyncRecord{ctx: ctx, handler: logger.Handler(), record: r}:
+	default:
+		a.dropped.Add(1)
+		a.unreported.Add(1)
+	}
+}
+
+// run writes queued records until stop is closed, then writes what's left.
+func (a *asyncLogger) run(stop <-chan struct{}) {
+	defer close(a.done)
+
+	for {
+		select {
+		case r := <-a.queue:
+			a.write(r)
+		case <-stop:
+			for {
+				select {
+				case r := <-a.queue:
+					a.write(r)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (a *asyncLogger) write(r asyncRecord) {
+	_ = r.handler.Handle(r.ctx, r.record)
+
+	if n := a.unreported.Swap(0); n != 0 {
+		dropped := slog.NewRecord(time.Now(), r.record.Level, "HTTP Logs Dropped", 0)
+		dropped.AddAttrs(slog.Uint64("dropped", n))
+
+		_ = r.handler.Handle(r.ctx, dropped)
+	}
+}
+
+// DroppedLogs returns how many records WithAsyncLogging has dropped because
+// its queue was full.
+func (st *SlogTripper) DroppedLogs() uint64 {
+	if st.async == nil {
+		return 0
+	}
+
+	return st.async.dropped.Load()
+}
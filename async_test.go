@@ -0,0 +1,72 @@
+package slogtripper
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// blockingHandler holds up writes until released
+type blockingHandler struct {
+	slog.Handler
+	release chan struct{}
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, r slog.Record) error {
+	<-h.release
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestAsyncLogging(t *testing.T) {
+	var output syncBuffer
+
+	handler := &blockingHandler{
+		Handler: slog.NewJSONHandler(&output, nil),
+		release: make(chan struct{}),
+	}
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(handler)),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithAsyncLogging(2),
+	)
+
+	// The worker holds one record while blocked, the queue two more, the
+	// rest are dropped without holding up the round trips
+	for i := 0; i < 6; i++ {
+		if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+			t.Fatalf("Error in roundtrip: %v", err)
+		}
+	}
+
+	if dropped := st.DroppedLogs(); dropped < 3 {
+		t.Errorf("Expected at least 3 dropped records, got %d", dropped)
+	}
+
+	close(handler.release)
+	st.Close()
+
+	var requests, droppedRecords int
+	for _, line := range output.Lines() {
+		switch {
+		case strings.Contains(string(line), `"msg":"HTTP Request"`):
+			requests++
+		case strings.Contains(string(line), `"msg":"HTTP Logs Dropped"`):
+			droppedRecords++
+		}
+	}
+
+	if requests+int(st.DroppedLogs()) != 6 {
+		t.Errorf("Expected every record written or dropped, got %d written %d dropped", requests, st.DroppedLogs())
+	}
+
+	if droppedRecords == 0 {
+		t.Error("Dropped records should be reported")
+	}
+}
@@ -0,0 +1,75 @@
+package slogtripper
+
+import (
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// auditBodyTimeout is how long a response's audit record waits for its body
+// to be finished with, unless DetectBodyLeaks gives a timeout.
+const auditBodyTimeout = time.Minute
+
+// AuditMode makes the tripper log every round trip for compliance: nothing
+// disables, samples or suppresses its records, so WithLogRateLimit,
+// WithErrorDedup, SummaryOnly, DisableLogging and SetEnabled(false) are
+// ignored and records are written synchronously even with
+// WithAsyncLogging. Records are tagged audit=true and carry the SHA-256 of
+// request and response bodies read to the end as body_sha256, holding a
+// response's record back until its body is finished with. A body the caller
+// doesn't finish with within DetectBodyLeaks' timeout, or a minute without
+// one, has its record written anyway, with body_unfinished=true and no hash.
+// identity, which may be nil, returns the caller's identity from the
+// request's context, logged as an identity group.
+//
+// The logger's level still has to admit the records, and the logging
+// pipeline's own requests, see WithLogSinkHosts, are never logged, as each
+// would log another.
+func AuditMode(identity func(ctx context.Context) []slog.Attr) Option {
+	return func(st *SlogTripper) {
+		st.audit = true
+		st.auditIdentity = identity
+	}
+}
+
+// auditAttrs returns the attributes audit mode adds to a record.
+func (st *SlogTripper) auditAttrs(ctx context.Context) []any {
+	attrs := []any{slog.Bool("audit", true)}
+
+	if st.auditIdentity != nil {
+		if identity := st.auditIdentity(ctx); len(identity) != 0 {
+			group := make([]any, len(identity))
+			for i, attr := range identity {
+				group[i] = attr
+			}
+
+			attrs = append(attrs, slog.Group("identity", group...))
+		}
+	}
+
+	return attrs
+}
+
+// auditAfterBody calls write once body is finished with, or once the audit
+// timeout is up, whichever is first, with whether the body was unfinished.
+func (st *SlogTripper) auditAfterBody(body *countingBody, write func(unfinished bool)) {
+	timeout := auditBodyTimeout
+	if st.leakTimeout > 0 {
+		timeout = st.leakTimeout
+	}
+
+	var once sync.Once
+	timer := time.AfterFunc(timeout, func() {
+		once.Do(func() { write(true) })
+	})
+
+	body.then(func() {
+		timer.Stop()
+		once.Do(func() { write(false) })
+	})
+}
+
+// newBodyHash returns the hash bodies are audited with.
+var newBodyHash = sha256.New
@@ -0,0 +1,166 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type userKey struct{}
+
+func TestAuditMode(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				io.Copy(io.Discard, r.Body)
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("response")),
+				}, nil
+			},
+		}),
+		SummaryOnly(),
+		WithErrorDedup(time.Minute),
+		WithHostConfig("localhost", DisableLogging()),
+		AuditMode(func(ctx context.Context) []slog.Attr {
+			return []slog.Attr{slog.String("user", ctx.Value(userKey{}).(string))}
+		}),
+	)
+	defer st.Close()
+
+	st.SetEnabled(false)
+
+	ctx := context.WithValue(context.Background(), userKey{}, "alice")
+	req := Must(http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost/transfer", strings.NewReader("request")))
+
+	res, err := st.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	var record struct {
+		Audit    bool `json:"audit"`
+		Identity struct {
+			User string `json:"user"`
+		} `json:"identity"`
+		Request struct {
+			BodySHA256 string `json:"body_sha256"`
+		} `json:"request"`
+		Response struct {
+			BodySHA256 string `json:"body_sha256"`
+		} `json:"response"`
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a record: %v: %s", err, output.String())
+	}
+
+	sum := func(s string) string {
+		h := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+
+	if !record.Audit || record.Identity.User != "alice" {
+		t.Errorf("Expected an audit record with the caller: %s", output.String())
+	}
+
+	if record.Request.BodySHA256 != sum("request") || record.Response.BodySHA256 != sum("response") {
+		t.Errorf("Unexpected body hashes: %s", output.String())
+	}
+}
+
+func TestAuditModeErrorsNotDeduplicated(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			},
+		}),
+		WithErrorDedup(time.Minute),
+		AuditMode(nil),
+	)
+	defer st.Close()
+
+	for i := 0; i < 3; i++ {
+		_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost", nil)))
+	}
+
+	if n := strings.Count(output.String(), `"audit":true`); n != 3 {
+		t.Errorf("Expected every failure logged, got %d: %s", n, output.String())
+	}
+}
+
+func TestAuditModeUnfinishedBody(t *testing.T) {
+	var output syncBuffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("response")),
+				}, nil
+			},
+		}),
+		DetectBodyLeaks(20*time.Millisecond),
+		AuditMode(nil),
+	)
+	defer st.Close()
+
+	// The caller drops the body unread
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil)))
+	if err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	records := func() []string {
+		var found []string
+		for _, line := range output.Lines() {
+			if strings.Contains(string(line), `"msg":"HTTP Request"`) {
+				found = append(found, string(line))
+			}
+		}
+
+		return found
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(records()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	found := records()
+	if len(found) != 1 || !strings.Contains(found[0], `"audit":true`) || !strings.Contains(found[0], `"body_unfinished":true`) {
+		t.Fatalf("Expected the abandoned body's record written as unfinished, got %q", found)
+	}
+
+	if strings.Contains(found[0], "body_sha256") {
+		t.Errorf("Expected no hash of an unread body: %s", found[0])
+	}
+
+	// Finishing with it late doesn't log the request again
+	res.Body.Close()
+	if n := len(records()); n != 1 {
+		t.Errorf("Expected one record, got %d", n)
+	}
+}
@@ -0,0 +1,114 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// CaptureAWSMetadata logs the IDs AWS support asks for as an aws group on
+// responses from AWS APIs: request_id, from x-amz-request-id or
+// x-amzn-RequestId, and S3's id_2. The service and operation come from the
+// X-Amz-Target header of JSON APIs, or else the endpoint's host and a query
+// API's Action parameter, along with the region the endpoint is in.
+func CaptureAWSMetadata() Option {
+	return WithCapturer(awsCapturer{})
+}
+
+type awsCapturer struct{}
+
+func (awsCapturer) CaptureRequest(req *http.Request) []slog.Attr {
+	return nil
+}
+
+func (awsCapturer) CaptureResponse(res *http.Response) []slog.Attr {
+	var attrs []any
+
+	requestID := res.Header.Get("X-Amz-Request-Id")
+	if requestID == "" {
+		requestID = res.Header.Get("X-Amzn-Requestid")
+	}
+
+	if requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+
+	if v := res.Header.Get("X-Amz-Id-2"); v != "" {
+		attrs = append(attrs, slog.String("id_2", v))
+	}
+
+	var service, operation, region string
+
+	if req := res.Request; req != nil {
+		if req.URL != nil {
+			service, region = awsEndpoint(req.URL.Hostname())
+			operation = req.URL.Query().Get("Action")
+		}
+
+		// JSON APIs name both, as in DynamoDB_20120810.GetItem
+		if target := req.Header.Get("X-Amz-Target"); target != "" {
+			service, operation, _ = strings.Cut(target, ".")
+		}
+	}
+
+	// Not an AWS API after all
+	if len(attrs) == 0 && service == "" {
+		return nil
+	}
+
+	for _, attr := range []struct{ key, value string }{
+		{"service", service},
+		{"operation", operation},
+		{"region", region},
+	} {
+		if attr.value != "" {
+			attrs = append(attrs, slog.String(attr.key, attr.value))
+		}
+	}
+
+	return []slog.Attr{slog.Group("aws", attrs...)}
+}
+
+// awsEndpoint returns the service and region of an AWS endpoint, such as
+// sqs.eu-west-1.amazonaws.com, or a virtual hosted S3 bucket in front of one.
+func awsEndpoint(host string) (service, region string) {
+	host = strings.ToLower(host)
+
+	var ok bool
+	for _, suffix := range []string{".amazonaws.com", ".amazonaws.com.cn"} {
+		if host, ok = strings.CutSuffix(host, suffix); ok {
+			break
+		}
+	}
+
+	if !ok {
+		return "", ""
+	}
+
+	labels := strings.Split(host, ".")
+	service = labels[0]
+
+	for i, label := range labels {
+		switch {
+		case label == "s3" || strings.HasPrefix(label, "s3-"):
+			// A bucket name comes first for a virtual hosted bucket
+			service = "s3"
+		case i > 0 && isAWSRegion(label):
+			region = label
+		}
+	}
+
+	return service, region
+}
+
+// isAWSRegion reports whether label looks like a region, i.e. us-east-1.
+func isAWSRegion(label string) bool {
+	parts := strings.Split(label, "-")
+	if len(parts) < 3 {
+		return false
+	}
+
+	last := parts[len(parts)-1]
+
+	return len(last) == 1 && last[0] >= '0' && last[0] <= '9'
+}
@@ -0,0 +1,77 @@
+package slogtripper
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCaptureAWSMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		target   string
+		header   http.Header
+		expected string
+	}{
+		{
+			name:     "json api",
+			url:      "https://dynamodb.us-east-1.amazonaws.com/",
+			target:   "DynamoDB_20120810.GetItem",
+			header:   http.Header{"X-Amzn-Requestid": []string{"ABC123"}},
+			expected: `"aws":{"request_id":"ABC123","service":"DynamoDB_20120810","operation":"GetItem","region":"us-east-1"}`,
+		},
+		{
+			name:     "s3 bucket",
+			url:      "https://my.bucket.s3.eu-west-2.amazonaws.com/key",
+			header:   http.Header{"X-Amz-Request-Id": []string{"4442587FB7D0A2F9"}, "X-Amz-Id-2": []string{"vlR7PnpV2Ce81puvIA=="}},
+			expected: `"aws":{"request_id":"4442587FB7D0A2F9","id_2":"vlR7PnpV2Ce81puvIA==","service":"s3","region":"eu-west-2"}`,
+		},
+		{
+			name:     "query api",
+			url:      "https://sqs.amazonaws.com.cn/?Action=SendMessage",
+			expected: `"aws":{"service":"sqs","operation":"SendMessage"}`,
+		},
+		{
+			name: "not aws",
+			url:  "https://example.com/?Action=SendMessage",
+		},
+	}
+
+	for _, tt := range tests {
+		var output bytes.Buffer
+
+		st := NewSlogTripper(
+			WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+			WithRoundTripper(&MockRoundTripper{
+				MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Header: tt.header, Request: r}, nil
+				},
+			}),
+			CaptureAWSMetadata(),
+		)
+
+		req := Must(http.NewRequest(http.MethodPost, tt.url, nil))
+		if tt.target != "" {
+			req.Header.Set("X-Amz-Target", tt.target)
+		}
+
+		if _, err := st.RoundTrip(req); err != nil {
+			t.Fatalf("%s: error in roundtrip: %v", tt.name, err)
+		}
+
+		if tt.expected == "" {
+			if strings.Contains(output.String(), `"aws"`) {
+				t.Errorf("%s: unexpected aws group: %s", tt.name, output.String())
+			}
+
+			continue
+		}
+
+		if !strings.Contains(output.String(), tt.expected) {
+			t.Errorf("%s: expected %s: %s", tt.name, tt.expected, output.String())
+		}
+	}
+}
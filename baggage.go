@@ -0,0 +1,155 @@
+package slogtripper
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// maxBaggageSize is the most a baggage header may hold, as the W3C spec
+// limits it to.
+const maxBaggageSize = 8192
+
+// ParseBaggage parses the value of a W3C baggage header into its entries,
+// decoding their values and dropping their properties. Malformed members are
+// skipped.
+func ParseBaggage(header string) map[string]string {
+	baggage := make(map[string]string)
+
+	for _, member := range strings.Split(header, ",") {
+		member, _, _ = strings.Cut(member, ";")
+
+		key, value, ok := strings.Cut(member, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || strings.ContainsAny(key, " \t\"") {
+			continue
+		}
+
+		if decoded, err := url.PathUnescape(value); err == nil {
+			value = decoded
+		}
+
+		baggage[key] = value
+	}
+
+	return baggage
+}
+
+// FormatBaggage formats baggage as the value of a baggage header, in key
+// order, leaving out entries which would take it over the spec's 8192 bytes.
+func FormatBaggage(baggage map[string]string) string {
+	keys := make([]string, 0, len(baggage))
+	for key := range baggage {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		member := key + "=" + url.PathEscape(baggage[key])
+		if b.Len()+len(member)+1 > maxBaggageSize {
+			continue
+		}
+
+		if b.Len() != 0 {
+			b.WriteByte(',')
+		}
+
+		b.WriteString(member)
+	}
+
+	return b.String()
+}
+
+type baggageKey struct{}
+
+// ContextWithBaggage returns a context carrying baggage, which requests made
+// with it send when baggage is forwarded.
+func ContextWithBaggage(ctx context.Context, baggage map[string]string) context.Context {
+	return context.WithValue(ctx, baggageKey{}, baggage)
+}
+
+// BaggageFromContext returns the baggage in ctx, if there is any. Middleware
+// puts the baggage of requests it handles there when baggage is logged or
+// forwarded.
+func BaggageFromContext(ctx context.Context) map[string]string {
+	baggage, _ := ctx.Value(baggageKey{}).(map[string]string)
+	return baggage
+}
+
+// LogBaggage logs the W3C baggage entries named by keys as a baggage group
+// on the request, or every entry when no keys are given, masked like header
+// values. Entries come from the request's baggage header, falling back to
+// the baggage in its context, from ContextWithBaggage or Middleware.
+func LogBaggage(keys ...string) Option {
+	return func(st *SlogTripper) {
+		st.logBaggage = true
+		st.baggageKeys = append(st.baggageKeys[:len(st.baggageKeys):len(st.baggageKeys)], keys...)
+	}
+}
+
+// ForwardBaggage sends the baggage in a request's context upstream as its
+// baggage header, unless it already has one, so cross-service metadata
+// carries on through the calls a handler makes.
+func ForwardBaggage() Option {
+	return func(st *SlogTripper) {
+		st.forwardBaggage = true
+	}
+}
+
+// outgoingBaggage returns the baggage header to add to req, if any.
+func (st *SlogTripper) outgoingBaggage(req *http.Request) string {
+	if !st.forwardBaggage {
+		return ""
+	}
+
+	if _, ok := req.Header["Baggage"]; ok {
+		return ""
+	}
+
+	return FormatBaggage(BaggageFromContext(req.Context()))
+}
+
+// baggageAttr returns the baggage group for req, if it has any of the
+// entries logged.
+func (st *SlogTripper) baggageAttr(req *http.Request) (slog.Attr, bool) {
+	baggage := BaggageFromContext(req.Context())
+	if header := req.Header.Get("Baggage"); header != "" {
+		baggage = ParseBaggage(header)
+	}
+
+	keys := st.baggageKeys
+	if len(keys) == 0 {
+		keys = make([]string, 0, len(baggage))
+		for key := range baggage {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+
+	var attrs []any
+	for _, key := range keys {
+		if value, ok := baggage[key]; ok {
+			attrs = append(attrs, slog.String(key, st.mask(value)))
+		}
+	}
+
+	if len(attrs) == 0 {
+		return slog.Attr{}, false
+	}
+
+	return slog.Group("baggage", attrs...), true
+}
+
+// incomingBaggage returns r with its baggage in its context.
+func incomingBaggage(r *http.Request) *http.Request {
+	header := r.Header.Get("Baggage")
+	if header == "" {
+		return r
+	}
+
+	return r.WithContext(ContextWithBaggage(r.Context(), ParseBaggage(header)))
+}
@@ -0,0 +1,125 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseBaggage(t *testing.T) {
+	got := ParseBaggage(" tenant = acme , user%20name=J%C3%B6rg;prop=1,empty=,invalid, =novalue,bad key=x")
+
+	want := map[string]string{"tenant": "acme", "user%20name": "Jörg", "empty": ""}
+	if !maps.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if header := FormatBaggage(map[string]string{"b": "x, y;z", "a": "1"}); header != "a=1,b=x%2C%20y%3Bz" {
+		t.Errorf("Unexpected header: %q", header)
+	}
+
+	if back := ParseBaggage(FormatBaggage(map[string]string{"b": "x, y;z"})); back["b"] != "x, y;z" {
+		t.Errorf("Expected the value to round trip, got %q", back["b"])
+	}
+
+	if header := FormatBaggage(map[string]string{"a": strings.Repeat("x", maxBaggageSize), "b": "1"}); header != "b=1" {
+		t.Errorf("Expected an oversized entry left out, got %d bytes", len(header))
+	}
+}
+
+func TestLogBaggage(t *testing.T) {
+	var output bytes.Buffer
+	var sent http.Header
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				sent = r.Header
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			},
+		}),
+		LogBaggage("tenant", "region"),
+		ForwardBaggage(),
+	)
+
+	handler := st.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := Must(http.NewRequestWithContext(r.Context(), http.MethodGet, "http://upstream/", nil))
+		if _, err := st.RoundTrip(req); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Baggage", "tenant=acme,region=eu-west-1,session=secret")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if got := ParseBaggage(sent.Get("Baggage")); got["tenant"] != "acme" || got["session"] != "secret" {
+		t.Errorf("Expected the baggage forwarded upstream, got %q", sent.Get("Baggage"))
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(output.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("Expected two records, got %d", len(lines))
+	}
+
+	for _, line := range lines {
+		var record struct {
+			Request struct {
+				Baggage map[string]string `json:"baggage"`
+			} `json:"request"`
+		}
+
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string]string{"tenant": "acme", "region": "eu-west-1"}
+		if !maps.Equal(record.Request.Baggage, want) {
+			t.Errorf("Expected %v logged, got %v", want, record.Request.Baggage)
+		}
+	}
+}
+
+func TestLogBaggageAll(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if _, ok := r.Header["Baggage"]; ok {
+					t.Error("Expected baggage only forwarded when asked to")
+				}
+
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			},
+		}),
+		LogBaggage(),
+	)
+
+	ctx := ContextWithBaggage(context.Background(), map[string]string{"tenant": "acme", "plan": "gold"})
+	if _, err := st.RoundTrip(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	var record struct {
+		Request struct {
+			Baggage map[string]string `json:"baggage"`
+		} `json:"request"`
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := BaggageFromContext(ctx); !maps.Equal(record.Request.Baggage, want) {
+		t.Errorf("Expected %v logged, got %v", want, record.Request.Baggage)
+	}
+}
@@ -0,0 +1,59 @@
+package benchmark
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/b1scuit/slogtripper"
+)
+
+func benchmarkTripper(b *testing.B, opts ...slogtripper.Option) {
+	st := slogtripper.NewSlogTripper(append([]slogtripper.Option{
+		slogtripper.WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		slogtripper.WithRoundTripper(transport{}),
+	}, opts...)...)
+	defer st.Close()
+
+	benchmark(b, st)
+}
+
+func BenchmarkBaseline(b *testing.B) {
+	benchmark(b, transport{})
+}
+
+func BenchmarkDefault(b *testing.B) {
+	benchmarkTripper(b)
+}
+
+func BenchmarkDisabledLevel(b *testing.B) {
+	benchmarkTripper(b, slogtripper.WithLogger(slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError}))))
+}
+
+func BenchmarkHeaders(b *testing.B) {
+	benchmarkTripper(b, slogtripper.CaptureRequestHeaders(), slogtripper.CaptureResponseHeaders())
+}
+
+func BenchmarkRequestBody(b *testing.B) {
+	benchmarkTripper(b, slogtripper.CaptureRequestBody())
+}
+
+func BenchmarkResponseBody(b *testing.B) {
+	benchmarkTripper(b, slogtripper.CaptureResponseBody())
+}
+
+func BenchmarkBodies(b *testing.B) {
+	benchmarkTripper(b, slogtripper.CaptureRequestBody(), slogtripper.CaptureResponseBody())
+}
+
+func BenchmarkBodiesLimited(b *testing.B) {
+	benchmarkTripper(b, slogtripper.CaptureRequestBody(), slogtripper.CaptureResponseBody(), slogtripper.WithMaxBodySize(256))
+}
+
+func BenchmarkTransferSizes(b *testing.B) {
+	benchmarkTripper(b, slogtripper.WithTransferSizes())
+}
+
+func BenchmarkAsync(b *testing.B) {
+	benchmarkTripper(b, slogtripper.WithAsyncLogging(1024))
+}
@@ -0,0 +1,7 @@
+// Package benchmark holds benchmarks of the cost slogtripper adds to a round
+// trip under common configurations, against a transport answering in memory:
+//
+//	go test -bench . -benchmem github.com/b1scuit/slogtripper/benchmark
+//
+// Overhead measures a configuration of your own.
+package benchmark
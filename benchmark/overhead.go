@@ -0,0 +1,92 @@
+package benchmark
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/b1scuit/slogtripper"
+)
+
+// OverheadReport is the cost a SlogTripper adds to each round trip.
+type OverheadReport struct {
+	PerRequest       time.Duration
+	AllocsPerRequest int64
+	BytesPerRequest  int64
+}
+
+// Overhead measures what a SlogTripper configured by opts adds to each round
+// trip in this environment, to weigh up options such as body capture. The
+// round trips are those the benchmarks make, compared to the same round
+// trips made without the tripper. Records are encoded by a JSON handler and
+// discarded, unless opts includes WithLogger. It runs benchmarks for a couple
+// of seconds, so isn't for the hot path itself.
+func Overhead(opts ...slogtripper.Option) OverheadReport {
+	run := func(rt http.RoundTripper) testing.BenchmarkResult {
+		return testing.Benchmark(func(b *testing.B) {
+			benchmark(b, rt)
+		})
+	}
+
+	st := slogtripper.NewSlogTripper(append([]slogtripper.Option{
+		slogtripper.WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		slogtripper.WithRoundTripper(transport{}),
+	}, opts...)...)
+	defer st.Close()
+
+	baseline := run(transport{})
+	tripped := run(st)
+
+	return OverheadReport{
+		PerRequest:       max(time.Duration(tripped.NsPerOp()-baseline.NsPerOp()), 0),
+		AllocsPerRequest: max(tripped.AllocsPerOp()-baseline.AllocsPerOp(), 0),
+		BytesPerRequest:  max(tripped.AllocedBytesPerOp()-baseline.AllocedBytesPerOp(), 0),
+	}
+}
+
+// payload is the kilobyte of JSON posted and read back by every round trip.
+var payload = []byte(`{"data":"` + strings.Repeat("x", 1014) + `"}`)
+
+// transport answers every request with payload, after reading its body.
+type transport struct{}
+
+func (transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(payload)),
+		ContentLength: int64(len(payload)),
+		Request:       req,
+	}, nil
+}
+
+// benchmark posts payload through rt b.N times, reading the response.
+func benchmark(b *testing.B, rt http.RoundTripper) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost/users", bytes.NewReader(payload))
+		if err != nil {
+			b.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer secret")
+
+		res, err := rt.RoundTrip(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+}
@@ -0,0 +1,25 @@
+package benchmark
+
+import (
+	"testing"
+
+	"github.com/b1scuit/slogtripper"
+)
+
+func TestOverhead(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Overhead runs benchmarks")
+	}
+
+	plain := Overhead()
+	bodies := Overhead(slogtripper.CaptureRequestBody(), slogtripper.CaptureResponseBody())
+
+	if plain.PerRequest <= 0 || plain.AllocsPerRequest <= 0 {
+		t.Errorf("Expected some overhead, got %+v", plain)
+	}
+
+	// Capturing bodies copies them, so it can only cost more memory
+	if bodies.BytesPerRequest <= plain.BytesPerRequest {
+		t.Errorf("Expected body capture to allocate more: %+v against %+v", bodies, plain)
+	}
+}
@@ -0,0 +1,162 @@
+package slogtripper
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// WithMaxBodySize limits captured body content to n bytes. The full body is
+// still passed on, only what's logged is truncated. 0 means no limit.
+func WithMaxBodySize(n int64) Option {
+	return func(st *SlogTripper) {
+		if n < 0 {
+			st.invalid("negative max body size %d", n)
+		}
+
+		st.maxBodySize = n
+	}
+}
+
+// WithBodyDump writes captured bodies to files in dir, named by request ID,
+// in place of logging them, logging body_file and body_size instead. Large
+// payloads stay out of the log stream but are still there for debugging.
+// The capture options still choose which bodies are kept, and
+// WithMaxBodySize how much of them. dir is created if need be.
+func WithBodyDump(dir string) Option {
+	return func(st *SlogTripper) {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			st.invalid("body dump dir: %v", err)
+			return
+		}
+
+		st.bodyDumpDir = dir
+	}
+}
+
+// dumpBody writes content to the file called name in the body dump dir,
+// returning the attributes logged in its place.
+func (st *SlogTripper) dumpBody(name string, content []byte, truncated bool) ([]slog.Attr, slog.Value) {
+	path := filepath.Join(st.bodyDumpDir, name)
+
+	attrs := []slog.Attr{slog.Int("body_size", len(content))}
+	if truncated {
+		attrs = append(attrs, slog.Bool("body_truncated", true))
+	}
+
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return append(attrs, slog.Any("body_dump_error", err)), slog.Value{}
+	}
+
+	value := slog.StringValue(path)
+
+	return append([]slog.Attr{{Key: "body_file", Value: value}}, attrs...), value
+}
+
+// maxPooledBuffer is the largest buffer put back in bufferPool, so one huge
+// body doesn't stay pinned in memory.
+const maxPooledBuffer = 256 << 10
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	b := bufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+
+	return b
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() <= maxPooledBuffer {
+		bufferPool.Put(b)
+	}
+}
+
+// pooledBody reads a captured body from a pooled buffer, returning the buffer
+// to the pool once closed. It holds the remainder of a truncated body, if any,
+// which is closed with it.
+type pooledBody struct {
+	io.Reader
+	buf  *bytes.Buffer
+	rest io.Closer
+	once sync.Once
+}
+
+func (p *pooledBody) Close() error {
+	var err error
+
+	p.once.Do(func() {
+		if p.rest != nil {
+			err = p.rest.Close()
+		}
+
+		p.Reader = eofReader{}
+		putBuffer(p.buf)
+	})
+
+	return err
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
+
+// multiReadCloser reads the already consumed prefix of a body before carrying
+// on with the remainder, closing the original body.
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureBody reads body for logging, returning the content to log, whether
+// it was truncated to limit and a replacement body holding the full content.
+// The content is only valid until the replacement body is closed when pooled
+// is set, in which case the buffer goes back to a pool. Request bodies can't
+// be pooled, as transports may close them while still reading.
+func captureBody(body io.ReadCloser, limit int64, pooled bool) ([]byte, bool, io.ReadCloser, error) {
+	b := new(bytes.Buffer)
+	if pooled {
+		b = getBuffer()
+	}
+
+	r := io.Reader(body)
+	if limit > 0 {
+		r = io.LimitReader(body, limit+1)
+	}
+
+	if _, err := b.ReadFrom(r); err != nil {
+		if pooled {
+			putBuffer(b)
+		}
+
+		return nil, false, nil, err
+	}
+
+	if limit <= 0 || int64(b.Len()) <= limit {
+		body.Close()
+
+		if pooled {
+			return b.Bytes(), false, &pooledBody{Reader: bytes.NewReader(b.Bytes()), buf: b}, nil
+		}
+
+		return b.Bytes(), false, io.NopCloser(b), nil
+	}
+
+	content := b.Bytes()[:limit]
+	rest := io.MultiReader(bytes.NewReader(b.Bytes()), body)
+
+	if pooled {
+		return content, true, &pooledBody{Reader: rest, buf: b, rest: body}, nil
+	}
+
+	return content, true, &multiReadCloser{Reader: rest, Closer: body}, nil
+}
@@ -0,0 +1,240 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`0123456789`)),
+				}, nil
+			},
+		}),
+		CaptureResponseBody(),
+		WithMaxBodySize(4),
+	)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil)))
+	if err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"body_content":"0123"`) || !strings.Contains(output.String(), `"body_truncated":true`) {
+		t.Errorf("Body not truncated in log: %s", output.String())
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("Error reading body: %v", err)
+	}
+	res.Body.Close()
+
+	if string(body) != "0123456789" {
+		t.Errorf("Full body not passed on, got %q", body)
+	}
+}
+
+func TestMaxBodySizeNotReached(t *testing.T) {
+	content, truncated, body, err := captureBody(io.NopCloser(strings.NewReader("0123")), 4, false)
+	if err != nil {
+		t.Fatalf("Error capturing body: %v", err)
+	}
+
+	if truncated || string(content) != "0123" {
+		t.Errorf("Unexpected capture: %q truncated=%v", content, truncated)
+	}
+
+	if b, _ := io.ReadAll(body); string(b) != "0123" {
+		t.Errorf("Unexpected body: %q", b)
+	}
+}
+
+func TestPooledBody(t *testing.T) {
+	closed := false
+	original := &closeRecorder{Reader: strings.NewReader("0123456789"), closed: &closed}
+
+	content, truncated, body, err := captureBody(original, 4, true)
+	if err != nil {
+		t.Fatalf("Error capturing body: %v", err)
+	}
+
+	if !truncated || string(content) != "0123" {
+		t.Errorf("Unexpected capture: %q truncated=%v", content, truncated)
+	}
+
+	if b, _ := io.ReadAll(body); string(b) != "0123456789" {
+		t.Errorf("Unexpected body: %q", b)
+	}
+
+	body.Close()
+	body.Close()
+
+	if !closed {
+		t.Error("Original body not closed")
+	}
+
+	if n, err := body.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Errorf("Closed body should read EOF, got %d %v", n, err)
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed *bool
+}
+
+func (c *closeRecorder) Close() error {
+	*c.closed = true
+	return nil
+}
+
+func benchmarkRoundTrip(b *testing.B, opts ...Option) {
+	payload := strings.Repeat(`{"hello": "world"}`, 1024)
+
+	st := NewSlogTripper(append([]Option{
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(payload)),
+				}, nil
+			},
+		}),
+	}, opts...)...)
+
+	req := Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		res, err := st.RoundTrip(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+}
+
+func BenchmarkRoundTrip(b *testing.B) {
+	benchmarkRoundTrip(b)
+}
+
+func BenchmarkRoundTripResponseBody(b *testing.B) {
+	benchmarkRoundTrip(b, CaptureResponseBody())
+}
+
+func BenchmarkRoundTripResponseBodyLimited(b *testing.B) {
+	benchmarkRoundTrip(b, CaptureResponseBody(), WithMaxBodySize(1024))
+}
+
+func TestBodyEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		limit    int64
+		expected string
+	}{
+		{"text", "hello\tworld\n", 0, `"body_content":"hello\tworld\n"`},
+		{"binary", "\xff\xfe\x00", 0, `"body_content":"//4A","body_encoding":"base64"`},
+		{"control", "bell\a", 0, `"body_content":"bell\\a","body_encoding":"escaped"`},
+		{"cut rune", "héllo", 2, `"body_content":"h","body_truncated":true`},
+	}
+
+	for _, tt := range tests {
+		var output bytes.Buffer
+
+		st := NewSlogTripper(
+			WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+			WithRoundTripper(&MockRoundTripper{
+				MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK}, nil
+				},
+			}),
+			CaptureRequestBody(),
+			WithMaxBodySize(tt.limit),
+		)
+
+		if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodPost, "http://localhost", strings.NewReader(tt.body)))); err != nil {
+			t.Fatalf("%s: error in roundtrip: %v", tt.name, err)
+		}
+
+		if !strings.Contains(output.String(), tt.expected) {
+			t.Errorf("%s: expected %s: %s", tt.name, tt.expected, output.String())
+		}
+	}
+}
+
+func TestWithBodyDump(t *testing.T) {
+	var output bytes.Buffer
+
+	dir := t.TempDir()
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"big": "payload"}`)),
+				}, nil
+			},
+		}),
+		CaptureResponseBody(),
+		WithBodyDump(dir),
+	)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost", nil)))
+	if err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if b, _ := io.ReadAll(res.Body); string(b) != `{"big": "payload"}` {
+		t.Errorf("Body should still be passed on, got %q", b)
+	}
+
+	var record struct {
+		Request struct {
+			ID string `json:"id"`
+		} `json:"request"`
+		Response struct {
+			BodyFile    string  `json:"body_file"`
+			BodySize    int     `json:"body_size"`
+			BodyContent *string `json:"body_content"`
+		} `json:"response"`
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("Error decoding record: %v", err)
+	}
+
+	if record.Response.BodyContent != nil || record.Response.BodySize != 18 {
+		t.Errorf("Expected only the file logged: %s", output.String())
+	}
+
+	if expected := filepath.Join(dir, record.Request.ID+"-response"); record.Response.BodyFile != expected {
+		t.Errorf("Expected the body in %s: %s", expected, output.String())
+	}
+
+	if b, err := os.ReadFile(record.Response.BodyFile); err != nil || string(b) != `{"big": "payload"}` {
+		t.Errorf("Unexpected dump: %q %v", b, err)
+	}
+}
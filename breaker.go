@@ -0,0 +1,198 @@
+package slogtripper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, wrapped with the host, for requests refused
+// because the host's circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitBreakerConfig configures WithCircuitBreaker.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is how many consecutive failures open the circuit,
+	// defaulting to 5
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a single trial
+	// request is let through, defaulting to 30s
+	Cooldown time.Duration
+	// IsFailure decides whether a round trip counts as a failure, defaulting
+	// to transport errors and 5xx responses. The default leaves out requests
+	// the caller cancelled, which say nothing about the host, but counts
+	// deadlines running out, as a host too slow for its callers is failing
+	// them. Round trips which are neither failures nor successes, as
+	// cancelled ones are by default, are ignored
+	IsFailure func(res *http.Response, err error) bool
+}
+
+// WithCircuitBreaker keeps a circuit breaker per host, refusing requests with
+// ErrCircuitOpen while the host is failing. Every state change is logged as
+// an "HTTP Circuit Breaker" record with the host's failure statistics.
+func WithCircuitBreaker(c CircuitBreakerConfig) Option {
+	return func(st *SlogTripper) {
+		if c.FailureThreshold < 0 || c.Cooldown < 0 {
+			st.invalid("negative circuit breaker threshold or cooldown")
+		}
+
+		if c.FailureThreshold <= 0 {
+			c.FailureThreshold = 5
+		}
+
+		if c.Cooldown <= 0 {
+			c.Cooldown = 30 * time.Second
+		}
+
+		if c.IsFailure == nil {
+			c.IsFailure = func(res *http.Response, err error) bool {
+				if err != nil {
+					return !errors.Is(err, context.Canceled)
+				}
+
+				return res.StatusCode >= http.StatusInternalServerError
+			}
+		}
+
+		st.breakers = &circuitBreakers{config: c, hosts: map[string]*circuitBreaker{}}
+	}
+}
+
+type circuitState string
+
+const (
+	circuitClosed   circuitState = "closed"
+	circuitOpen     circuitState = "open"
+	circuitHalfOpen circuitState = "half-open"
+)
+
+type circuitBreakers struct {
+	config CircuitBreakerConfig
+
+	mu    sync.Mutex
+	hosts map[string]*circuitBreaker
+}
+
+type circuitBreaker struct {
+	state    circuitState
+	openedAt time.Time
+	probing  bool
+
+	consecutiveFailures int
+	failures            int
+	requests            int
+}
+
+// allow reports whether a request to host may be sent.
+func (cb *circuitBreakers) allow(st *SlogTripper, ctx context.Context, host string) bool {
+	var changes []slog.Attr
+
+	cb.mu.Lock()
+	defer func() { cb.unlock(st, ctx, changes) }()
+
+	b, ok := cb.hosts[host]
+	if !ok {
+		b = &circuitBreaker{state: circuitClosed}
+		cb.hosts[host] = b
+	}
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < cb.config.Cooldown {
+			return false
+		}
+
+		changes = append(changes, cb.transition(host, b, circuitHalfOpen))
+		fallthrough
+	case circuitHalfOpen:
+		if b.probing {
+			return false
+		}
+
+		b.probing = true
+	}
+
+	return true
+}
+
+// record updates host's breaker with the outcome of a request allow let through.
+func (cb *circuitBreakers) record(st *SlogTripper, ctx context.Context, host string, res *http.Response, err error) {
+	failed := cb.config.IsFailure(res, err)
+
+	var changes []slog.Attr
+
+	cb.mu.Lock()
+	defer func() { cb.unlock(st, ctx, changes) }()
+
+	b := cb.hosts[host]
+	b.probing = false
+
+	// A cancelled trial request leaves the circuit half open for the next
+	if !failed && errors.Is(err, context.Canceled) {
+		return
+	}
+
+	b.requests++
+
+	if !failed {
+		b.consecutiveFailures = 0
+		if b.state != circuitClosed {
+			changes = append(changes, cb.transition(host, b, circuitClosed))
+		}
+
+		return
+	}
+
+	b.failures++
+	b.consecutiveFailures++
+
+	if b.state == circuitHalfOpen || (b.state == circuitClosed && b.consecutiveFailures >= cb.config.FailureThreshold) {
+		b.openedAt = time.Now()
+		changes = append(changes, cb.transition(host, b, circuitOpen))
+	}
+}
+
+// transition moves host's breaker b to state to, returning the attribute
+// describing the change for unlock to log.
+func (cb *circuitBreakers) transition(host string, b *circuitBreaker, to circuitState) slog.Attr {
+	from := b.state
+	b.state = to
+
+	return slog.Group("circuit_breaker",
+		slog.String("host", host),
+		slog.String("from", string(from)),
+		slog.String("to", string(to)),
+		slog.Int("consecutive_failures", b.consecutiveFailures),
+		slog.Int("failures", b.failures),
+		slog.Int("requests", b.requests),
+	)
+}
+
+// unlock releases cb.mu, then logs the state changes made while holding it.
+// They're logged after, as logging may send a request through the breaker.
+func (cb *circuitBreakers) unlock(st *SlogTripper, ctx context.Context, changes []slog.Attr) {
+	cb.mu.Unlock()
+
+	for _, change := range changes {
+		st.log(ctx, "HTTP Circuit Breaker", change)
+	}
+}
+
+// sendWithBreaker sends req through the proxied transport, unless the
+// circuit breaker for its host is open.
+func (st *SlogTripper) sendWithBreaker(req *http.Request) (*http.Response, error) {
+	host := requestHost(req)
+
+	if !st.breakers.allow(st, req.Context(), host) {
+		return nil, fmt.Errorf("slogtripper: %s: %w", host, ErrCircuitOpen)
+	}
+
+	res, err := st.proxyTransport.RoundTrip(req)
+	st.breakers.record(st, req.Context(), host, res, err)
+
+	return res, err
+}
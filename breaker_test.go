@@ -0,0 +1,166 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var output bytes.Buffer
+
+	failing := true
+	calls := 0
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				calls++
+				if failing {
+					return &http.Response{StatusCode: http.StatusBadGateway}, nil
+				}
+
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithCircuitBreaker(CircuitBreakerConfig{
+			FailureThreshold: 2,
+			Cooldown:         20 * time.Millisecond,
+		}),
+	)
+
+	get := func(host string) error {
+		_, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://"+host+"/", nil)))
+		return err
+	}
+
+	_ = get("flaky")
+	_ = get("flaky")
+
+	if err := get("flaky"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("Open circuit should not call the transport, got %d calls", calls)
+	}
+
+	// Other hosts have their own breaker
+	if err := get("healthy"); err != nil {
+		t.Errorf("Unexpected error for another host: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	failing = false
+
+	if err := get("flaky"); err != nil {
+		t.Errorf("Trial request should be let through after the cooldown: %v", err)
+	}
+
+	out := output.String()
+	for _, transition := range []string{`"from":"closed","to":"open"`, `"from":"open","to":"half-open"`, `"from":"half-open","to":"closed"`} {
+		if !strings.Contains(out, transition) {
+			t.Errorf("Transition %s not logged: %s", transition, out)
+		}
+	}
+
+	if !strings.Contains(out, `"message":"slogtripper: flaky: circuit breaker open"`) {
+		t.Errorf("Refused request not logged: %s", out)
+	}
+}
+
+func TestCircuitBreakerCancelled(t *testing.T) {
+	var upstreamErr error
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return nil, upstreamErr
+			},
+		}),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2}),
+	)
+
+	get := func() error {
+		_, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://api.example/", nil)))
+		return err
+	}
+
+	// Callers giving up on a healthy host don't open its circuit
+	upstreamErr = context.Canceled
+	for i := 0; i < 3; i++ {
+		if err := get(); errors.Is(err, ErrCircuitOpen) {
+			t.Fatal("Expected cancellations not counted as failures")
+		}
+	}
+
+	// But a host which keeps running out the callers' deadlines is failing
+	upstreamErr = context.DeadlineExceeded
+	_ = get()
+	_ = get()
+
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected deadlines counted as failures, got %v", err)
+	}
+}
+
+func TestCircuitBreakerLogSink(t *testing.T) {
+	var output bytes.Buffer
+	var shipped atomic.Int32
+
+	handler := &shippingHandler{
+		Handler: slog.NewJSONHandler(&output, nil),
+		url:     "http://logs.collector.example/ingest",
+		shipped: &shipped,
+	}
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(handler)),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Host == "flaky" {
+					return &http.Response{StatusCode: http.StatusBadGateway, Body: http.NoBody}, nil
+				}
+
+				return &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody}, nil
+			},
+		}),
+		WithLogSinkHosts("logs.collector.example"),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1}),
+	)
+	handler.client = &http.Client{Transport: st}
+
+	done := make(chan error)
+	go func() {
+		_, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://flaky/", nil)))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Deadlocked shipping the circuit breaker's record through the tripper")
+	}
+
+	if !strings.Contains(output.String(), `"to":"open"`) {
+		t.Errorf("Expected the circuit opening logged: %s", output.String())
+	}
+
+	// Retrying a request the open circuit refused would only be refused again
+	_, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://flaky/", nil)))
+	if !errors.Is(err, ErrCircuitOpen) || DefaultRetryIf(nil, err) {
+		t.Errorf("Expected an open circuit's refusal not retried, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CaptureCacheHeaders logs a response's caching headers as a cache group,
+// along with whether it was a 304 Not Modified and, for a conditional
+// request answered with a 304, that it was revalidated.
+func CaptureCacheHeaders() Option {
+	return WithCapturer(cacheCapturer{})
+}
+
+type cacheCapturer struct{}
+
+func (cacheCapturer) CaptureRequest(req *http.Request) []slog.Attr {
+	return nil
+}
+
+func (cacheCapturer) CaptureResponse(res *http.Response) []slog.Attr {
+	notModified := res.StatusCode == http.StatusNotModified
+
+	attrs := []any{slog.Bool("not_modified", notModified)}
+
+	if v := res.Header.Get("ETag"); v != "" {
+		attrs = append(attrs, slog.String("etag", v))
+	}
+
+	if v := res.Header.Get("Cache-Control"); v != "" {
+		attrs = append(attrs, slog.String("cache_control", v))
+	}
+
+	if v, err := strconv.ParseInt(res.Header.Get("Age"), 10, 64); err == nil {
+		attrs = append(attrs, slog.Duration("age", time.Duration(v)*time.Second))
+	}
+
+	if v, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil {
+		attrs = append(attrs, slog.Time("last_modified", v))
+	}
+
+	// The transport links the request, a conditional one answered with a 304
+	// means our cached copy was revalidated
+	if req := res.Request; req != nil {
+		conditional := req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+		attrs = append(attrs, slog.Bool("revalidated", conditional && notModified))
+	}
+
+	return []slog.Attr{slog.Group("cache", attrs...)}
+}
@@ -0,0 +1,44 @@
+package slogtripper
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCaptureCacheHeaders(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusNotModified,
+					Request:    r,
+					Header: http.Header{
+						"Etag":          []string{`"abc"`},
+						"Cache-Control": []string{"max-age=60"},
+						"Age":           []string{"12"},
+						"Last-Modified": []string{"Tue, 14 Nov 2023 22:13:20 GMT"},
+					},
+				}, nil
+			},
+		}),
+		CaptureCacheHeaders(),
+	)
+
+	req := Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))
+	req.Header.Set("If-None-Match", `"abc"`)
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	expected := `"cache":{"not_modified":true,"etag":"\"abc\"","cache_control":"max-age=60","age":12000000000,"last_modified":"2023-11-14T22:13:20Z","revalidated":true}`
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("Expected %s in log: %s", expected, output.String())
+	}
+}
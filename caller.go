@@ -0,0 +1,98 @@
+package slogtripper
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+)
+
+// WithCaller logs where each request was made from as a caller group of
+// function, file and line, so call sites hammering an endpoint can be told
+// apart. Frames in net/http and this package are passed over, then skip more,
+// for helpers which wrap an http.Client and would otherwise be named for
+// every request.
+func WithCaller(skip int) Option {
+	return func(st *SlogTripper) {
+		if skip < 0 {
+			st.invalid("negative caller skip %d", skip)
+			return
+		}
+
+		st.caller = true
+		st.callerSkip = skip
+	}
+}
+
+// WithErrorStacks logs the stack which made a request alongside the error of
+// every round trip which fails with one, so sporadic network failures can be
+// traced to the code path behind them. The stack is trimmed to the frames
+// outside net/http and this package, at most maxStackFrames of them, logged
+// as stack in the response group.
+func WithErrorStacks() Option {
+	return func(st *SlogTripper) {
+		st.errorStacks = true
+	}
+}
+
+const (
+	packagePrefix  = "github.com/b1scuit/slogtripper."
+	maxStackFrames = 32
+)
+
+// callerAttr describes the frame which made the request being sent.
+func (st *SlogTripper) callerAttr() (slog.Attr, bool) {
+	frames := externalFrames(st.callerSkip + 1)
+	if len(frames) <= st.callerSkip {
+		return slog.Attr{}, false
+	}
+
+	frame := frames[st.callerSkip]
+
+	return slog.Group("caller",
+		slog.String("function", frame.Function),
+		slog.String("file", frame.File),
+		slog.Int("line", frame.Line),
+	), true
+}
+
+// stackAttr describes the stack which made the request being sent.
+func stackAttr() slog.Attr {
+	frames := externalFrames(maxStackFrames)
+
+	stack := make([]string, len(frames))
+	for i, frame := range frames {
+		stack[i] = fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line)
+	}
+
+	return slog.Any("stack", stack)
+}
+
+// externalFrames returns up to limit frames of the current goroutine's stack,
+// outermost last, leaving out those of net/http, this package and the
+// runtime.
+func externalFrames(limit int) []runtime.Frame {
+	var pcs [128]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var external []runtime.Frame
+	for len(external) < limit {
+		frame, more := frames.Next()
+
+		internal := frame.Function == "" ||
+			strings.HasPrefix(frame.Function, "net/http.") ||
+			strings.HasPrefix(frame.Function, "runtime.") ||
+			(strings.HasPrefix(frame.Function, packagePrefix) && !strings.HasSuffix(frame.File, "_test.go"))
+
+		if !internal {
+			external = append(external, frame)
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return external
+}
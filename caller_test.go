@@ -0,0 +1,118 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// getUser stands in for a client helper used from many call sites.
+func getUser(c *http.Client) {
+	res, err := c.Get("http://localhost/users/1")
+	if err == nil {
+		res.Body.Close()
+	}
+}
+
+func TestWithCaller(t *testing.T) {
+	for _, tt := range []struct {
+		skip     int
+		function string
+	}{
+		{skip: 0, function: "slogtripper.getUser"},
+		{skip: 1, function: "slogtripper.TestWithCaller"},
+	} {
+		var buf bytes.Buffer
+
+		c := &http.Client{Transport: NewSlogTripper(
+			WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+			WithRoundTripper(&MockRoundTripper{
+				MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+				},
+			}),
+			WithCaller(tt.skip),
+		)}
+
+		getUser(c)
+		_, _, line, _ := runtime.Caller(0)
+
+		var record struct {
+			Request struct {
+				Caller struct {
+					Function string `json:"function"`
+					File     string `json:"file"`
+					Line     int    `json:"line"`
+				} `json:"caller"`
+			} `json:"request"`
+		}
+
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+
+		caller := record.Request.Caller
+		if !strings.HasSuffix(caller.Function, tt.function) || !strings.HasSuffix(caller.File, "caller_test.go") {
+			t.Errorf("Unexpected caller: %+v", caller)
+		}
+
+		if tt.skip == 1 && caller.Line != line-1 {
+			t.Errorf("Expected line %d, got %d", line-1, caller.Line)
+		}
+	}
+}
+
+func TestWithErrorStacks(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &http.Client{Transport: NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path == "/error" {
+					return nil, errors.New("connection reset by peer")
+				}
+
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			},
+		}),
+		WithErrorStacks(),
+	)}
+
+	for _, path := range []string{"/ok", "/error"} {
+		if res, err := c.Get("http://localhost" + path); err == nil {
+			res.Body.Close()
+		}
+	}
+
+	dec := json.NewDecoder(&buf)
+	for _, path := range []string{"/ok", "/error"} {
+		var record struct {
+			Response struct {
+				Stack []string `json:"stack"`
+			} `json:"response"`
+		}
+
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+
+		stack := record.Response.Stack
+		if path == "/ok" {
+			if stack != nil {
+				t.Errorf("Expected no stack for a success, got %q", stack)
+			}
+
+			continue
+		}
+
+		if len(stack) == 0 || !strings.Contains(stack[0], "slogtripper.TestWithErrorStacks") || !strings.Contains(stack[0], "caller_test.go:") {
+			t.Errorf("Unexpected stack: %q", stack)
+		}
+	}
+}
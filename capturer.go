@@ -0,0 +1,27 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Capturer contributes attributes to the request and response groups, so new
+// details (i.e. vendor request IDs) can be logged without changing RoundTrip.
+// CaptureResponse is only called when there's a response.
+type Capturer interface {
+	CaptureRequest(req *http.Request) []slog.Attr
+	CaptureResponse(res *http.Response) []slog.Attr
+}
+
+// WithCapturer adds c to the tripper's capturers, which run in the order they
+// were added.
+func WithCapturer(c Capturer) Option {
+	return func(st *SlogTripper) {
+		if c == nil {
+			st.invalid("nil capturer")
+			return
+		}
+
+		st.capturers = append(st.capturers[:len(st.capturers):len(st.capturers)], c)
+	}
+}
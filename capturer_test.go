@@ -0,0 +1,51 @@
+package slogtripper
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type requestIDCapturer struct{}
+
+func (requestIDCapturer) CaptureRequest(req *http.Request) []slog.Attr {
+	return []slog.Attr{slog.String("client_id", req.Header.Get("X-Client-Id"))}
+}
+
+func (requestIDCapturer) CaptureResponse(res *http.Response) []slog.Attr {
+	return []slog.Attr{slog.Group("vendor", slog.String("request_id", res.Header.Get("X-Request-Id")))}
+}
+
+func TestWithCapturer(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"X-Request-Id": []string{"abc123"}},
+				}, nil
+			},
+		}),
+		WithCapturer(requestIDCapturer{}),
+	)
+
+	req := Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))
+	req.Header.Set("X-Client-Id", "billing")
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"client_id":"billing"`) {
+		t.Errorf("Log does not contain request attrs: %s", output.String())
+	}
+
+	if !strings.Contains(output.String(), `"vendor":{"request_id":"abc123"}`) {
+		t.Errorf("Log does not contain response attrs: %s", output.String())
+	}
+}
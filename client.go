@@ -0,0 +1,45 @@
+package slogtripper
+
+import (
+	"net/http"
+	"time"
+)
+
+// WithTimeout sets the http.Client Timeout used by NewClient. It has no effect
+// on a SlogTripper used directly as a transport.
+func WithTimeout(d time.Duration) Option {
+	return func(st *SlogTripper) {
+		st.clientTimeout = d
+	}
+}
+
+// NewClient returns an http.Client using a SlogTripper built from opts as its
+// Transport.
+func NewClient(opts ...Option) *http.Client {
+	st := NewSlogTripper(opts...)
+
+	return &http.Client{
+		Transport: st,
+		Timeout:   st.clientTimeout,
+	}
+}
+
+// WrapClient returns a shallow copy of c whose Transport is wrapped in a
+// SlogTripper. An existing Transport on c is kept as the proxied transport, a
+// nil Transport falls back to http.DefaultTransport. A nil c is treated as an
+// empty http.Client.
+func WrapClient(c *http.Client, opts ...Option) *http.Client {
+	wrapped := &http.Client{}
+	if c != nil {
+		*wrapped = *c
+	}
+
+	transport := wrapped.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	wrapped.Transport = NewSlogTripper(append([]Option{WithRoundTripper(transport)}, opts...)...)
+
+	return wrapped
+}
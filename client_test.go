@@ -0,0 +1,96 @@
+package slogtripper
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWrapClient(t *testing.T) {
+	called := false
+	mrt := &MockRoundTripper{
+		MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+			called = true
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		},
+	}
+
+	original := &http.Client{
+		Transport: mrt,
+		Timeout:   5 * time.Second,
+	}
+
+	wrapped := WrapClient(original)
+
+	if wrapped == original {
+		t.Error("WrapClient returned the original client")
+	}
+
+	if original.Transport != mrt {
+		t.Error("WrapClient modified the original client")
+	}
+
+	if wrapped.Timeout != original.Timeout {
+		t.Errorf("Timeout not preserved, got %v", wrapped.Timeout)
+	}
+
+	st, ok := wrapped.Transport.(*SlogTripper)
+	if !ok {
+		t.Fatalf("Transport is %T, expected *SlogTripper", wrapped.Transport)
+	}
+
+	if st.proxyTransport != mrt {
+		t.Error("Existing transport was not kept as the proxied transport")
+	}
+
+	if _, err := wrapped.Get("http://localhost/"); err != nil {
+		t.Errorf("Error in request: %v", err)
+	}
+
+	if !called {
+		t.Error("Existing transport was not called")
+	}
+}
+
+func TestWrapNilClient(t *testing.T) {
+	wrapped := WrapClient(nil)
+
+	st, ok := wrapped.Transport.(*SlogTripper)
+	if !ok {
+		t.Fatalf("Transport is %T, expected *SlogTripper", wrapped.Transport)
+	}
+
+	if st.proxyTransport == nil {
+		t.Error("Proxied transport should default to http.DefaultTransport")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	mrt := &MockRoundTripper{
+		MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		},
+	}
+
+	client := NewClient(
+		WithRoundTripper(mrt),
+		WithTimeout(3*time.Second),
+	)
+
+	if client.Timeout != 3*time.Second {
+		t.Errorf("Unexpected timeout: %v", client.Timeout)
+	}
+
+	if _, ok := client.Transport.(*SlogTripper); !ok {
+		t.Fatalf("Transport is %T, expected *SlogTripper", client.Transport)
+	}
+
+	res, err := client.Get("http://localhost/")
+	if err != nil {
+		t.Fatalf("Error in request: %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status code: %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,35 @@
+package slogtripper
+
+import "time"
+
+// Clock tells the time a round trip started and finished.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a func to a Clock.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// WithClock times round trips with c rather than the system clock, so tests
+// can assert on started_at, finished_at and time_taken. Lower level timings,
+// such as phases and throughputs, aren't affected.
+func WithClock(c Clock) Option {
+	return func(st *SlogTripper) {
+		if c == nil {
+			st.invalid("nil clock")
+			return
+		}
+
+		st.clock = c
+	}
+}
@@ -0,0 +1,55 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithClock(t *testing.T) {
+	var buf bytes.Buffer
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tick := func() time.Time {
+		now = now.Add(250 * time.Millisecond)
+		return now
+	}
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithClock(ClockFunc(tick)),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost", nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	var record struct {
+		Request struct {
+			StartedAt time.Time `json:"started_at"`
+		} `json:"request"`
+		Response struct {
+			TimeTaken  time.Duration `json:"time_taken"`
+			FinishedAt time.Time     `json:"finished_at"`
+		} `json:"response"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 250_000_000, time.UTC)
+	if !record.Request.StartedAt.Equal(start) ||
+		!record.Response.FinishedAt.Equal(start.Add(250*time.Millisecond)) ||
+		record.Response.TimeTaken != 250*time.Millisecond {
+		t.Errorf("Unexpected timings: %s", buf.String())
+	}
+}
@@ -0,0 +1,177 @@
+package slogtripper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// NewCombinedLogHandler returns a slog.Handler writing the tripper's request
+// records to w as lines of the Combined Log Format Apache and NGINX use for
+// access logs, so existing parsers keep working:
+//
+//	127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"
+//
+// The client address is the remote_addr the Middleware logs, "-" for
+// outbound requests. The referer and user agent are "-" unless request
+// headers are captured. Records other than requests are dropped, as are
+// those below level, which may be nil for slog.LevelInfo.
+func NewCombinedLogHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+
+	return &combinedLogHandler{w: w, mu: new(sync.Mutex), level: level}
+}
+
+type combinedLogHandler struct {
+	w     io.Writer
+	mu    *sync.Mutex
+	level slog.Leveler
+
+	attrs  []slog.Attr
+	groups []string
+}
+
+func (h *combinedLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *combinedLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = append(c.attrs[:len(c.attrs):len(c.attrs)], nestAttrs(h.groups, attrs)...)
+
+	return &c
+}
+
+func (h *combinedLogHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.groups = append(c.groups[:len(c.groups):len(c.groups)], name)
+
+	return &c
+}
+
+// nestAttrs puts attrs inside groups.
+func nestAttrs(groups []string, attrs []slog.Attr) []slog.Attr {
+	for i := len(groups) - 1; i >= 0; i-- {
+		args := make([]any, len(attrs))
+		for j, attr := range attrs {
+			args[j] = attr
+		}
+
+		attrs = []slog.Attr{slog.Group(groups[i], args...)}
+	}
+
+	return attrs
+}
+
+func (h *combinedLogHandler) Handle(_ context.Context, r slog.Record) error {
+	var attrs []slog.Attr
+	r.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+
+	fields := map[string]slog.Value{}
+	for _, attr := range append(h.attrs, nestAttrs(h.groups, attrs)...) {
+		collectFields(fields, "", attr)
+	}
+
+	// Records may be nested under a group name, or flattened
+	field := func(name string) (slog.Value, bool) {
+		flat := strings.ReplaceAll(name, ".", "_")
+		if v, ok := fields[name]; ok {
+			return v, true
+		}
+
+		if v, ok := fields[flat]; ok {
+			return v, true
+		}
+
+		for key, v := range fields {
+			if strings.HasSuffix(key, "."+name) || strings.HasSuffix(key, "_"+flat) {
+				return v, true
+			}
+		}
+
+		return slog.Value{}, false
+	}
+
+	str := func(name string) string {
+		if v, ok := field(name); ok && v.String() != "" {
+			return v.String()
+		}
+
+		return "-"
+	}
+
+	method, ok := field("request.method")
+	if !ok {
+		return nil
+	}
+
+	t := r.Time
+	if v, ok := field("request.started_at"); ok && v.Kind() == slog.KindTime {
+		t = v.Time()
+	}
+
+	host := str("request.remote_addr")
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	status := "-"
+	if v, ok := field("response.status_code"); ok && v.Kind() == slog.KindInt64 {
+		status = strconv.FormatInt(v.Int64(), 10)
+	}
+
+	size := "-"
+	for _, name := range []string{"response.bytes_received", "response.content_length"} {
+		if v, ok := field(name); ok && v.Kind() == slog.KindInt64 && v.Int64() > 0 {
+			size = strconv.FormatInt(v.Int64(), 10)
+			break
+		}
+	}
+
+	line := fmt.Sprintf("%s - - [%s] %s %s %s %s %s\n",
+		host,
+		t.Format("02/Jan/2006:15:04:05 -0700"),
+		strconv.Quote(method.String()+" "+str("request.url")+" "+str("request.proto")),
+		status,
+		size,
+		strconv.Quote(str("request.headers.Referer")),
+		strconv.Quote(str("request.headers.User-Agent")),
+	)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := io.WriteString(h.w, line)
+
+	return err
+}
+
+// collectFields flattens attr into fields, keyed by its dotted group path.
+func collectFields(fields map[string]slog.Value, prefix string, attr slog.Attr) {
+	key := attr.Key
+	if prefix != "" && key != "" {
+		key = prefix + "." + key
+	} else if key == "" {
+		key = prefix
+	}
+
+	// Values are left unresolved, so bodies nobody logs aren't decoded
+	if attr.Value.Kind() != slog.KindGroup {
+		fields[key] = attr.Value
+		return
+	}
+
+	for _, a := range attr.Value.Group() {
+		collectFields(fields, key, a)
+	}
+}
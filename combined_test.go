@@ -0,0 +1,40 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestCombinedLogHandler(t *testing.T) {
+	var output bytes.Buffer
+
+	logger := slog.New(NewCombinedLogHandler(&output, nil))
+
+	handler := Middleware(
+		WithLogger(logger),
+		CaptureRequestHeaders(),
+		WithGroupName("http"),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/missing?page=2", nil)
+	req.Header.Set("Referer", "http://example.com/start")
+	req.Header.Set("User-Agent", "curl/8.0")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Records other than requests are dropped
+	logger.Log(context.Background(), slog.LevelInfo, "HTTP Summary")
+
+	line := regexp.MustCompile(`^192\.0\.2\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /missing\?page=2 HTTP/1\.1" 404 9 "http://example\.com/start" "curl/8\.0"\n$`)
+	if !line.MatchString(output.String()) {
+		t.Errorf("Unexpected log line: %q", output.String())
+	}
+}
@@ -0,0 +1,139 @@
+package slogtripper
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+)
+
+// WithConditionalRequests remembers the ETag and Last-Modified headers of
+// the last successful response to a GET, for up to size URLs, and sends them
+// back as If-None-Match and If-Modified-Since with the next GET to the same
+// URL which has no conditional headers of its own. When nothing has changed
+// the upstream answers 304 Not Modified without a body, cutting the
+// bandwidth of polling clients, which must treat a 304 as no change.
+//
+// Requests the headers were added to are logged with conditional=true, and
+// their status_code shows whether the upstream returned 304. WithCache
+// revalidates the responses it keeps itself, so isn't meant to be used with
+// this.
+func WithConditionalRequests(size int) Option {
+	return func(st *SlogTripper) {
+		if size <= 0 {
+			st.invalid("conditional requests size %d", size)
+			return
+		}
+
+		st.conditional = newValidatorStore(size)
+	}
+}
+
+// validators are the headers a response can be revalidated with.
+type validators struct {
+	url          string
+	etag         string
+	lastModified string
+}
+
+// validatorStore is an LRU of the validators of the last response to each
+// URL.
+type validatorStore struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+func newValidatorStore(size int) *validatorStore {
+	return &validatorStore{size: size, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+func (s *validatorStore) get(url string) (validators, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[url]
+	if !ok {
+		return validators{}, false
+	}
+
+	s.lru.MoveToFront(el)
+
+	return el.Value.(validators), true
+}
+
+func (s *validatorStore) put(v validators) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[v.url]; ok {
+		el.Value = v
+		s.lru.MoveToFront(el)
+
+		return
+	}
+
+	s.entries[v.url] = s.lru.PushFront(v)
+
+	for s.lru.Len() > s.size {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(validators).url)
+	}
+}
+
+func (s *validatorStore) remove(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[url]; ok {
+		s.lru.Remove(el)
+		delete(s.entries, url)
+	}
+}
+
+// sendConditional sends req through send, made conditional on the last
+// response to its URL if there was one.
+func (st *SlogTripper) sendConditional(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != "" || isConditional(req) {
+		return send(req)
+	}
+
+	key := req.URL.String()
+
+	if v, ok := st.conditional.get(key); ok {
+		conditional := req.WithContext(req.Context())
+		conditional.Header = req.Header.Clone()
+		if conditional.Header == nil {
+			conditional.Header = make(http.Header)
+		}
+
+		if v.etag != "" {
+			conditional.Header.Set("If-None-Match", v.etag)
+		}
+
+		if v.lastModified != "" {
+			conditional.Header.Set("If-Modified-Since", v.lastModified)
+		}
+
+		if n := notesFrom(req.Context()); n != nil {
+			n.conditional = true
+		}
+
+		req = conditional
+	}
+
+	res, err := send(req)
+	if err != nil || res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return res, err
+	}
+
+	v := validators{url: key, etag: res.Header.Get("ETag"), lastModified: res.Header.Get("Last-Modified")}
+	if v.etag == "" && v.lastModified == "" {
+		st.conditional.remove(key)
+	} else {
+		st.conditional.put(v)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,100 @@
+package slogtripper
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWithConditionalRequests(t *testing.T) {
+	var output syncBuffer
+	var sent []string
+
+	etag := `"v1"`
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				sent = append(sent, r.Header.Get("If-None-Match")+"|"+r.Header.Get("If-Modified-Since"))
+
+				if r.Header.Get("If-None-Match") == etag {
+					return &http.Response{StatusCode: http.StatusNotModified, Header: http.Header{}, Body: http.NoBody}, nil
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Etag": {etag}, "Last-Modified": {"Mon, 01 Jan 2024 00:00:00 GMT"}},
+					Body:       io.NopCloser(strings.NewReader("state")),
+				}, nil
+			},
+		}),
+		WithConditionalRequests(10),
+	)
+
+	var statuses []int
+	for i := 0; i < 3; i++ {
+		if i == 2 {
+			etag = `"v2"`
+		}
+
+		res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/poll", nil)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		statuses = append(statuses, res.StatusCode)
+	}
+
+	if want := []int{http.StatusOK, http.StatusNotModified, http.StatusOK}; !slices.Equal(statuses, want) {
+		t.Errorf("Expected %v, got %v", want, statuses)
+	}
+
+	conditional := `"v1"|Mon, 01 Jan 2024 00:00:00 GMT`
+	if want := []string{"|", conditional, conditional}; !slices.Equal(sent, want) {
+		t.Errorf("Expected %q sent, got %q", want, sent)
+	}
+
+	var logged []bool
+	for _, line := range output.Lines() {
+		var record struct {
+			Request struct {
+				Conditional bool `json:"conditional"`
+			} `json:"request"`
+		}
+
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+
+		logged = append(logged, record.Request.Conditional)
+	}
+
+	if want := []bool{false, true, true}; !slices.Equal(logged, want) {
+		t.Errorf("Expected conditional %v logged, got %v", want, logged)
+	}
+
+	// The caller's own conditional headers are left alone
+	req := Must(http.NewRequest(http.MethodGet, "http://localhost/poll", nil))
+	req.Header.Set("If-None-Match", `"mine"`)
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if last := sent[len(sent)-1]; last != `"mine"|` {
+		t.Errorf("Expected the caller's headers sent, got %q", last)
+	}
+}
+
+func TestWithConditionalRequestsInvalid(t *testing.T) {
+	if _, err := NewSlogTripperE(WithConditionalRequests(-1)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected an invalid option, got %v", err)
+	}
+}
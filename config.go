@@ -0,0 +1,112 @@
+package slogtripper
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Config holds the declarative settings of a SlogTripper, for applications
+// which load their configuration from files. Anything which can't be
+// expressed as data (loggers, transports, funcs) is still set with Options.
+type Config struct {
+	Level    slog.Level `json:"level" yaml:"level"`
+	Disabled bool       `json:"disabled" yaml:"disabled"`
+
+	CaptureRequestBody     bool `json:"capture_request_body" yaml:"capture_request_body"`
+	CaptureResponseBody    bool `json:"capture_response_body" yaml:"capture_response_body"`
+	CaptureRequestHeaders  bool `json:"capture_request_headers" yaml:"capture_request_headers"`
+	CaptureResponseHeaders bool `json:"capture_response_headers" yaml:"capture_response_headers"`
+
+	MaxBodySize int64 `json:"max_body_size" yaml:"max_body_size"`
+
+	// Timeout is only used by NewClient
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+
+	// Hosts and Methods are complete configs of their own, they don't inherit
+	// the settings above
+	Hosts   []HostConfig   `json:"hosts" yaml:"hosts"`
+	Methods []MethodConfig `json:"methods" yaml:"methods"`
+}
+
+// Duration is a time.Duration read from configuration as a string
+// time.ParseDuration accepts, such as "5s" or "1m30s". JSON may give a number
+// of nanoseconds instead.
+type Duration time.Duration
+
+// MarshalText writes d as time.Duration's String does.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText reads d with time.ParseDuration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+
+	return nil
+}
+
+// UnmarshalJSON reads d from a string, or a number of nanoseconds.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var n int64
+	if err := json.Unmarshal(b, &n); err == nil {
+		*d = Duration(n)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("slogtripper: duration %s isn't a string or a number", b)
+	}
+
+	return d.UnmarshalText([]byte(s))
+}
+
+// HostConfig is the Config used for requests to Host, see WithHostConfig.
+type HostConfig struct {
+	Host   string `json:"host" yaml:"host"`
+	Config `yaml:",inline"`
+}
+
+// MethodConfig is the Config used for requests using one of Methods, see
+// ForMethods.
+type MethodConfig struct {
+	Methods []string `json:"methods" yaml:"methods"`
+	Config  `yaml:",inline"`
+}
+
+// WithConfig applies every setting in c.
+func WithConfig(c Config) Option {
+	return c.apply
+}
+
+// NewFromConfig creates a SlogTripper from c, opts are applied afterwards.
+func NewFromConfig(c Config, opts ...Option) *SlogTripper {
+	return NewSlogTripper(append([]Option{WithConfig(c)}, opts...)...)
+}
+
+func (c Config) apply(st *SlogTripper) {
+	WithLoggingLevel(c.Level)(st)
+
+	st.disabled = newBool(c.Disabled)
+	st.captureRequestBody = newBool(c.CaptureRequestBody)
+	st.captureResponseBody = newBool(c.CaptureResponseBody)
+	st.captureRequestHeaders = newBool(c.CaptureRequestHeaders)
+	st.captureResponseHeaders = newBool(c.CaptureResponseHeaders)
+	WithMaxBodySize(c.MaxBodySize)(st)
+	st.clientTimeout = time.Duration(c.Timeout)
+
+	for _, h := range c.Hosts {
+		WithHostConfig(h.Host, h.Config.apply)(st)
+	}
+
+	for _, m := range c.Methods {
+		ForMethods(m.Methods...)(m.Config.apply)(st)
+	}
+}
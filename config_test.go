@@ -0,0 +1,95 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	raw := `{
+		"level": "DEBUG",
+		"capture_request_headers": true,
+		"max_body_size": 512,
+		"hosts": [
+			{"host": "metrics.example.com", "disabled": true}
+		],
+		"methods": [
+			{"methods": ["POST"], "level": "WARN", "capture_request_body": true}
+		]
+	}`
+
+	var c Config
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		t.Fatalf("Error unmarshalling config: %v", err)
+	}
+
+	var output bytes.Buffer
+
+	st := NewFromConfig(c,
+		WithLogger(slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+	)
+
+	if st.logAtLevel.Level() != slog.LevelDebug || !st.captureRequestHeaders.Load() || st.maxBodySize != 512 {
+		t.Errorf("Config not applied: level=%v headers=%v max=%d", st.logAtLevel.Level(), st.captureRequestHeaders.Load(), st.maxBodySize)
+	}
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://metrics.example.com/", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if output.Len() != 0 {
+		t.Errorf("Disabled host was logged: %s", output.String())
+	}
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodPost, "http://localhost/", strings.NewReader("payload")))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"level":"WARN"`) || !strings.Contains(output.String(), `"body_content":"payload"`) {
+		t.Errorf("Method config not applied: %s", output.String())
+	}
+}
+
+func TestConfigTimeout(t *testing.T) {
+	for raw, want := range map[string]time.Duration{
+		`{"timeout": "5s"}`:       5 * time.Second,
+		`{"timeout": "1m30s"}`:    90 * time.Second,
+		`{"timeout": 2000000000}`: 2 * time.Second,
+	} {
+		var c Config
+		if err := json.Unmarshal([]byte(raw), &c); err != nil {
+			t.Errorf("%s: %v", raw, err)
+			continue
+		}
+
+		if client := NewClient(WithConfig(c)); client.Timeout != want {
+			t.Errorf("%s: expected a timeout of %v, got %v", raw, want, client.Timeout)
+		}
+	}
+
+	for _, raw := range []string{`{"timeout": "soon"}`, `{"timeout": true}`} {
+		var c Config
+		if err := json.Unmarshal([]byte(raw), &c); err == nil {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+
+	b, err := json.Marshal(Config{Timeout: Duration(5 * time.Second)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), `"timeout":"5s"`) {
+		t.Errorf("Expected the timeout marshalled as a string: %s", b)
+	}
+}
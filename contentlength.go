@@ -0,0 +1,45 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// CheckContentLength counts the bytes read from each response body against
+// its declared Content-Length, so a body cut short is flagged at the
+// transport rather than surfacing later as a puzzling parse error. A body
+// read to the end at a different length is logged with
+// content_length_mismatch=true, and one whose read failed part way with
+// body_incomplete=true and the error as body_error, both alongside
+// bytes_received.
+//
+// The record for a response with a body is held back until that body has
+// been read to the end or closed, as with WithTransferSizes.
+func CheckContentLength() Option {
+	return func(st *SlogTripper) {
+		st.checkContentLength = true
+	}
+}
+
+// contentLengthAttrs flags a response body that didn't arrive whole.
+func (st *SlogTripper) contentLengthAttrs(res *http.Response, body *countingBody) []slog.Attr {
+	n, _, _ := body.result()
+	err, eof := body.readErr()
+
+	var attrs []slog.Attr
+	switch {
+	case err != nil:
+		attrs = append(attrs, slog.Bool("body_incomplete", true), slog.String("body_error", err.Error()))
+	case eof && res.ContentLength >= 0 && n != res.ContentLength:
+		attrs = append(attrs, slog.Bool("content_length_mismatch", true))
+	default:
+		return nil
+	}
+
+	// WithTransferSizes logs the bytes received already
+	if !st.transferSizes {
+		attrs = append(attrs, slog.Int64("bytes_received", n))
+	}
+
+	return attrs
+}
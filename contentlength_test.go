@@ -0,0 +1,88 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckContentLength(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentLength int64
+		body          io.Reader
+		mismatch      bool
+		incomplete    bool
+	}{
+		{name: "whole", contentLength: 5, body: strings.NewReader("hello")},
+		{name: "chunked", contentLength: -1, body: strings.NewReader("hello")},
+		{name: "short", contentLength: 10, body: strings.NewReader("hello"), mismatch: true},
+		{name: "cut", contentLength: 10, body: io.MultiReader(strings.NewReader("hello"), &errorReader{io.ErrUnexpectedEOF}), incomplete: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			st := NewSlogTripper(
+				WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+				WithRoundTripper(&MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						return &http.Response{
+							StatusCode:    http.StatusOK,
+							ContentLength: tt.contentLength,
+							Body:          io.NopCloser(tt.body),
+						}, nil
+					},
+				}),
+				CheckContentLength(),
+			)
+
+			res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost", nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+
+			var record struct {
+				Response struct {
+					Mismatch      bool   `json:"content_length_mismatch"`
+					Incomplete    bool   `json:"body_incomplete"`
+					BodyError     string `json:"body_error"`
+					BytesReceived *int64 `json:"bytes_received"`
+				} `json:"response"`
+			}
+
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatal(err)
+			}
+
+			r := record.Response
+			if r.Mismatch != tt.mismatch || r.Incomplete != tt.incomplete {
+				t.Errorf("Unexpected record: %s", buf.String())
+			}
+
+			if (tt.mismatch || tt.incomplete) != (r.BytesReceived != nil) {
+				t.Errorf("Unexpected bytes_received in %s", buf.String())
+			}
+
+			if tt.incomplete && r.BodyError != io.ErrUnexpectedEOF.Error() {
+				t.Errorf("Unexpected body_error %q", r.BodyError)
+			}
+		})
+	}
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r *errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,96 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// CaptureCookies logs request cookies and response Set-Cookie directives as
+// cookies and set_cookies groups, with masked values. The raw Cookie and
+// Set-Cookie headers are redacted should headers be captured.
+func CaptureCookies() Option {
+	return func(st *SlogTripper) {
+		st.redactHeader("Cookie", "Set-Cookie")
+		WithCapturer(cookieCapturer{})(st)
+	}
+}
+
+type cookieCapturer struct{}
+
+func (cookieCapturer) CaptureRequest(req *http.Request) []slog.Attr {
+	cookies := req.Cookies()
+	if len(cookies) == 0 {
+		return nil
+	}
+
+	attrs := make([]any, 0, len(cookies))
+	for _, c := range cookies {
+		attrs = append(attrs, slog.String(c.Name, maskValue(c.Value)))
+	}
+
+	return []slog.Attr{slog.Group("cookies", attrs...)}
+}
+
+func (cookieCapturer) CaptureResponse(res *http.Response) []slog.Attr {
+	cookies := res.Cookies()
+	if len(cookies) == 0 {
+		return nil
+	}
+
+	attrs := make([]any, 0, len(cookies))
+	for _, c := range cookies {
+		cookie := []any{slog.String("value", maskValue(c.Value))}
+
+		if c.Domain != "" {
+			cookie = append(cookie, slog.String("domain", c.Domain))
+		}
+
+		if c.Path != "" {
+			cookie = append(cookie, slog.String("path", c.Path))
+		}
+
+		if !c.Expires.IsZero() {
+			cookie = append(cookie, slog.Time("expires", c.Expires))
+		}
+
+		if c.MaxAge != 0 {
+			cookie = append(cookie, slog.Int("max_age", c.MaxAge))
+		}
+
+		cookie = append(cookie,
+			slog.Bool("secure", c.Secure),
+			slog.Bool("http_only", c.HttpOnly),
+		)
+
+		if sameSite := sameSiteString(c.SameSite); sameSite != "" {
+			cookie = append(cookie, slog.String("same_site", sameSite))
+		}
+
+		attrs = append(attrs, slog.Group(c.Name, cookie...))
+	}
+
+	return []slog.Attr{slog.Group("set_cookies", attrs...)}
+}
+
+func sameSiteString(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+
+	return ""
+}
+
+// maskValue keeps enough of a secret to tell values apart without logging
+// it, short values are masked entirely.
+func maskValue(v string) string {
+	if len(v) <= 8 {
+		return "****"
+	}
+
+	return v[:4] + "****"
+}
@@ -0,0 +1,56 @@
+package slogtripper
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCaptureCookies(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header: http.Header{
+						"Set-Cookie": []string{"session=abcdef123456789; Domain=example.com; Path=/; Max-Age=3600; Secure; HttpOnly; SameSite=Lax"},
+					},
+				}, nil
+			},
+		}),
+		CaptureRequestHeaders(),
+		CaptureResponseHeaders(),
+		CaptureCookies(),
+	)
+
+	req := Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))
+	req.AddCookie(&http.Cookie{Name: "prefs", Value: "dark"})
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abcdef123456789"})
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	out := output.String()
+
+	if strings.Contains(out, "abcdef123456789") {
+		t.Errorf("Cookie value leaked: %s", out)
+	}
+
+	if !strings.Contains(out, `"cookies":{"prefs":"****","session":"abcd****"}`) {
+		t.Errorf("Log does not contain request cookies: %s", out)
+	}
+
+	if !strings.Contains(out, `"set_cookies":{"session":{"value":"abcd****","domain":"example.com","path":"/","max_age":3600,"secure":true,"http_only":true,"same_site":"Lax"}}`) {
+		t.Errorf("Log does not contain response cookies: %s", out)
+	}
+
+	if !strings.Contains(out, `"Cookie":"[REDACTED]"`) || !strings.Contains(out, `"Set-Cookie":"[REDACTED]"`) {
+		t.Errorf("Cookie headers not redacted: %s", out)
+	}
+}
@@ -0,0 +1,68 @@
+package slogtripper
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+)
+
+// DatadogSpanFunc returns the IDs of the Datadog span in ctx, if there is
+// one. With dd-trace-go that's
+//
+//	func(ctx context.Context) (uint64, uint64, bool) {
+//		span, ok := tracer.SpanFromContext(ctx)
+//		if !ok {
+//			return 0, 0, false
+//		}
+//
+//		return span.Context().TraceID(), span.Context().SpanID(), true
+//	}
+//
+// using TraceIDLower in place of TraceID from dd-trace-go v2.
+type DatadogSpanFunc func(ctx context.Context) (traceID, spanID uint64, ok bool)
+
+// WithDatadogTrace adds the IDs of the Datadog span f finds in a record's
+// context as dd.trace_id and dd.span_id, the decimal strings Datadog's log
+// and trace correlation expects. They're logged at the top level, outside
+// any WithGroupName group, so the Datadog UI links the record to its trace.
+func WithDatadogTrace(f DatadogSpanFunc) Option {
+	return func(st *SlogTripper) {
+		if f == nil {
+			st.invalid("nil datadog span func")
+			return
+		}
+
+		st.datadog = f
+	}
+}
+
+// WithDatadogTraceKeys renames the attributes WithDatadogTrace logs the trace
+// and span IDs under. An empty key leaves that attribute's name unchanged.
+func WithDatadogTraceKeys(traceKey, spanKey string) Option {
+	return func(st *SlogTripper) {
+		if traceKey != "" {
+			st.datadogTraceKey = traceKey
+		}
+
+		if spanKey != "" {
+			st.datadogSpanKey = spanKey
+		}
+	}
+}
+
+// datadogAttrs returns the trace correlation attributes for ctx.
+func (st *SlogTripper) datadogAttrs(ctx context.Context) []any {
+	if st.datadog == nil || ctx == nil {
+		return nil
+	}
+
+	traceID, spanID, ok := st.datadog(ctx)
+	if !ok {
+		return nil
+	}
+
+	return []any{
+		slog.String(st.datadogTraceKey, strconv.FormatUint(traceID, 10)),
+		slog.String(st.datadogSpanKey, strconv.FormatUint(spanID, 10)),
+	}
+}
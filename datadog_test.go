@@ -0,0 +1,73 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type spanKey struct{}
+
+func TestWithDatadogTrace(t *testing.T) {
+	var output bytes.Buffer
+
+	spans := func(ctx context.Context) (uint64, uint64, bool) {
+		ids, ok := ctx.Value(spanKey{}).([2]uint64)
+		return ids[0], ids[1], ok
+	}
+
+	newTripper := func(opts ...Option) *SlogTripper {
+		return NewSlogTripper(append([]Option{
+			WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+			WithRoundTripper(&MockRoundTripper{
+				MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK}, nil
+				},
+			}),
+			WithGroupName("http"),
+			WithDatadogTrace(spans),
+		}, opts...)...)
+	}
+
+	tests := []struct {
+		name string
+		st   *SlogTripper
+		ctx  context.Context
+		want string
+	}{
+		{
+			name: "span",
+			st:   newTripper(),
+			ctx:  context.WithValue(context.Background(), spanKey{}, [2]uint64{18446744073709551615, 42}),
+			want: `"dd.trace_id":"18446744073709551615","dd.span_id":"42"}`,
+		},
+		{
+			name: "no span",
+			st:   newTripper(),
+			ctx:  context.Background(),
+			want: `}}}`,
+		},
+		{
+			name: "renamed",
+			st:   newTripper(WithDatadogTraceKeys("trace_id", "")),
+			ctx:  context.WithValue(context.Background(), spanKey{}, [2]uint64{1, 2}),
+			want: `"trace_id":"1","dd.span_id":"2"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		output.Reset()
+
+		req := Must(http.NewRequestWithContext(tt.ctx, http.MethodGet, "http://localhost", nil))
+		if _, err := tt.st.RoundTrip(req); err != nil {
+			t.Fatalf("%s: error in roundtrip: %v", tt.name, err)
+		}
+
+		if !strings.HasSuffix(strings.TrimSpace(output.String()), tt.want) {
+			t.Errorf("%s: expected top level %s: %s", tt.name, tt.want, output.String())
+		}
+	}
+}
@@ -0,0 +1,525 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BodyDecoder turns a captured body into a structured value for logging.
+type BodyDecoder func(body []byte) (any, error)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]BodyDecoder{
+		"application/json":                  DecodeJSON,
+		"+json":                             DecodeJSON,
+		"application/xml":                   DecodeXML,
+		"text/xml":                          DecodeXML,
+		"+xml":                              DecodeXML,
+		"application/x-www-form-urlencoded": DecodeForm,
+	}
+)
+
+// RegisterBodyDecoder registers fn for bodies of contentType, replacing any
+// existing decoder. contentType is a media type without parameters, or a
+// structured syntax suffix such as "+json" which matches any media type
+// ending with it. Decoders are only used by trippers created with
+// DecodeBodies.
+func RegisterBodyDecoder(contentType string, fn BodyDecoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	if fn == nil {
+		delete(decoders, strings.ToLower(contentType))
+		return
+	}
+
+	decoders[strings.ToLower(contentType)] = fn
+}
+
+// DecodeBodies logs captured bodies as structured values using the decoder
+// registered for their content type. Bodies without a decoder, which fail to
+// decode or which were truncated are logged as strings.
+func DecodeBodies() Option {
+	return func(st *SlogTripper) {
+		st.decodeBodies = true
+	}
+}
+
+// WithBodyDecoder registers fn for bodies of contentType on this tripper
+// only, taking precedence over RegisterBodyDecoder, and turns on
+// DecodeBodies. Combined with
+// WithHostConfig or ForRequests it decodes per endpoint, i.e. protobuf bodies
+// whose message type depends on the API being called:
+//
+//	slogtripper.ForRequests(isOrdersAPI)(
+//		slogtripper.WithBodyDecoder("application/x-protobuf", slogtripper.DecodeProto(
+//			func() proto.Message { return new(pb.Order) }, proto.Unmarshal, protojson.Marshal,
+//		)),
+//	)
+func WithBodyDecoder(contentType string, fn BodyDecoder) Option {
+	return func(st *SlogTripper) {
+		if fn == nil {
+			st.invalid("nil body decoder for %s", contentType)
+			return
+		}
+
+		decoders := make(map[string]BodyDecoder, len(st.decoders)+1)
+		for k, v := range st.decoders {
+			decoders[k] = v
+		}
+		decoders[strings.ToLower(contentType)] = fn
+
+		st.decoders = decoders
+		st.decodeBodies = true
+	}
+}
+
+// DecodeProto returns a BodyDecoder for protobuf bodies without this package
+// depending on a protobuf runtime. newMessage returns an empty message to
+// unmarshal into, toJSON renders it for logging, i.e. proto.Unmarshal and
+// protojson.Marshal.
+func DecodeProto[M any](newMessage func() M, unmarshal func([]byte, M) error, toJSON func(M) ([]byte, error)) BodyDecoder {
+	return func(body []byte) (any, error) {
+		m := newMessage()
+		if err := unmarshal(body, m); err != nil {
+			return nil, err
+		}
+
+		b, err := toJSON(m)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.RawMessage(b), nil
+	}
+}
+
+func lookupBodyDecoder(contentType string, local map[string]BodyDecoder) BodyDecoder {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	if fn, ok := local[mediaType]; ok {
+		return fn
+	}
+
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+
+	if fn, ok := decoders[mediaType]; ok {
+		return fn
+	}
+
+	if i := strings.LastIndexByte(mediaType, '+'); i != -1 {
+		return decoders[mediaType[i:]]
+	}
+
+	return nil
+}
+
+// RedactXMLElements replaces the content of XML elements and attributes
+// called one of names with a placeholder. XML bodies are logged structured,
+// as with DecodeBodies, so they can be redacted; an XML body which can't be
+// parsed is replaced entirely rather than risk logging its secrets.
+func RedactXMLElements(names ...string) Option {
+	return func(st *SlogTripper) {
+		redact := make(map[string]struct{}, len(st.redactXML)+len(names))
+		for name := range st.redactXML {
+			redact[name] = struct{}{}
+		}
+
+		for _, name := range names {
+			redact[strings.ToLower(name)] = struct{}{}
+		}
+
+		st.redactXML = redact
+	}
+}
+
+// RedactFormFields adds names to the form fields whose values are redacted.
+// Form bodies are always logged as a group of their fields, with
+// password, client_secret, refresh_token, access_token, code, code_verifier,
+// assertion and client_assertion redacted by default.
+func RedactFormFields(names ...string) Option {
+	return func(st *SlogTripper) {
+		redact := make(map[string]struct{}, len(st.redactForm)+len(names))
+		for name := range st.redactForm {
+			redact[name] = struct{}{}
+		}
+
+		for _, name := range names {
+			redact[strings.ToLower(name)] = struct{}{}
+		}
+
+		st.redactForm = redact
+	}
+}
+
+var defaultRedactedFormFields = map[string]struct{}{
+	"password":         {},
+	"client_secret":    {},
+	"refresh_token":    {},
+	"access_token":     {},
+	"code":             {},
+	"code_verifier":    {},
+	"assertion":        {},
+	"client_assertion": {},
+}
+
+const redacted = "[REDACTED]"
+
+// bodyAttrs builds the attributes for a captured body, returning the value
+// of body_content too. The value is resolved lazily, so decoding and
+// redaction only happen if a handler actually logs it. Content that isn't
+// valid UTF-8 is logged in base64 and control characters are escaped, with
+// body_encoding saying which. name identifies the body for WithBodyDump.
+func (st *SlogTripper) bodyAttrs(name, contentType string, content []byte, truncated bool) ([]slog.Attr, slog.Value) {
+	if st.bodyDumpDir != "" {
+		return st.dumpBody(name, content, truncated)
+	}
+
+	if truncated {
+		content = trimPartialRune(content)
+	}
+
+	b := &bodyValue{
+		st:          st,
+		contentType: contentType,
+		content:     string(content),
+		truncated:   truncated,
+	}
+
+	// Form and redacted XML bodies are never logged as they are
+	if !isForm(contentType) && !st.redactsXML(contentType) {
+		b.encoding = bodyEncoding(content)
+	}
+
+	// A binary format with a decoder needs no encoding, if it decodes, so it
+	// has to be decoded now to know
+	if b.encoding != "" && st.decodeBodies && !truncated {
+		if fn := lookupBodyDecoder(contentType, st.decoders); fn != nil {
+			if v, err := fn(content); err == nil {
+				b.once.Do(func() {
+					b.value = st.maskSlogValue(slog.AnyValue(v))
+				})
+				b.encoding = ""
+			}
+		}
+	}
+
+	value := slog.AnyValue(b)
+
+	attrs := []slog.Attr{{Key: "body_content", Value: value}}
+	if b.encoding != "" {
+		attrs = append(attrs, slog.String("body_encoding", b.encoding))
+	}
+
+	if truncated {
+		attrs = append(attrs, slog.Bool("body_truncated", true))
+	}
+
+	return attrs, value
+}
+
+// bodyValue is a captured body, decoded and redacted when first resolved.
+// It keeps its own copy of the content, as the captured bytes may be reused.
+type bodyValue struct {
+	st          *SlogTripper
+	contentType string
+	content     string
+	truncated   bool
+	encoding    string
+
+	once  sync.Once
+	value slog.Value
+}
+
+func (b *bodyValue) LogValue() slog.Value {
+	b.once.Do(func() {
+		switch b.encoding {
+		case "base64":
+			b.value = slog.StringValue(base64.StdEncoding.EncodeToString([]byte(b.content)))
+		case "escaped":
+			b.value = b.st.maskSlogValue(slog.StringValue(escapeControl(b.content)))
+		default:
+			b.value = b.st.maskSlogValue(b.st.bodyValue(b.contentType, b.content, b.truncated))
+		}
+	})
+
+	return b.value
+}
+
+func (st *SlogTripper) bodyValue(contentType string, content string, truncated bool) slog.Value {
+	if isForm(contentType) {
+		return formValue(content, st.redactForm)
+	}
+
+	redactXML := st.redactsXML(contentType)
+
+	if (st.decodeBodies || redactXML) && !truncated {
+		if fn := lookupBodyDecoder(contentType, st.decoders); fn != nil {
+			if v, err := fn([]byte(content)); err == nil {
+				if redactXML {
+					v = redactXMLValue(v, st.redactXML)
+				}
+
+				return slog.AnyValue(v)
+			}
+		}
+	}
+
+	if redactXML {
+		return slog.StringValue(redacted)
+	}
+
+	return slog.StringValue(content)
+}
+
+func isForm(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/x-www-form-urlencoded"
+}
+
+func (st *SlogTripper) redactsXML(contentType string) bool {
+	return len(st.redactXML) != 0 && isXML(contentType)
+}
+
+// bodyEncoding returns how content has to be encoded to be logged safely, or
+// "" if it can be logged as it is.
+func bodyEncoding(content []byte) string {
+	if !utf8.Valid(content) {
+		return "base64"
+	}
+
+	// Decoding runes in place saves copying the body to a string
+	for len(content) > 0 {
+		r, size := utf8.DecodeRune(content)
+		if isEscapedControl(r) {
+			return "escaped"
+		}
+
+		content = content[size:]
+	}
+
+	return ""
+}
+
+// isEscapedControl reports whether r is a control character other than the
+// whitespace text bodies are expected to have.
+func isEscapedControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r'
+}
+
+// escapeControl escapes the control characters in s, as Go would quote them.
+func escapeControl(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if isEscapedControl(r) {
+			q := strconv.QuoteRune(r)
+			b.WriteString(q[1 : len(q)-1])
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// trimPartialRune drops a multi-byte character cut short by truncation, so
+// truncated text doesn't look like binary.
+func trimPartialRune(content []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(content); i++ {
+		if utf8.RuneStart(content[len(content)-i]) {
+			if !utf8.FullRune(content[len(content)-i:]) {
+				return content[:len(content)-i]
+			}
+
+			break
+		}
+	}
+
+	return content
+}
+
+// formValue logs a form body as a group of its fields. A truncated body is
+// still parsed so a partial secret can't slip through unredacted.
+func formValue(content string, names map[string]struct{}) slog.Value {
+	values, err := url.ParseQuery(content)
+	if err != nil {
+		return slog.StringValue(redacted)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		v := values[key]
+
+		switch _, redact := names[strings.ToLower(key)]; {
+		case redact:
+			fields = append(fields, slog.String(key, redacted))
+		case len(v) == 1:
+			fields = append(fields, slog.String(key, v[0]))
+		default:
+			fields = append(fields, slog.Any(key, v))
+		}
+	}
+
+	return slog.GroupValue(fields...)
+}
+
+func isXML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// redactXMLValue walks a value produced by DecodeXML, redacting elements and
+// attributes named in names.
+func redactXMLValue(v any, names map[string]struct{}) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for key, child := range t {
+			if _, ok := names[strings.ToLower(strings.TrimPrefix(key, "@"))]; ok {
+				t[key] = redacted
+				continue
+			}
+
+			t[key] = redactXMLValue(child, names)
+		}
+	case []any:
+		for i, child := range t {
+			t[i] = redactXMLValue(child, names)
+		}
+	}
+
+	return v
+}
+
+// DecodeJSON decodes a JSON body, keeping numbers as json.Number.
+func DecodeJSON(body []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// DecodeForm decodes an application/x-www-form-urlencoded body. Fields with a
+// single value are strings, repeated fields are []string.
+func DecodeForm(body []byte) (any, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	form := make(map[string]any, len(values))
+	for name, v := range values {
+		if len(v) == 1 {
+			form[name] = v[0]
+		} else {
+			form[name] = v
+		}
+	}
+
+	return form, nil
+}
+
+// DecodeXML decodes an XML body into nested maps keyed by element name.
+// Attributes are keyed "@name", text alongside child elements is keyed
+// "#text" and repeated elements become slices.
+func DecodeXML(body []byte) (any, error) {
+	d := xml.NewDecoder(bytes.NewReader(body))
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, errors.New("slogtripper: no xml root element")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			v, err := decodeXMLElement(d, start)
+			if err != nil {
+				return nil, err
+			}
+
+			return map[string]any{start.Name.Local: v}, nil
+		}
+	}
+}
+
+func decodeXMLElement(d *xml.Decoder, start xml.StartElement) (any, error) {
+	element := map[string]any{}
+	for _, attr := range start.Attr {
+		element["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(d, t)
+			if err != nil {
+				return nil, err
+			}
+
+			switch existing := element[t.Name.Local].(type) {
+			case nil:
+				element[t.Name.Local] = child
+			case []any:
+				element[t.Name.Local] = append(existing, child)
+			default:
+				element[t.Name.Local] = []any{existing, child}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			s := strings.TrimSpace(text.String())
+			if len(element) == 0 {
+				return s, nil
+			}
+
+			if s != "" {
+				element["#text"] = s
+			}
+
+			return element, nil
+		}
+	}
+}
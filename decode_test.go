@@ -0,0 +1,318 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBodies(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			name:        "JSON",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"id": 12345678901234567890, "ok": true}`,
+			expected:    `"body_content":{"id":12345678901234567890,"ok":true}`,
+		},
+		{
+			name:        "JSON suffix",
+			contentType: "application/problem+json",
+			body:        `{"title": "Not Found"}`,
+			expected:    `"body_content":{"title":"Not Found"}`,
+		},
+		{
+			name:        "XML",
+			contentType: "text/xml",
+			body:        `<order id="1"><item>a</item><item>b</item></order>`,
+			expected:    `"body_content":{"order":{"@id":"1","item":["a","b"]}}`,
+		},
+		{
+			name:        "Invalid JSON",
+			contentType: "application/json",
+			body:        `{"id": `,
+			expected:    `"body_content":"{\"id\": "`,
+		},
+		{
+			name:        "Unknown",
+			contentType: "application/octet-stream",
+			body:        `raw`,
+			expected:    `"body_content":"raw"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			st := NewSlogTripper(
+				WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+				WithRoundTripper(&MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Header:     http.Header{"Content-Type": []string{test.contentType}},
+							Body:       io.NopCloser(strings.NewReader(test.body)),
+						}, nil
+					},
+				}),
+				CaptureResponseBody(),
+				DecodeBodies(),
+			)
+
+			if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+				t.Fatalf("Error in roundtrip: %v", err)
+			}
+
+			if !strings.Contains(output.String(), test.expected) {
+				t.Errorf("Expected %s in log: %s", test.expected, output.String())
+			}
+		})
+	}
+}
+
+func TestRegisterBodyDecoder(t *testing.T) {
+	RegisterBodyDecoder("application/x-test", func(body []byte) (any, error) {
+		return map[string]any{"length": len(body)}, nil
+	})
+	defer RegisterBodyDecoder("application/x-test", nil)
+
+	fn := lookupBodyDecoder("application/x-test", nil)
+	if fn == nil {
+		t.Fatal("Registered decoder not found")
+	}
+
+	v, err := fn([]byte("abc"))
+	if err != nil {
+		t.Fatalf("Error decoding: %v", err)
+	}
+
+	if !reflect.DeepEqual(v, map[string]any{"length": 3}) {
+		t.Errorf("Unexpected decoded value: %v", v)
+	}
+}
+
+func TestDecodeJSONNumbers(t *testing.T) {
+	v, err := DecodeJSON([]byte(`{"n": 1.5}`))
+	if err != nil {
+		t.Fatalf("Error decoding: %v", err)
+	}
+
+	if _, ok := v.(map[string]any)["n"].(json.Number); !ok {
+		t.Errorf("Number should be decoded as json.Number: %#v", v)
+	}
+}
+
+func TestRedactXMLElements(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "Elements and attributes",
+			body:     `<login user="bob"><password>hunter2</password><token kind="a">abc</token><token>def</token></login>`,
+			expected: `"body_content":{"login":{"@user":"[REDACTED]","password":"[REDACTED]","token":"[REDACTED]"}}`,
+		},
+		{
+			name:     "Unparsable",
+			body:     `<login><password>hunter2</pass`,
+			expected: `"body_content":"[REDACTED]"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			st := NewSlogTripper(
+				WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+				WithRoundTripper(&MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						return &http.Response{StatusCode: http.StatusOK}, nil
+					},
+				}),
+				CaptureRequestBody(),
+				RedactXMLElements("Password", "token", "user"),
+			)
+
+			req := Must(http.NewRequest(http.MethodPost, "http://localhost/", strings.NewReader(test.body)))
+			req.Header.Set("Content-Type", "application/soap+xml")
+
+			if _, err := st.RoundTrip(req); err != nil {
+				t.Fatalf("Error in roundtrip: %v", err)
+			}
+
+			if !strings.Contains(output.String(), test.expected) || strings.Contains(output.String(), "hunter2") {
+				t.Errorf("Expected %s in log: %s", test.expected, output.String())
+			}
+		})
+	}
+}
+
+// fakeProto stands in for a generated protobuf message
+type fakeProto struct {
+	Name string `json:"name"`
+}
+
+func TestWithBodyDecoderProto(t *testing.T) {
+	var output bytes.Buffer
+
+	decoder := DecodeProto(
+		func() *fakeProto { return new(fakeProto) },
+		func(b []byte, m *fakeProto) error {
+			// A length prefixed string in place of the protobuf wire format
+			m.Name = string(b[1 : 1+b[0]])
+			return nil
+		},
+		func(m *fakeProto) ([]byte, error) { return json.Marshal(m) },
+	)
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		CaptureRequestBody(),
+		ForRequests(func(req *http.Request) bool { return req.URL.Path == "/orders" })(
+			WithBodyDecoder("application/x-protobuf", decoder),
+		),
+	)
+
+	for _, path := range []string{"/orders", "/other"} {
+		output.Reset()
+
+		req := Must(http.NewRequest(http.MethodPost, "http://localhost"+path, bytes.NewReader([]byte("\x03abc"))))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+
+		if _, err := st.RoundTrip(req); err != nil {
+			t.Fatalf("Error in roundtrip: %v", err)
+		}
+
+		decoded := strings.Contains(output.String(), `"body_content":{"name":"abc"}`)
+		if decoded != (path == "/orders") {
+			t.Errorf("%s: unexpected decoding %v: %s", path, decoded, output.String())
+		}
+	}
+}
+
+func TestFormBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     "Default redaction",
+			body:     `grant_type=client_credentials&client_id=app&client_secret=hunter2&scope=a&scope=b`,
+			expected: `"body_content":{"client_id":"app","client_secret":"[REDACTED]","grant_type":"client_credentials","scope":["a","b"]}`,
+		},
+		{
+			name:     "Extra fields",
+			body:     `username=bob&PIN=hunter2`,
+			opts:     []Option{RedactFormFields("pin")},
+			expected: `"body_content":{"PIN":"[REDACTED]","username":"bob"}`,
+		},
+		{
+			name:     "Truncated",
+			body:     `client_id=app&client_secret=hunter2`,
+			opts:     []Option{WithMaxBodySize(30)},
+			expected: `"body_content":{"client_id":"app","client_secret":"[REDACTED]"}`,
+		},
+		{
+			name:     "Unparsable",
+			body:     `client_secret=%zzhunter2`,
+			expected: `"body_content":"[REDACTED]"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			st := NewSlogTripper(append([]Option{
+				WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+				WithRoundTripper(&MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						return &http.Response{StatusCode: http.StatusOK}, nil
+					},
+				}),
+				CaptureRequestBody(),
+			}, test.opts...)...)
+
+			req := Must(http.NewRequest(http.MethodPost, "http://localhost/token", strings.NewReader(test.body)))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			if _, err := st.RoundTrip(req); err != nil {
+				t.Fatalf("Error in roundtrip: %v", err)
+			}
+
+			if !strings.Contains(output.String(), test.expected) || strings.Contains(output.String(), "hunter2") {
+				t.Errorf("Expected %s in log: %s", test.expected, output.String())
+			}
+		})
+	}
+}
+
+// discardingHandler accepts every record without resolving its attributes
+type discardingHandler struct {
+	slog.Handler
+}
+
+func (discardingHandler) Handle(context.Context, slog.Record) error {
+	return nil
+}
+
+func TestBodyDecodedLazily(t *testing.T) {
+	var calls int
+
+	decoder := func(b []byte) (any, error) {
+		calls++
+		return string(b), nil
+	}
+
+	newTripper := func(handler slog.Handler) *SlogTripper {
+		return NewSlogTripper(
+			WithLogger(slog.New(handler)),
+			WithRoundTripper(&MockRoundTripper{
+				MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK}, nil
+				},
+			}),
+			CaptureRequestBody(),
+			WithBodyDecoder("text/plain", decoder),
+		)
+	}
+
+	roundTrip := func(st *SlogTripper) {
+		req := Must(http.NewRequest(http.MethodPost, "http://localhost", strings.NewReader("hello")))
+		req.Header.Set("Content-Type", "text/plain")
+
+		if _, err := st.RoundTrip(req); err != nil {
+			t.Fatalf("Error in roundtrip: %v", err)
+		}
+	}
+
+	roundTrip(newTripper(discardingHandler{slog.NewJSONHandler(io.Discard, nil)}))
+	if calls != 0 {
+		t.Errorf("Body decoded %d times for a discarded record", calls)
+	}
+
+	roundTrip(newTripper(slog.NewJSONHandler(io.Discard, nil)))
+	if calls != 1 {
+		t.Errorf("Body decoded %d times for a logged record", calls)
+	}
+}
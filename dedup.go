@@ -0,0 +1,176 @@
+package slogtripper
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// WithErrorDedup collapses runs of identical failed round trips, the same
+// host, method and error, into one record. The first failure of a run is
+// logged as usual, repeats are counted and logged as an "HTTP Error Repeated"
+// record with a repeat_count when the run ends, because the next round trip
+// succeeded or failed differently, and at least every window while it lasts.
+// A run without a failure for a window is forgotten. Call Close to stop the
+// periodic records, logging the repeats not yet reported.
+func WithErrorDedup(window time.Duration) Option {
+	return func(st *SlogTripper) {
+		if window <= 0 {
+			st.invalid("non-positive error dedup window %v", window)
+			return
+		}
+
+		st.dedup = &errorDedup{window: window, runs: map[string]*errorRun{}, done: make(chan struct{})}
+	}
+}
+
+type errorDedup struct {
+	window time.Duration
+	done   chan struct{}
+
+	mu   sync.Mutex
+	runs map[string]*errorRun
+}
+
+// errorRun is a run of identical failures for a host and method.
+type errorRun struct {
+	host, method, kind string
+
+	// count is the repeats since the last report, first and last when they
+	// happened
+	count       int
+	first, last time.Time
+	// seen is when the run last failed
+	seen time.Time
+}
+
+// errorKind identifies an error regardless of the URL a *url.Error carries,
+// which would make every path a different error.
+func errorKind(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err.Error()
+	}
+
+	return err.Error()
+}
+
+// observe reports whether the record for a round trip should be logged,
+// ending the run for its host and method when the outcome differs.
+func (d *errorDedup) observe(st *SlogTripper, req *http.Request, err error) bool {
+	host, method := requestHost(req), ""
+	if req != nil {
+		method = req.Method
+	}
+	key := method + " " + host
+
+	var repeated *errorRun
+
+	d.mu.Lock()
+	defer func() {
+		d.mu.Unlock()
+		repeated.report(st)
+	}()
+
+	run, ok := d.runs[key]
+
+	if err != nil && ok && run.kind == errorKind(err) {
+		run.last = time.Now()
+		if run.count == 0 {
+			run.first = run.last
+		}
+		run.count++
+		run.seen = run.last
+
+		return false
+	}
+
+	if ok {
+		repeated = run.take()
+		delete(d.runs, key)
+	}
+
+	if err != nil {
+		d.runs[key] = &errorRun{host: host, method: method, kind: errorKind(err), seen: time.Now()}
+	}
+
+	return true
+}
+
+// take returns a copy of run to report, resetting its count, or nil if
+// there's nothing to report. It's called with the lock held, and the copy
+// reported once it's released, as logging may send a round trip through d.
+func (run *errorRun) take() *errorRun {
+	if run.count == 0 {
+		return nil
+	}
+
+	taken := *run
+	run.count = 0
+
+	return &taken
+}
+
+// report logs the repeats counted for run, if it isn't nil.
+func (run *errorRun) report(st *SlogTripper) {
+	if run == nil {
+		return
+	}
+
+	st.log(context.Background(), "HTTP Error Repeated", slog.Group("repeated",
+		slog.String("host", run.host),
+		slog.String("method", run.method),
+		slog.String("error", run.kind),
+		slog.Int("repeat_count", run.count),
+		slog.Time("first_at", run.first),
+		slog.Time("last_at", run.last),
+	))
+}
+
+// run reports the repeats of ongoing runs every window until stop is closed,
+// then reports the rest.
+func (d *errorDedup) run(st *SlogTripper, stop <-chan struct{}) {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			d.report(st)
+			return
+		case <-ticker.C:
+			d.report(st)
+		}
+	}
+}
+
+// report logs the repeats of ongoing runs since they were last reported.
+func (d *errorDedup) report(st *SlogTripper) {
+	for _, run := range d.flush(time.Now()) {
+		run.report(st)
+	}
+}
+
+// flush takes the repeats of ongoing runs to report, forgetting runs which
+// haven't failed for a window.
+func (d *errorDedup) flush(now time.Time) []*errorRun {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var repeated []*errorRun
+	for key, run := range d.runs {
+		if taken := run.take(); taken != nil {
+			repeated = append(repeated, taken)
+		} else if now.Sub(run.seen) >= d.window {
+			delete(d.runs, key)
+		}
+	}
+
+	return repeated
+}
@@ -0,0 +1,89 @@
+package slogtripper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorDedup(t *testing.T) {
+	var output bytes.Buffer
+
+	failing := true
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if failing {
+					return nil, errors.New("connection refused")
+				}
+
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithErrorDedup(time.Hour),
+	)
+	defer st.Close()
+
+	for i := 0; i < 4; i++ {
+		_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://dead/"+string(rune('a'+i)), nil)))
+	}
+
+	if n := strings.Count(output.String(), `"msg":"HTTP Request"`); n != 1 {
+		t.Errorf("Only the first failure should be logged, got %d: %s", n, output.String())
+	}
+
+	failing = false
+	_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://dead/", nil)))
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected failure, repeat and success records, got: %s", output.String())
+	}
+
+	if !strings.Contains(lines[1], `"msg":"HTTP Error Repeated"`) || !strings.Contains(lines[1], `"repeat_count":3`) || !strings.Contains(lines[1], `"error":"connection refused"`) {
+		t.Errorf("Unexpected repeat record: %s", lines[1])
+	}
+
+	if !strings.Contains(lines[2], `"status_code":200`) {
+		t.Errorf("Success should be logged after the run: %s", lines[2])
+	}
+}
+
+func TestErrorDedupIdleRuns(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			},
+		}),
+		WithErrorDedup(time.Hour),
+	)
+
+	for i := 0; i < 100; i++ {
+		_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, fmt.Sprintf("http://host%d/", i), nil)))
+	}
+
+	if repeated := st.dedup.flush(time.Now().Add(2 * time.Hour)); len(repeated) != 0 || len(st.dedup.runs) != 0 {
+		t.Errorf("Expected runs idle for a window forgotten, %d kept", len(st.dedup.runs))
+	}
+
+	// Repeats not yet reported are on Close
+	for i := 0; i < 3; i++ {
+		_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://dead/", nil)))
+	}
+	st.Close()
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if last := lines[len(lines)-1]; !strings.Contains(last, `"msg":"HTTP Error Repeated"`) || !strings.Contains(last, `"repeat_count":2`) {
+		t.Errorf("Expected the repeats reported on Close: %s", output.String())
+	}
+}
@@ -0,0 +1,34 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// LogRequestOnSend logs an "HTTP Request Dispatching" record, with the
+// request's ID, method, URL and attempt, as each request is handed to the
+// transport, so a request to a hung upstream shows up straight away rather
+// than only once it fails. The full record follows as usual.
+func LogRequestOnSend() Option {
+	return func(st *SlogTripper) {
+		st.logOnSend = true
+	}
+}
+
+// logDispatch logs that req, logged under id, is being sent.
+func (st *SlogTripper) logDispatch(req *http.Request, id string, attempt int) {
+	attrs := []any{
+		slog.String("id", id),
+		slog.String("method", req.Method),
+	}
+
+	if req.URL != nil {
+		attrs = append(attrs, slog.String("url", req.URL.String()))
+	}
+
+	if attempt != 0 {
+		attrs = append(attrs, slog.Int("attempt", attempt))
+	}
+
+	st.log(req.Context(), "HTTP Request Dispatching", slog.Group(st.requestKey, attrs...))
+}
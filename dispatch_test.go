@@ -0,0 +1,61 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogRequestOnSend(t *testing.T) {
+	var buf bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				// The dispatching record is out before the transport is called
+				if !strings.Contains(buf.String(), `"msg":"HTTP Request Dispatching"`) {
+					t.Error("Expected the request logged before it was sent")
+				}
+
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		LogRequestOnSend(),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/slow", nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	type record struct {
+		Msg     string `json:"msg"`
+		Request struct {
+			ID     string `json:"id"`
+			Method string `json:"method"`
+			URL    string `json:"url"`
+		} `json:"request"`
+	}
+
+	var dispatched, done record
+
+	dec := json.NewDecoder(&buf)
+	if err := dec.Decode(&dispatched); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dec.Decode(&done); err != nil {
+		t.Fatal(err)
+	}
+
+	if dispatched.Request.Method != http.MethodGet || dispatched.Request.URL != "http://localhost/slow" {
+		t.Errorf("Unexpected dispatching record: %+v", dispatched)
+	}
+
+	if done.Msg != "HTTP Request" || done.Request.ID == "" || done.Request.ID != dispatched.Request.ID {
+		t.Errorf("Expected the records to share an ID: %+v, %+v", dispatched, done)
+	}
+}
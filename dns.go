@@ -0,0 +1,105 @@
+package slogtripper
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+)
+
+// CaptureDNS logs the DNS lookup made for a request as a dns group on the
+// response: the host looked up, how long it took, the addresses it resolved
+// to and, when it failed, an error group telling a name that doesn't exist
+// (not_found) from a lookup that timed out or hit a temporary failure. The
+// address dialed is logged too, with fallback=true when it wasn't the first
+// one tried, such as when happy eyeballs fell back to the other address
+// family, and the number of connect attempts. Requests which reused a pooled
+// connection, or whose host is an IP address, make no lookup and log none.
+func CaptureDNS() Option {
+	return func(st *SlogTripper) {
+		st.captureDNS = true
+	}
+}
+
+// connectAttempt is a dial the transport made for a request.
+type connectAttempt struct {
+	addr string
+	done bool
+	err  error
+}
+
+// dns returns the dns group, if a lookup was made.
+func (t *roundTripTrace) dns() (slog.Attr, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.dnsStart.IsZero() {
+		return slog.Attr{}, false
+	}
+
+	attrs := []any{slog.String("host", t.dnsHost)}
+
+	if !t.dnsDone.IsZero() {
+		addrs := make([]string, len(t.dnsInfo.Addrs))
+		for i, addr := range t.dnsInfo.Addrs {
+			addrs[i] = addr.String()
+		}
+
+		attrs = append(attrs,
+			slog.Duration("duration", t.dnsDone.Sub(t.dnsStart)),
+			slog.Any("addrs", addrs),
+		)
+
+		if t.dnsInfo.Coalesced {
+			attrs = append(attrs, slog.Bool("coalesced", true))
+		}
+
+		if t.dnsInfo.Err != nil {
+			attrs = append(attrs, dnsErrorAttr(t.dnsInfo.Err))
+		}
+	}
+
+	for i, c := range t.connects {
+		if !c.done || c.err != nil {
+			continue
+		}
+
+		attrs = append(attrs, slog.String("dialed", c.addr))
+		if i > 0 {
+			attrs = append(attrs, slog.Bool("fallback", true))
+		}
+
+		break
+	}
+
+	if len(t.connects) != 0 {
+		attrs = append(attrs, slog.Int("connect_attempts", len(t.connects)))
+	}
+
+	return slog.Group("dns", attrs...), true
+}
+
+// dnsErrorAttr describes a failed lookup, with flags set only when true.
+func dnsErrorAttr(err error) slog.Attr {
+	attrs := []any{slog.String("message", err.Error())}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsNotFound {
+			attrs = append(attrs, slog.Bool("not_found", true))
+		}
+
+		if dnsErr.IsTimeout {
+			attrs = append(attrs, slog.Bool("timeout", true))
+		}
+
+		if dnsErr.IsTemporary {
+			attrs = append(attrs, slog.Bool("temporary", true))
+		}
+
+		if dnsErr.Server != "" {
+			attrs = append(attrs, slog.String("server", dnsErr.Server))
+		}
+	}
+
+	return slog.Group("error", attrs...)
+}
@@ -0,0 +1,159 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+type dnsGroup struct {
+	Host     string   `json:"host"`
+	Addrs    []string `json:"addrs"`
+	Dialed   string   `json:"dialed"`
+	Fallback bool     `json:"fallback"`
+	Attempts int      `json:"connect_attempts"`
+	Error    struct {
+		Message  string `json:"message"`
+		NotFound bool   `json:"not_found"`
+		Timeout  bool   `json:"timeout"`
+	} `json:"error"`
+}
+
+// dnsAttr decodes the dns group t logs.
+func dnsAttr(t *testing.T, trace *roundTripTrace) dnsGroup {
+	t.Helper()
+
+	attr, ok := trace.dns()
+	if !ok {
+		t.Fatal("Expected a dns group")
+	}
+
+	var output bytes.Buffer
+	slog.New(slog.NewJSONHandler(&output, nil)).Info("", attr)
+
+	var record struct {
+		DNS dnsGroup `json:"dns"`
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	return record.DNS
+}
+
+func TestCaptureDNS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	if _, err := net.LookupHost("localhost"); err != nil {
+		t.Skipf("localhost doesn't resolve: %v", err)
+	}
+
+	transport := &http.Transport{}
+	defer transport.CloseIdleConnections()
+
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(transport),
+		CaptureDNS(),
+	)
+
+	u := Must(url.Parse(server.URL))
+	addr := u.Host
+	u.Host = "localhost:" + u.Port()
+
+	for i, lookup := range []bool{true, false} {
+		output.Reset()
+
+		res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, u.String(), nil)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		var record struct {
+			Response struct {
+				DNS *dnsGroup `json:"dns"`
+			} `json:"response"`
+		}
+
+		if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+
+		// The second request reuses the pooled connection, so looks nothing up
+		if !lookup {
+			if record.Response.DNS != nil {
+				t.Errorf("Request %d: expected no lookup, got %+v", i, record.Response.DNS)
+			}
+
+			continue
+		}
+
+		dns := record.Response.DNS
+		if dns == nil || dns.Host != "localhost" || !slices.Contains(dns.Addrs, "127.0.0.1") || dns.Dialed != addr {
+			t.Errorf("Request %d: unexpected lookup: %s", i, output.Bytes())
+		}
+	}
+}
+
+func TestDNSFailure(t *testing.T) {
+	trace := newRoundTripTrace(time.Now())
+	hooks := trace.clientTrace()
+
+	hooks.DNSStart(httptrace.DNSStartInfo{Host: "missing.example"})
+	hooks.DNSDone(httptrace.DNSDoneInfo{Err: &net.DNSError{Err: "no such host", Name: "missing.example", IsNotFound: true}})
+
+	dns := dnsAttr(t, trace)
+	if dns.Host != "missing.example" || !dns.Error.NotFound || dns.Error.Timeout || dns.Error.Message == "" {
+		t.Errorf("Expected a missing name, got %+v", dns)
+	}
+
+	trace = newRoundTripTrace(time.Now())
+	hooks = trace.clientTrace()
+
+	hooks.DNSStart(httptrace.DNSStartInfo{Host: "slow.example"})
+	hooks.DNSDone(httptrace.DNSDoneInfo{Err: &net.DNSError{Err: "i/o timeout", Name: "slow.example", IsTimeout: true}})
+
+	if dns := dnsAttr(t, trace); dns.Error.NotFound || !dns.Error.Timeout {
+		t.Errorf("Expected a timeout, got %+v", dns)
+	}
+}
+
+func TestDNSFallback(t *testing.T) {
+	trace := newRoundTripTrace(time.Now())
+	hooks := trace.clientTrace()
+
+	hooks.DNSStart(httptrace.DNSStartInfo{Host: "example.com"})
+	hooks.DNSDone(httptrace.DNSDoneInfo{Addrs: []net.IPAddr{
+		{IP: net.ParseIP("2001:db8::1")},
+		{IP: net.ParseIP("192.0.2.1")},
+	}})
+
+	// The IPv6 attempt stalls, so happy eyeballs races IPv4 and wins
+	hooks.ConnectStart("tcp", "[2001:db8::1]:443")
+	hooks.ConnectStart("tcp", "192.0.2.1:443")
+	hooks.ConnectDone("tcp", "192.0.2.1:443", nil)
+	hooks.ConnectDone("tcp", "[2001:db8::1]:443", errors.New("operation was canceled"))
+
+	dns := dnsAttr(t, trace)
+	if !slices.Equal(dns.Addrs, []string{"2001:db8::1", "192.0.2.1"}) {
+		t.Errorf("Unexpected addresses: %v", dns.Addrs)
+	}
+
+	if dns.Dialed != "192.0.2.1:443" || !dns.Fallback || dns.Attempts != 2 {
+		t.Errorf("Expected the fallback noted, got %+v", dns)
+	}
+}
@@ -0,0 +1,36 @@
+package slogtripper
+
+import (
+	"net/http"
+)
+
+// DryRun stops requests reaching the network: the tripper logs them as
+// usual, with the capture options chosen, but answers each with an empty 200
+// OK of its own, and tags the response dry_run=true. It's for validating an
+// integration in CI, or seeing what would have been sent. It replaces any
+// transport set by WithRoundTripper before it.
+func DryRun() Option {
+	return func(st *SlogTripper) {
+		st.proxyTransport = NopTransport{}
+		st.dryRun = true
+	}
+}
+
+// NopTransport is an http.RoundTripper which sends nothing, answering every
+// request with an empty 200 OK.
+type NopTransport struct{}
+
+func (NopTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	closeBody(req)
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	var buf bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		DryRun(),
+		CaptureRequestBody(),
+	)
+
+	var closed bool
+	body := &closeRecorder{Reader: strings.NewReader(`{"name":"gopher"}`), closed: &closed}
+	req := Must(http.NewRequest(http.MethodPost, "http://192.0.2.1/users", body))
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := st.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != http.StatusOK || res.Request.URL.String() != req.URL.String() {
+		t.Errorf("Unexpected synthetic response: %+v", res)
+	}
+
+	if content, _ := io.ReadAll(res.Body); len(content) != 0 {
+		t.Errorf("Expected an empty body, got %q", content)
+	}
+
+	if !closed {
+		t.Error("Expected the request body closed")
+	}
+
+	var record struct {
+		Request struct {
+			BodyContent string `json:"body_content"`
+		} `json:"request"`
+		Response struct {
+			DryRun bool `json:"dry_run"`
+		} `json:"response"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if !record.Response.DryRun || record.Request.BodyContent != `{"name":"gopher"}` {
+		t.Errorf("Unexpected record: %s", buf.String())
+	}
+}
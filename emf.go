@@ -0,0 +1,118 @@
+package slogtripper
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithCloudWatchEMF emits a CloudWatch Embedded Metric Format document for
+// every round trip, whether or not it's logged, which CloudWatch Logs turns
+// into metrics in namespace without an agent, as from Lambda. The metrics
+// are Requests, Errors, counting transport errors and 5xx responses, and
+// Duration in milliseconds, with the dimensions Host, Method and
+// StatusClass, the last being 2xx, 5xx and so on, or error for transport
+// errors. Requests Middleware handles aren't outbound calls, and aren't
+// emitted.
+//
+// Documents are written to w a line at a time, such as os.Stdout in Lambda.
+// When w is nil they're logged as "HTTP Metrics" records instead, whose
+// attributes must reach CloudWatch as top level JSON fields, as with an
+// slog.JSONHandler; they're left out of any WithGroupName group.
+func WithCloudWatchEMF(namespace string, w io.Writer) Option {
+	return func(st *SlogTripper) {
+		if namespace == "" {
+			st.invalid("empty cloudwatch namespace")
+			return
+		}
+
+		st.emf = &emf{namespace: namespace, w: w}
+	}
+}
+
+type emf struct {
+	namespace string
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// emfMetrics are the metrics in every document.
+var emfMetrics = []map[string]string{
+	{"Name": "Requests", "Unit": "Count"},
+	{"Name": "Errors", "Unit": "Count"},
+	{"Name": "Duration", "Unit": "Milliseconds"},
+}
+
+// emit writes or logs the document for a round trip.
+func (e *emf) emit(st *SlogTripper, host string, req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	method := http.MethodGet
+	if req != nil && req.Method != "" {
+		method = req.Method
+	}
+
+	class := "error"
+	if err == nil && res != nil {
+		class = statusClass(res.StatusCode)
+	}
+
+	errorCount := 0
+	if failed(res, err) {
+		errorCount = 1
+	}
+
+	metadata := map[string]any{
+		"Timestamp": st.clock.Now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]any{{
+			"Namespace":  e.namespace,
+			"Dimensions": [][]string{{"Host", "Method", "StatusClass"}},
+			"Metrics":    emfMetrics,
+		}},
+	}
+
+	fields := []struct {
+		key   string
+		value any
+	}{
+		{"Host", host},
+		{"Method", method},
+		{"StatusClass", class},
+		{"Requests", 1},
+		{"Errors", errorCount},
+		{"Duration", float64(elapsed) / float64(time.Millisecond)},
+	}
+
+	if e.w == nil {
+		ctx := context.Background()
+		if req != nil {
+			ctx = req.Context()
+		}
+
+		attrs := []any{slog.Any("_aws", metadata)}
+		for _, f := range fields {
+			attrs = append(attrs, slog.Any(f.key, f.value))
+		}
+
+		st.log(ctx, "HTTP Metrics", attrs...)
+		return
+	}
+
+	doc := map[string]any{"_aws": metadata}
+	for _, f := range fields {
+		doc[f.key] = f.value
+	}
+
+	b, marshalErr := json.Marshal(doc)
+	if marshalErr != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, _ = e.w.Write(append(b, '\n'))
+}
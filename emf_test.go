@@ -0,0 +1,135 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type emfDocument struct {
+	AWS struct {
+		Timestamp         int64 `json:"Timestamp"`
+		CloudWatchMetrics []struct {
+			Namespace  string     `json:"Namespace"`
+			Dimensions [][]string `json:"Dimensions"`
+			Metrics    []struct {
+				Name string `json:"Name"`
+				Unit string `json:"Unit"`
+			} `json:"Metrics"`
+		} `json:"CloudWatchMetrics"`
+	} `json:"_aws"`
+	Host        string  `json:"Host"`
+	Method      string  `json:"Method"`
+	StatusClass string  `json:"StatusClass"`
+	Requests    int     `json:"Requests"`
+	Errors      int     `json:"Errors"`
+	Duration    float64 `json:"Duration"`
+}
+
+func TestWithCloudWatchEMF(t *testing.T) {
+	var metrics, output bytes.Buffer
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+			},
+		}),
+		WithClock(ClockFunc(func() time.Time { return now })),
+		WithCloudWatchEMF("MyApp/HTTP", &metrics),
+		DisableLogging(),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodPut, "http://api.example/things", nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc emfDocument
+	if err := json.Unmarshal(metrics.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Host != "api.example" || doc.Method != http.MethodPut || doc.StatusClass != "5xx" || doc.Requests != 1 || doc.Errors != 1 {
+		t.Errorf("Unexpected document %s", metrics.Bytes())
+	}
+
+	if doc.AWS.Timestamp != now.UnixMilli() || len(doc.AWS.CloudWatchMetrics) != 1 ||
+		doc.AWS.CloudWatchMetrics[0].Namespace != "MyApp/HTTP" || len(doc.AWS.CloudWatchMetrics[0].Metrics) != 3 {
+		t.Errorf("Unexpected metadata %s", metrics.Bytes())
+	}
+
+	if output.Len() != 0 {
+		t.Errorf("Expected nothing logged: %s", output.Bytes())
+	}
+}
+
+func TestWithCloudWatchEMFLogged(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			},
+		}),
+		WithGroupName("http"),
+		WithCloudWatchEMF("MyApp/HTTP", nil),
+	)
+
+	st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil)))
+
+	var found bool
+	for _, line := range bytes.Split(bytes.TrimSpace(output.Bytes()), []byte("\n")) {
+		var doc struct {
+			Msg string `json:"msg"`
+			emfDocument
+		}
+
+		if err := json.Unmarshal(line, &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if doc.Msg != "HTTP Metrics" {
+			continue
+		}
+
+		found = true
+		if doc.StatusClass != "error" || doc.Errors != 1 || len(doc.AWS.CloudWatchMetrics) != 1 {
+			t.Errorf("Unexpected document %s", line)
+		}
+	}
+
+	if !found {
+		t.Errorf("Expected the metrics logged: %s", output.Bytes())
+	}
+}
+
+func TestWithCloudWatchEMFInvalid(t *testing.T) {
+	if _, err := NewSlogTripperE(WithCloudWatchEMF("", nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected an invalid option, got %v", err)
+	}
+}
+
+func TestWithCloudWatchEMFMiddleware(t *testing.T) {
+	var metrics bytes.Buffer
+
+	handler := Middleware(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithCloudWatchEMF("MyApp/HTTP", &metrics),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if metrics.Len() != 0 {
+		t.Errorf("Expected no document for a server request: %s", metrics.Bytes())
+	}
+}
@@ -0,0 +1,67 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// FromEnv configures the tripper from environment variables, letting
+// operators tune logging without a code change. Unset or unparsable variables
+// leave the existing setting alone, unparsable ones are reported by
+// NewSlogTripperE. Options after FromEnv take precedence.
+//
+//	SLOGTRIPPER_LEVEL            debug, info, warn or error (slog level syntax, e.g. info+2)
+//	SLOGTRIPPER_CAPTURE_BODIES   capture request and response bodies
+//	SLOGTRIPPER_CAPTURE_HEADERS  capture request and response headers
+//	SLOGTRIPPER_MAX_BODY         maximum captured body size in bytes
+//	SLOGTRIPPER_DISABLE          turn logging off entirely
+func FromEnv() Option {
+	return func(st *SlogTripper) {
+		if v, ok := os.LookupEnv("SLOGTRIPPER_LEVEL"); ok {
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(v)); err != nil {
+				st.invalid("SLOGTRIPPER_LEVEL: %v", err)
+			} else {
+				WithLoggingLevel(level)(st)
+			}
+		}
+
+		if b, ok := lookupEnvBool(st, "SLOGTRIPPER_CAPTURE_BODIES"); ok {
+			st.captureRequestBody = newBool(b)
+			st.captureResponseBody = newBool(b)
+		}
+
+		if b, ok := lookupEnvBool(st, "SLOGTRIPPER_CAPTURE_HEADERS"); ok {
+			st.captureRequestHeaders = newBool(b)
+			st.captureResponseHeaders = newBool(b)
+		}
+
+		if v, ok := os.LookupEnv("SLOGTRIPPER_MAX_BODY"); ok {
+			if n, err := strconv.ParseInt(v, 10, 64); err != nil {
+				st.invalid("SLOGTRIPPER_MAX_BODY: %v", err)
+			} else {
+				WithMaxBodySize(n)(st)
+			}
+		}
+
+		if b, ok := lookupEnvBool(st, "SLOGTRIPPER_DISABLE"); ok {
+			st.disabled = newBool(b)
+		}
+	}
+}
+
+func lookupEnvBool(st *SlogTripper, key string) (bool, bool) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return false, false
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		st.invalid("%s: %v", key, err)
+		return false, false
+	}
+
+	return b, true
+}
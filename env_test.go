@@ -0,0 +1,58 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("SLOGTRIPPER_LEVEL", "debug")
+	t.Setenv("SLOGTRIPPER_CAPTURE_BODIES", "true")
+	t.Setenv("SLOGTRIPPER_MAX_BODY", "1024")
+	t.Setenv("SLOGTRIPPER_DISABLE", "1")
+
+	st := NewSlogTripper(FromEnv())
+
+	if st.logAtLevel.Level() != slog.LevelDebug {
+		t.Errorf("Unexpected level: %v", st.logAtLevel.Level())
+	}
+
+	if !st.captureRequestBody.Load() || !st.captureResponseBody.Load() {
+		t.Error("Bodies should be captured")
+	}
+
+	if st.captureRequestHeaders.Load() {
+		t.Error("Headers should be left alone")
+	}
+
+	if st.maxBodySize != 1024 {
+		t.Errorf("Unexpected max body size: %d", st.maxBodySize)
+	}
+
+	if !st.disabled.Load() {
+		t.Error("Logging should be disabled")
+	}
+}
+
+func TestFromEnvInvalid(t *testing.T) {
+	t.Setenv("SLOGTRIPPER_LEVEL", "loud")
+	t.Setenv("SLOGTRIPPER_CAPTURE_BODIES", "maybe")
+
+	st := NewSlogTripper(WithLoggingLevel(slog.LevelWarn), FromEnv())
+
+	if st.logAtLevel.Level() != slog.LevelWarn {
+		t.Errorf("Invalid level should be ignored, got %v", st.logAtLevel.Level())
+	}
+
+	if st.captureRequestBody.Load() {
+		t.Error("Invalid bool should be ignored")
+	}
+}
+
+func TestFromEnvInvalidReported(t *testing.T) {
+	t.Setenv("SLOGTRIPPER_MAX_BODY", "lots")
+
+	if _, err := NewSlogTripperE(FromEnv()); err == nil {
+		t.Error("Invalid environment variable should be reported")
+	}
+}
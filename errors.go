@@ -0,0 +1,67 @@
+package slogtripper
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// errorAttr describes err as an error group: its message, the types along
+// the chain errors.Unwrap walks, the operation and URL of a *url.Error, and
+// flags for the kinds of failure worth querying for, present when true.
+func errorAttr(err error) slog.Attr {
+	attrs := []any{slog.String("message", err.Error())}
+
+	var chain []string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, fmt.Sprintf("%T", e))
+	}
+
+	attrs = append(attrs, slog.String("type", chain[len(chain)-1]))
+	if len(chain) > 1 {
+		attrs = append(attrs, slog.Any("chain", chain))
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		attrs = append(attrs, slog.String("op", urlErr.Op), slog.String("url", urlErr.URL))
+	}
+
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"timeout", isTimeout(err)},
+		{"canceled", errors.Is(err, context.Canceled)},
+		{"deadline_exceeded", errors.Is(err, context.DeadlineExceeded)},
+		{"dns", errors.As(err, new(*net.DNSError))},
+		{"connection_refused", errors.Is(err, syscall.ECONNREFUSED)},
+		{"connection_reset", errors.Is(err, syscall.ECONNRESET)},
+		{"tls", isTLSError(err)},
+	} {
+		if flag.set {
+			attrs = append(attrs, slog.Bool(flag.name, true))
+		}
+	}
+
+	return slog.Group("error", attrs...)
+}
+
+func isTimeout(err error) bool {
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+func isTLSError(err error) bool {
+	return errors.As(err, new(tls.RecordHeaderError)) ||
+		errors.As(err, new(*tls.CertificateVerificationError)) ||
+		errors.As(err, new(x509.UnknownAuthorityError)) ||
+		errors.As(err, new(x509.HostnameError)) ||
+		errors.As(err, new(x509.CertificateInvalidError))
+}
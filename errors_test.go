@@ -0,0 +1,87 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestErrorAttr(t *testing.T) {
+	// A port nothing is listening on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	var buf bytes.Buffer
+	c := &http.Client{Transport: NewSlogTripper(WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))}
+
+	type errorGroup struct {
+		Message           string   `json:"message"`
+		Type              string   `json:"type"`
+		Chain             []string `json:"chain"`
+		Op                string   `json:"op"`
+		URL               string   `json:"url"`
+		ConnectionRefused bool     `json:"connection_refused"`
+		DeadlineExceeded  bool     `json:"deadline_exceeded"`
+		Timeout           bool     `json:"timeout"`
+	}
+
+	decode := func() errorGroup {
+		t.Helper()
+
+		var record struct {
+			Response struct {
+				Error errorGroup `json:"error"`
+			} `json:"response"`
+		}
+
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+
+		return record.Response.Error
+	}
+
+	if _, err := c.Get("http://" + addr + "/users"); err == nil {
+		t.Fatal("Expected the connection refused")
+	}
+
+	refused := decode()
+	if refused.Message == "" || refused.Type != "syscall.Errno" || !refused.ConnectionRefused || refused.Timeout {
+		t.Errorf("Unexpected error group: %+v", refused)
+	}
+
+	if len(refused.Chain) < 2 || refused.Chain[0] != "*net.OpError" {
+		t.Errorf("Unexpected chain: %q", refused.Chain)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	if _, err := c.Do(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr, nil))); err == nil {
+		t.Fatal("Expected the deadline exceeded")
+	}
+
+	if expired := decode(); !expired.DeadlineExceeded || !expired.Timeout {
+		t.Errorf("Unexpected error group: %+v", expired)
+	}
+}
+
+func TestErrorAttrURLError(t *testing.T) {
+	attrs := AttrMap([]slog.Attr{errorAttr(&url.Error{Op: "Get", URL: "http://localhost", Err: context.Canceled})})
+	group := attrs["error"].(map[string]any)
+
+	if group["op"] != "Get" || group["url"] != "http://localhost" || group["canceled"] != true || group["type"] != "*errors.errorString" {
+		t.Errorf("Unexpected error group: %v", group)
+	}
+}
@@ -0,0 +1,17 @@
+package slogtripper
+
+import "expvar"
+
+// PublishExpvar publishes the tripper's live counters as an expvar called
+// name, served under /debug/vars: the in flight gauges and, when WithStats is
+// used, the per host Stats. Like expvar.Publish it panics if name is already
+// in use.
+func (st *SlogTripper) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return map[string]any{
+			"in_flight":         st.InFlight(),
+			"in_flight_by_host": st.InFlightByHost(),
+			"hosts":             st.Stats().Hosts,
+		}
+	}))
+}
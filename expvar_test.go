@@ -0,0 +1,43 @@
+package slogtripper
+
+import (
+	"encoding/json"
+	"expvar"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithStats(),
+	)
+
+	st.PublishExpvar("slogtripper_test")
+
+	_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://api/", nil)))
+
+	v := expvar.Get("slogtripper_test")
+	if v == nil {
+		t.Fatal("Expvar not published")
+	}
+
+	var published struct {
+		InFlight int64                `json:"in_flight"`
+		Hosts    map[string]HostStats `json:"hosts"`
+	}
+	if err := json.Unmarshal([]byte(v.String()), &published); err != nil {
+		t.Fatalf("Error unmarshalling expvar: %v", err)
+	}
+
+	if published.Hosts["api"].Requests != 1 {
+		t.Errorf("Unexpected published stats: %s", v.String())
+	}
+}
@@ -0,0 +1,75 @@
+package slogtripper
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// WithLoggers sends every record to each of loggers, such as the app's main
+// logger and a dedicated HTTP audit logger, each with its own level and
+// destination. It takes the place of WithLogger.
+func WithLoggers(loggers ...*slog.Logger) Option {
+	return func(st *SlogTripper) {
+		handlers := make([]slog.Handler, 0, len(loggers))
+		for _, l := range loggers {
+			if l == nil {
+				st.invalid("nil logger")
+				continue
+			}
+
+			handlers = append(handlers, l.Handler())
+		}
+
+		if len(handlers) == 0 {
+			st.invalid("no loggers")
+			return
+		}
+
+		st.logger = slog.New(fanoutHandler(handlers))
+	}
+}
+
+// fanoutHandler hands records to every handler that's enabled for them.
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, r.Level) {
+			if err := handler.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		c[i] = handler.WithAttrs(attrs)
+	}
+
+	return c
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	c := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		c[i] = handler.WithGroup(name)
+	}
+
+	return c
+}
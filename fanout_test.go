@@ -0,0 +1,49 @@
+package slogtripper
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithLoggers(t *testing.T) {
+	var app, audit bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLoggers(
+			slog.New(slog.NewJSONHandler(&app, &slog.HandlerOptions{Level: slog.LevelWarn})),
+			slog.New(slog.NewTextHandler(&audit, nil)),
+		),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if app.Len() != 0 {
+		t.Errorf("The app logger's level should drop the record: %s", app.String())
+	}
+
+	if !strings.Contains(audit.String(), "msg=\"HTTP Request\"") {
+		t.Errorf("Expected the record in the audit logger: %s", audit.String())
+	}
+
+	st.SetLevel(slog.LevelWarn)
+	app.Reset()
+	audit.Reset()
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if app.Len() == 0 || audit.Len() == 0 {
+		t.Errorf("Expected the record in both loggers: %s %s", app.String(), audit.String())
+	}
+}
@@ -0,0 +1,182 @@
+package slogtripper
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// ErrFaultInjected is returned, wrapped with the request's context error,
+// for requests a fault injection dropped.
+var ErrFaultInjected = errors.New("fault injected")
+
+// FaultConfig configures WithFaultInjection. Alongside any Delay, at most one
+// of Drop, Err and StatusCode is set; with none of them the request is sent
+// once the delay is over.
+type FaultConfig struct {
+	// Match picks the requests faults can be injected into, defaulting to
+	// all of them
+	Match func(*http.Request) bool
+	// Percentage is the share of matching requests faults are injected
+	// into, from 0 to 100
+	Percentage float64
+	// Delay holds requests back before anything else happens to them
+	Delay time.Duration
+	// Drop never sends requests, failing them with ErrFaultInjected once
+	// their context ends, as if the network lost them. Use it with a client
+	// Timeout or a context deadline
+	Drop bool
+	// Err fails requests with Err, without sending them
+	Err error
+	// StatusCode answers requests with an empty response of this status,
+	// without sending them
+	StatusCode int
+}
+
+// WithFaultInjection injects the fault c describes into a percentage of the
+// requests it matches, for exercising how callers cope with slow or failing
+// dependencies. The record of a request with a fault injected has
+// fault_injected=true and a fault group describing it in its response group.
+// So that every injection is seen, faults are only injected into requests
+// which get a record, not into ones skipped because logging is disabled or
+// below the logger's level. It can be given more than once, and the first
+// fault a request rolls is the one injected.
+func WithFaultInjection(c FaultConfig) Option {
+	return func(st *SlogTripper) {
+		if c.Percentage < 0 || c.Percentage > 100 {
+			st.invalid("fault percentage %v out of range", c.Percentage)
+			return
+		}
+
+		if c.Delay < 0 {
+			st.invalid("negative fault delay")
+			return
+		}
+
+		outcomes := 0
+		for _, set := range []bool{c.Drop, c.Err != nil, c.StatusCode != 0} {
+			if set {
+				outcomes++
+			}
+		}
+
+		if outcomes > 1 {
+			st.invalid("fault sets more than one of Drop, Err and StatusCode")
+			return
+		}
+
+		if outcomes == 0 && c.Delay == 0 {
+			st.invalid("fault injects nothing")
+			return
+		}
+
+		if c.StatusCode != 0 && (c.StatusCode < 100 || c.StatusCode > 999) {
+			st.invalid("fault status code %d out of range", c.StatusCode)
+			return
+		}
+
+		st.faults = append(st.faults[:len(st.faults):len(st.faults)], &c)
+	}
+}
+
+// pickFault returns the fault to inject into req, nil for none.
+func (st *SlogTripper) pickFault(req *http.Request) *FaultConfig {
+	if req == nil {
+		return nil
+	}
+
+	for _, f := range st.faults {
+		if f.Match != nil && !f.Match(req) {
+			continue
+		}
+
+		if rand.Float64()*100 < f.Percentage {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// sendWithFault sends req, after injecting f into it when it isn't nil.
+func (st *SlogTripper) sendWithFault(req *http.Request, f *FaultConfig) (*http.Response, error) {
+	if f == nil {
+		return st.send(req)
+	}
+
+	ctx := req.Context()
+
+	if f.Delay > 0 {
+		t := time.NewTimer(f.Delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			closeBody(req)
+
+			return nil, ctx.Err()
+		}
+	}
+
+	switch {
+	case f.Drop:
+		closeBody(req)
+		<-ctx.Done()
+
+		return nil, fmt.Errorf("slogtripper: %w: %w", ErrFaultInjected, ctx.Err())
+	case f.Err != nil:
+		closeBody(req)
+
+		return nil, f.Err
+	case f.StatusCode != 0:
+		closeBody(req)
+
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+			StatusCode: f.StatusCode,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{},
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+
+	return st.send(req)
+}
+
+// faultAttrs describes the fault f injected.
+func faultAttrs(f *FaultConfig) []slog.Attr {
+	attrs := []any{}
+
+	switch {
+	case f.Drop:
+		attrs = append(attrs, slog.String("type", "drop"))
+	case f.Err != nil:
+		attrs = append(attrs, slog.String("type", "error"), slog.String("error", f.Err.Error()))
+	case f.StatusCode != 0:
+		attrs = append(attrs, slog.String("type", "status"), slog.Int("status_code", f.StatusCode))
+	default:
+		attrs = append(attrs, slog.String("type", "delay"))
+	}
+
+	if f.Delay > 0 {
+		attrs = append(attrs, slog.Duration("delay", f.Delay))
+	}
+
+	attrs = append(attrs, slog.Float64("percentage", f.Percentage))
+
+	return []slog.Attr{slog.Bool("fault_injected", true), slog.Group("fault", attrs...)}
+}
+
+// closeBody closes the body of a request that won't be sent, as a transport
+// would.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
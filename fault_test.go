@@ -0,0 +1,159 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithFaultInjection(t *testing.T) {
+	refused := errors.New("connection refused")
+
+	tests := []struct {
+		name   string
+		fault  FaultConfig
+		path   string
+		status int
+		err    error
+		kind   string
+		sent   bool
+	}{
+		{
+			name:  "error",
+			fault: FaultConfig{Percentage: 100, Err: refused},
+			err:   refused,
+			kind:  "error",
+		},
+		{
+			name:   "status",
+			fault:  FaultConfig{Percentage: 100, StatusCode: http.StatusServiceUnavailable},
+			status: http.StatusServiceUnavailable,
+			kind:   "status",
+		},
+		{
+			name:   "delay",
+			fault:  FaultConfig{Percentage: 100, Delay: time.Millisecond},
+			status: http.StatusOK,
+			kind:   "delay",
+			sent:   true,
+		},
+		{
+			name:  "drop",
+			fault: FaultConfig{Percentage: 100, Drop: true},
+			err:   ErrFaultInjected,
+			kind:  "drop",
+		},
+		{
+			name: "unmatched",
+			fault: FaultConfig{
+				Match:      func(r *http.Request) bool { return strings.HasPrefix(r.URL.Path, "/flaky") },
+				Percentage: 100,
+				Err:        refused,
+			},
+			status: http.StatusOK,
+			sent:   true,
+		},
+		{
+			name:   "never",
+			fault:  FaultConfig{Percentage: 0, Err: refused},
+			status: http.StatusOK,
+			sent:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var sent bool
+
+			st := NewSlogTripper(
+				WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+				WithRoundTripper(&MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						sent = true
+						return &http.Response{StatusCode: http.StatusOK}, nil
+					},
+				}),
+				WithFaultInjection(tt.fault),
+			)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			req := Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/users", nil))
+
+			res, err := st.RoundTrip(req)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Expected error %v, got %v", tt.err, err)
+			}
+
+			if res != nil && res.StatusCode != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, res.StatusCode)
+			}
+
+			if sent != tt.sent {
+				t.Errorf("Expected sent %v, got %v", tt.sent, sent)
+			}
+
+			var record struct {
+				Response struct {
+					FaultInjected bool `json:"fault_injected"`
+					Fault         struct {
+						Type string `json:"type"`
+					} `json:"fault"`
+				} `json:"response"`
+			}
+
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatal(err)
+			}
+
+			if record.Response.FaultInjected != (tt.kind != "") || record.Response.Fault.Type != tt.kind {
+				t.Errorf("Unexpected record: %s", buf.String())
+			}
+		})
+	}
+}
+
+func TestWithFaultInjectionUnlogged(t *testing.T) {
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelWarn}))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithFaultInjection(FaultConfig{Percentage: 100, StatusCode: http.StatusServiceUnavailable}),
+	)
+
+	// The record would be below the logger's level, so nothing would show
+	// the fault
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/users", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Expected no fault injected into a request without a record, got %d", res.StatusCode)
+	}
+}
+
+func TestWithFaultInjectionInvalid(t *testing.T) {
+	for _, c := range []FaultConfig{
+		{Percentage: 101, Err: errors.New("x")},
+		{Percentage: 50},
+		{Percentage: 50, Drop: true, StatusCode: http.StatusBadGateway},
+		{Percentage: 50, StatusCode: 42},
+	} {
+		if _, err := NewSlogTripperE(WithFaultInjection(c)); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("Expected %+v rejected, got %v", c, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package slogtripper
+
+import "log/slog"
+
+// WithFlatAttributes logs every attribute at the top level of the record in
+// place of nested groups, joining the group names to the key with
+// underscores, i.e. request_method and response_status_code. Some backends,
+// and some text handlers, make nested groups painful to query.
+func WithFlatAttributes() Option {
+	return func(st *SlogTripper) {
+		st.flatAttributes = true
+	}
+}
+
+// flattenArgs replaces the groups in args with their attributes, prefixed
+// with the group's key.
+func (st *SlogTripper) flattenArgs(args []any) []any {
+	if !st.flatAttributes {
+		return args
+	}
+
+	flat := make([]any, 0, len(args))
+	for _, arg := range args {
+		attr, ok := arg.(slog.Attr)
+		if !ok {
+			flat = append(flat, arg)
+			continue
+		}
+
+		flat = appendFlat(flat, "", attr)
+	}
+
+	return flat
+}
+
+func appendFlat(flat []any, prefix string, attr slog.Attr) []any {
+	// Groups with an empty key are inlined, as slog does
+	key := attr.Key
+	if prefix != "" && key != "" {
+		key = prefix + "_" + key
+	} else if key == "" {
+		key = prefix
+	}
+
+	if attr.Value.Kind() != slog.KindGroup {
+		return append(flat, slog.Attr{Key: key, Value: attr.Value})
+	}
+
+	for _, a := range attr.Value.Group() {
+		flat = appendFlat(flat, key, a)
+	}
+
+	return flat
+}
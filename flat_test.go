@@ -0,0 +1,54 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+func TestWithFlatAttributes(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusTeapot}, nil
+			},
+		}),
+		CaptureRequestHeaders(),
+		WithAttrs(slog.Group("", slog.String("service", "billing"))),
+		WithFlatAttributes(),
+	)
+
+	req := Must(http.NewRequest(http.MethodGet, "http://localhost", nil))
+	req.Header.Set("Accept", "text/plain")
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("Error decoding record: %v", err)
+	}
+
+	expected := map[string]any{
+		"service":                "billing",
+		"request_method":         "GET",
+		"request_headers_Accept": "text/plain",
+		"response_status_code":   float64(http.StatusTeapot),
+	}
+
+	for key, value := range expected {
+		if record[key] != value {
+			t.Errorf("Expected %s=%v: %s", key, value, output.String())
+		}
+	}
+
+	if _, ok := record["request"]; ok {
+		t.Errorf("Unexpected request group: %s", output.String())
+	}
+}
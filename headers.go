@@ -0,0 +1,79 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+)
+
+// CaptureTrailers logs the trailers of requests and responses, such as
+// grpc-status or checksums, as a trailers group. Response trailers only
+// arrive with the end of the body, so the record for a response with a body
+// is held back until that body has been read to the end or closed.
+func CaptureTrailers() Option {
+	return func(st *SlogTripper) {
+		st.captureTrailers = true
+	}
+}
+
+// trailersAttr returns the trailers group for t, if any trailers were sent.
+func (st *SlogTripper) trailersAttr(t http.Header) (slog.Attr, bool) {
+	if !st.captureTrailers || len(t) == 0 {
+		return slog.Attr{}, false
+	}
+
+	trailers := st.headerAttrs(t)
+	if len(trailers) == 0 {
+		return slog.Attr{}, false
+	}
+
+	return slog.Group("trailers", trailers...), true
+}
+
+// headerAttrs builds the attributes for the headers group, redacting the
+// values of headers in st.redactHeaders.
+func (st *SlogTripper) headerAttrs(h http.Header) []any {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := make([]any, 0, len(names))
+
+	for _, name := range names {
+		values := h[name]
+		if len(values) == 0 {
+			continue
+		}
+
+		// Only the first value is logged, the one .Get would use
+		headers = append(headers, slog.String(name, st.headerValue(name, values[0])))
+	}
+
+	return headers
+}
+
+// headerValue returns value, of the header name, as it may be logged:
+// redacted if name is in st.redactHeaders, masked otherwise.
+func (st *SlogTripper) headerValue(name, value string) string {
+	if _, ok := st.redactHeaders[http.CanonicalHeaderKey(name)]; ok {
+		return redacted
+	}
+
+	return st.mask(value)
+}
+
+// redactHeader adds name to the headers whose values are never logged.
+func (st *SlogTripper) redactHeader(names ...string) {
+	redact := make(map[string]struct{}, len(st.redactHeaders)+len(names))
+	for name := range st.redactHeaders {
+		redact[name] = struct{}{}
+	}
+
+	for _, name := range names {
+		redact[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+
+	st.redactHeaders = redact
+}
@@ -0,0 +1,75 @@
+package slogtripper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCaptureTrailers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+
+		w.Header().Set("Trailer", "Grpc-Status")
+		fmt.Fprint(w, "hello")
+		w.Header().Set("Grpc-Status", r.Trailer.Get("Checksum"))
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+
+	client := &http.Client{Transport: NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(server.Client().Transport),
+		CaptureTrailers(),
+	)}
+
+	req := Must(http.NewRequest(http.MethodPost, server.URL, nil))
+	req.Trailer = http.Header{"Checksum": nil}
+
+	// A body of unknown length, filling in the trailer once it's been read
+	req.Body = io.NopCloser(&trailerReader{
+		Reader: strings.NewReader("hello"),
+		set:    func() { req.Trailer.Set("Checksum", "abc") },
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Error in request: %v", err)
+	}
+
+	if output.Len() != 0 {
+		t.Fatalf("Record written before the response trailers arrived: %s", output.String())
+	}
+
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	if !strings.Contains(output.String(), `"trailers":{"Checksum":"abc"}`) {
+		t.Errorf("Expected the request trailers logged: %s", output.String())
+	}
+
+	if !strings.Contains(output.String(), `"trailers":{"Grpc-Status":"abc"}`) {
+		t.Errorf("Expected the response trailers logged: %s", output.String())
+	}
+}
+
+// trailerReader calls set when its reader runs out
+type trailerReader struct {
+	io.Reader
+	set func()
+}
+
+func (r *trailerReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.set()
+	}
+
+	return n, err
+}
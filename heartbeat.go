@@ -0,0 +1,130 @@
+package slogtripper
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithHeartbeat logs an "HTTP Request In Flight" record every interval for
+// as long as a round trip lasts, from sending the request to the caller
+// finishing with the response body, so long uploads and streaming downloads
+// aren't silent until they end. Each record has the request's ID, method and
+// URL, the time elapsed, the stage, either "awaiting_response" or
+// "reading_body", and the body bytes received so far.
+func WithHeartbeat(interval time.Duration) Option {
+	return func(st *SlogTripper) {
+		if interval <= 0 {
+			st.invalid("heartbeat interval of %s", interval)
+			return
+		}
+
+		st.heartbeat = interval
+	}
+}
+
+// sendWithHeartbeat sends req through send, beating until it's over.
+func (st *SlogTripper) sendWithHeartbeat(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	hb := &heartbeat{st: st, req: req, start: time.Now()}
+	hb.stage.Store("awaiting_response")
+
+	hb.mu.Lock()
+	hb.timer = time.AfterFunc(st.heartbeat, hb.beat)
+	hb.mu.Unlock()
+
+	res, err := send(req)
+	if err != nil || !wrappableBody(res) {
+		hb.stop()
+		return res, err
+	}
+
+	hb.stage.Store("reading_body")
+	res.Body = &heartbeatBody{ReadCloser: res.Body, hb: hb}
+
+	return res, nil
+}
+
+// heartbeat logs a round trip periodically until it's stopped.
+type heartbeat struct {
+	st       *SlogTripper
+	req      *http.Request
+	start    time.Time
+	stage    atomic.Value
+	received atomic.Int64
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	stopped bool
+}
+
+func (hb *heartbeat) beat() {
+	hb.mu.Lock()
+	if hb.stopped {
+		hb.mu.Unlock()
+		return
+	}
+	hb.mu.Unlock()
+
+	if !hb.st.disabled.Load() {
+		hb.log()
+	}
+
+	hb.mu.Lock()
+	if !hb.stopped {
+		hb.timer.Reset(hb.st.heartbeat)
+	}
+	hb.mu.Unlock()
+}
+
+func (hb *heartbeat) log() {
+	attrs := []any{
+		slog.String("method", hb.req.Method),
+		slog.String("url", hb.req.URL.String()),
+	}
+
+	ctx := hb.req.Context()
+	if id, ok := RequestIDFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("id", id))
+	}
+
+	hb.st.log(context.WithoutCancel(ctx), "HTTP Request In Flight",
+		slog.Group(hb.st.requestKey, attrs...),
+		slog.Duration("elapsed", time.Since(hb.start)),
+		slog.String("stage", hb.stage.Load().(string)),
+		slog.Int64("bytes_received", hb.received.Load()),
+	)
+}
+
+func (hb *heartbeat) stop() {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	hb.stopped = true
+	hb.timer.Stop()
+}
+
+// heartbeatBody stops its heartbeat once it's read to the end or closed.
+type heartbeatBody struct {
+	io.ReadCloser
+	hb *heartbeat
+}
+
+func (b *heartbeatBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hb.received.Add(int64(n))
+
+	if err != nil {
+		b.hb.stop()
+	}
+
+	return n, err
+}
+
+func (b *heartbeatBody) Close() error {
+	b.hb.stop()
+	return b.ReadCloser.Close()
+}
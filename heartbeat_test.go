@@ -0,0 +1,97 @@
+package slogtripper
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// slowReader returns one byte at a time, each after a delay.
+type slowReader struct {
+	data  string
+	delay time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, io.EOF
+	}
+
+	time.Sleep(r.delay)
+	p[0], r.data = r.data[0], r.data[1:]
+
+	return 1, nil
+}
+
+func TestWithHeartbeat(t *testing.T) {
+	var output syncBuffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				time.Sleep(35 * time.Millisecond)
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(&slowReader{data: "abc", delay: 15 * time.Millisecond}),
+				}, nil
+			},
+		}),
+		WithHeartbeat(10*time.Millisecond),
+	)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/stream", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	stages := map[string]int{}
+	var last time.Duration
+
+	for _, line := range output.Lines() {
+		var record struct {
+			Msg     string `json:"msg"`
+			Request struct {
+				URL string `json:"url"`
+			} `json:"request"`
+			Elapsed time.Duration `json:"elapsed"`
+			Stage   string        `json:"stage"`
+		}
+
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+
+		if record.Msg != "HTTP Request In Flight" {
+			continue
+		}
+
+		if record.Request.URL != "http://localhost/stream" || record.Elapsed < last {
+			t.Errorf("Unexpected heartbeat: %s", line)
+		}
+
+		last = record.Elapsed
+		stages[record.Stage]++
+	}
+
+	if stages["awaiting_response"] == 0 || stages["reading_body"] == 0 {
+		t.Errorf("Expected heartbeats in both stages, got %v", stages)
+	}
+
+	// Nothing more once the body's done with
+	n := len(output.Lines())
+	time.Sleep(30 * time.Millisecond)
+
+	if got := len(output.Lines()); got != n {
+		t.Errorf("Expected the heartbeat stopped, got %d more records", got-n)
+	}
+}
@@ -0,0 +1,48 @@
+package slogtripper
+
+import (
+	"net/http"
+	"time"
+)
+
+// ResponseHook is called once the proxied transport has returned, with the
+// time the round trip took. res is nil when err isn't.
+type ResponseHook func(req *http.Request, res *http.Response, err error, elapsed time.Duration)
+
+// OnRequest calls f with every request before it's sent. Hooks run even when
+// logging is disabled, in the order they were added.
+func OnRequest(f func(req *http.Request)) Option {
+	return func(st *SlogTripper) {
+		if f == nil {
+			st.invalid("nil request hook")
+			return
+		}
+
+		st.onRequest = append(st.onRequest[:len(st.onRequest):len(st.onRequest)], f)
+	}
+}
+
+// OnResponse calls f after every round trip. Hooks run even when logging is
+// disabled, in the order they were added.
+func OnResponse(f ResponseHook) Option {
+	return func(st *SlogTripper) {
+		if f == nil {
+			st.invalid("nil response hook")
+			return
+		}
+
+		st.onResponse = append(st.onResponse[:len(st.onResponse):len(st.onResponse)], f)
+	}
+}
+
+func (st *SlogTripper) runOnRequest(req *http.Request) {
+	for _, f := range st.onRequest {
+		f(req)
+	}
+}
+
+func (st *SlogTripper) runOnResponse(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	for _, f := range st.onResponse {
+		f(req, res, err, elapsed)
+	}
+}
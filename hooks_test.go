@@ -0,0 +1,56 @@
+package slogtripper
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHooks(t *testing.T) {
+	mockErr := errors.New("mock error")
+
+	var requests, responses int
+	var gotErr error
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.Header.Get("X-Hooked") != "true" {
+					t.Error("Request hook did not run before the request was sent")
+				}
+
+				return nil, mockErr
+			},
+		}),
+		OnRequest(func(req *http.Request) {
+			requests++
+			req.Header.Set("X-Hooked", "true")
+		}),
+		OnResponse(func(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+			responses++
+			gotErr = err
+
+			if elapsed < 0 {
+				t.Errorf("Unexpected elapsed time: %v", elapsed)
+			}
+		}),
+	)
+
+	_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil)))
+
+	st.SetEnabled(false)
+
+	_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil)))
+
+	if requests != 2 || responses != 2 {
+		t.Errorf("Hooks should run with and without logging, got %d requests %d responses", requests, responses)
+	}
+
+	if gotErr != mockErr {
+		t.Errorf("Response hook got unexpected error: %v", gotErr)
+	}
+}
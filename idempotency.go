@@ -0,0 +1,54 @@
+package slogtripper
+
+import (
+	"context"
+	"net/http"
+)
+
+type idempotencyKeyKey struct{}
+
+// ContextWithIdempotencyKey returns a context carrying key, which requests
+// made with it send as their Idempotency-Key when idempotency keys are
+// managed. Reusing the context for every attempt at an operation makes the
+// upstream see them as one.
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// WithIdempotencyKeys sends an Idempotency-Key header with requests whose
+// method isn't idempotent, such as POST and PATCH, when they don't already
+// have one: the key from ContextWithIdempotencyKey, or a new random UUID.
+// Every attempt WithRetry makes at a request shares its key, and requests
+// with a key are retried like idempotent ones. The key of every request
+// sending one is logged as idempotency_key, so attempts can be correlated.
+func WithIdempotencyKeys() Option {
+	return func(st *SlogTripper) {
+		st.idempotencyKeys = true
+	}
+}
+
+// outgoingIdempotencyKey returns the Idempotency-Key to add to req, if any.
+func (st *SlogTripper) outgoingIdempotencyKey(req *http.Request) string {
+	if !st.idempotencyKeys || idempotentMethod(req.Method) {
+		return ""
+	}
+
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return ""
+	}
+
+	if key, ok := req.Context().Value(idempotencyKeyKey{}).(string); ok && key != "" {
+		return key
+	}
+
+	return newUUID()
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	b := []byte(randomHex(16))
+	b[12] = '4'
+	b[16] = "89ab"[b[16]%4]
+
+	return string(b[0:8]) + "-" + string(b[8:12]) + "-" + string(b[12:16]) + "-" + string(b[16:20]) + "-" + string(b[20:32])
+}
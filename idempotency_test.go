@@ -0,0 +1,126 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestWithIdempotencyKeys(t *testing.T) {
+	for name, test := range map[string]struct {
+		method string
+		ctx    context.Context
+		header string
+		want   func(string) bool
+	}{
+		"generated": {
+			method: http.MethodPost,
+			want:   uuidPattern.MatchString,
+		},
+		"from context": {
+			method: http.MethodPatch,
+			ctx:    ContextWithIdempotencyKey(context.Background(), "order-42"),
+			want:   func(key string) bool { return key == "order-42" },
+		},
+		"already set": {
+			method: http.MethodPost,
+			header: "caller-key",
+			want:   func(key string) bool { return key == "caller-key" },
+		},
+		"idempotent method": {
+			method: http.MethodGet,
+			want:   func(key string) bool { return key == "" },
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var output bytes.Buffer
+			var sent string
+
+			st := NewSlogTripper(
+				WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+				WithRoundTripper(&MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						sent = r.Header.Get("Idempotency-Key")
+						return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+					},
+				}),
+				WithIdempotencyKeys(),
+			)
+
+			ctx := test.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			req := Must(http.NewRequestWithContext(ctx, test.method, "http://localhost/orders", nil))
+			if test.header != "" {
+				req.Header.Set("Idempotency-Key", test.header)
+			}
+
+			if _, err := st.RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+
+			if !test.want(sent) {
+				t.Errorf("Unexpected key sent: %q", sent)
+			}
+
+			var record struct {
+				Request struct {
+					IdempotencyKey string `json:"idempotency_key"`
+				} `json:"request"`
+			}
+
+			if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+				t.Fatal(err)
+			}
+
+			if record.Request.IdempotencyKey != sent {
+				t.Errorf("Expected %q logged, got %q", sent, record.Request.IdempotencyKey)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyRetries(t *testing.T) {
+	var output bytes.Buffer
+	var keys []string
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				keys = append(keys, r.Header.Get("Idempotency-Key"))
+
+				status := http.StatusServiceUnavailable
+				if len(keys) == 2 {
+					status = http.StatusCreated
+				}
+
+				return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+			},
+		}),
+		WithIdempotencyKeys(),
+		WithRetry(3, func(int) time.Duration { return 0 }, nil),
+	)
+
+	req := Must(http.NewRequest(http.MethodPost, "http://localhost/orders", strings.NewReader(`{"sku": 1}`)))
+
+	res, err := st.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The key makes the POST safe to retry, and both attempts share it
+	if res.StatusCode != http.StatusCreated || len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("Expected two attempts with one key, got %v", keys)
+	}
+}
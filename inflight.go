@@ -0,0 +1,69 @@
+package slogtripper
+
+import (
+	"net/http"
+	"sync"
+)
+
+// inFlight counts the round trips waiting on the proxied transport, in total
+// and per host. It's shared with host and method configs.
+type inFlight struct {
+	mu    sync.Mutex
+	total int64
+	hosts map[string]int64
+}
+
+func newInFlight() *inFlight {
+	return &inFlight{hosts: map[string]int64{}}
+}
+
+// start counts a round trip to host, returning the counts including it.
+func (f *inFlight) start(host string) (int64, int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.total++
+	f.hosts[host]++
+
+	return f.total, f.hosts[host]
+}
+
+func (f *inFlight) done(host string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.total--
+	if f.hosts[host]--; f.hosts[host] <= 0 {
+		delete(f.hosts, host)
+	}
+}
+
+// InFlight returns how many round trips are waiting on the proxied transport.
+func (st *SlogTripper) InFlight() int64 {
+	st.inFlight.mu.Lock()
+	defer st.inFlight.mu.Unlock()
+
+	return st.inFlight.total
+}
+
+// InFlightByHost returns how many round trips are waiting on the proxied
+// transport per host, hosts with none are left out.
+func (st *SlogTripper) InFlightByHost() map[string]int64 {
+	st.inFlight.mu.Lock()
+	defer st.inFlight.mu.Unlock()
+
+	hosts := make(map[string]int64, len(st.inFlight.hosts))
+	for host, n := range st.inFlight.hosts {
+		hosts[host] = n
+	}
+
+	return hosts
+}
+
+func requestHost(req *http.Request) string {
+	if req == nil || req.URL == nil {
+		return ""
+	}
+
+	return req.URL.Host
+}
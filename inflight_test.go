@@ -0,0 +1,68 @@
+package slogtripper
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestInFlight(t *testing.T) {
+	var output bytes.Buffer
+
+	release := make(chan struct{})
+	sent := make(chan struct{})
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Host == "slow" {
+					sent <- struct{}{}
+					<-release
+				}
+
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://slow/", nil)))
+		}()
+		<-sent
+	}
+
+	if n := st.InFlight(); n != 2 {
+		t.Errorf("Expected 2 in flight, got %d", n)
+	}
+
+	if hosts := st.InFlightByHost(); hosts["slow"] != 2 {
+		t.Errorf("Expected 2 in flight to slow, got %v", hosts)
+	}
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://fast/", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"in_flight":3,"in_flight_host":1`) {
+		t.Errorf("Log does not contain in flight counts: %s", output.String())
+	}
+
+	close(release)
+	wg.Wait()
+
+	if n := st.InFlight(); n != 0 {
+		t.Errorf("Expected nothing in flight, got %d", n)
+	}
+
+	if hosts := st.InFlightByHost(); len(hosts) != 0 {
+		t.Errorf("Expected no hosts in flight, got %v", hosts)
+	}
+}
@@ -0,0 +1,350 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONSchemaValidator returns a ResponseValidator checking that the bodies of
+// successful responses are JSON conforming to schema. Its violations are
+// joined, each naming the JSON Pointer to the offending value.
+//
+// A subset of JSON Schema is supported: type, enum, const, properties,
+// required, additionalProperties, items, minItems, maxItems, minLength,
+// maxLength, pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// allOf, anyOf and oneOf. Other keywords, including $ref, are ignored.
+func JSONSchemaValidator(schema []byte) (ResponseValidator, error) {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, fmt.Errorf("slogtripper: json schema: %w", err)
+	}
+
+	if err := s.compile(); err != nil {
+		return nil, fmt.Errorf("slogtripper: json schema: %w", err)
+	}
+
+	return func(res *http.Response) error {
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return nil
+		}
+
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return err
+		}
+
+		d := json.NewDecoder(bytes.NewReader(body))
+		d.UseNumber()
+
+		var v any
+		if err := d.Decode(&v); err != nil {
+			return fmt.Errorf("body isn't JSON: %w", err)
+		}
+
+		var violations []error
+		s.validate("", v, &violations)
+
+		return errors.Join(violations...)
+	}, nil
+}
+
+// jsonSchema is the subset of a JSON Schema JSONSchemaValidator supports.
+type jsonSchema struct {
+	Type                 jsonTypes              `json:"type"`
+	Enum                 []any                  `json:"enum"`
+	Const                *json.RawMessage       `json:"const"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Pattern              string                 `json:"pattern"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum"`
+	AllOf                []*jsonSchema          `json:"allOf"`
+	AnyOf                []*jsonSchema          `json:"anyOf"`
+	OneOf                []*jsonSchema          `json:"oneOf"`
+
+	// never is set by a schema of false, which nothing conforms to
+	never   bool
+	pattern *regexp.Regexp
+}
+
+func (s *jsonSchema) UnmarshalJSON(b []byte) error {
+	var allow bool
+	if err := json.Unmarshal(b, &allow); err == nil {
+		*s = jsonSchema{never: !allow}
+		return nil
+	}
+
+	type plain jsonSchema
+
+	return json.Unmarshal(b, (*plain)(s))
+}
+
+// jsonTypes is a schema's type, which may be one type or a list of them.
+type jsonTypes []string
+
+func (t *jsonTypes) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*t = jsonTypes{one}
+		return nil
+	}
+
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+// compile prepares s and its subschemas for validation.
+func (s *jsonSchema) compile() error {
+	if s == nil {
+		return nil
+	}
+
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+
+		s.pattern = re
+	}
+
+	subschemas := []*jsonSchema{s.AdditionalProperties, s.Items}
+	for _, p := range s.Properties {
+		subschemas = append(subschemas, p)
+	}
+
+	subschemas = append(subschemas, s.AllOf...)
+	subschemas = append(subschemas, s.AnyOf...)
+	subschemas = append(subschemas, s.OneOf...)
+
+	for _, sub := range subschemas {
+		if err := sub.compile(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validate appends to violations every way v, found at path, doesn't
+// conform to s.
+func (s *jsonSchema) validate(path string, v any, violations *[]error) {
+	at := path
+	if at == "" {
+		at = "/"
+	}
+
+	fail := func(format string, args ...any) {
+		*violations = append(*violations, fmt.Errorf("%s: %s", at, fmt.Sprintf(format, args...)))
+	}
+
+	if s.never {
+		fail("not allowed")
+		return
+	}
+
+	if len(s.Type) != 0 && !s.Type.match(v) {
+		fail("expected %s, got %s", strings.Join(s.Type, " or "), jsonType(v))
+		return
+	}
+
+	if s.Const != nil {
+		var c any
+		_ = json.Unmarshal(*s.Const, &c)
+		if !jsonEqual(c, v) {
+			fail("expected %s", *s.Const)
+		}
+	}
+
+	if s.Enum != nil {
+		found := false
+		for _, e := range s.Enum {
+			found = found || jsonEqual(e, v)
+		}
+
+		if !found {
+			fail("not one of the allowed values")
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			p := path + "/" + strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+			if sub, ok := s.Properties[name]; ok {
+				sub.validate(p, v[name], violations)
+			} else if s.AdditionalProperties != nil {
+				if s.AdditionalProperties.never {
+					fail("unexpected property %q", name)
+					continue
+				}
+
+				s.AdditionalProperties.validate(p, v[name], violations)
+			}
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("fewer than %d items", *s.MinItems)
+		}
+
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("more than %d items", *s.MaxItems)
+		}
+
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s/%d", path, i), item, violations)
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("shorter than %d characters", *s.MinLength)
+		}
+
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("longer than %d characters", *s.MaxLength)
+		}
+
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("doesn't match %q", s.Pattern)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("less than %v", *s.Minimum)
+		}
+
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("greater than %v", *s.Maximum)
+		}
+
+		if s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum {
+			fail("not greater than %v", *s.ExclusiveMinimum)
+		}
+
+		if s.ExclusiveMaximum != nil && f >= *s.ExclusiveMaximum {
+			fail("not less than %v", *s.ExclusiveMaximum)
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		sub.validate(path, v, violations)
+	}
+
+	if len(s.AnyOf) != 0 && s.matching(s.AnyOf, path, v) == 0 {
+		fail("matches none of anyOf")
+	}
+
+	if len(s.OneOf) != 0 {
+		if n := s.matching(s.OneOf, path, v); n != 1 {
+			fail("matches %d of oneOf, not exactly one", n)
+		}
+	}
+}
+
+// matching counts the schemas v conforms to.
+func (s *jsonSchema) matching(schemas []*jsonSchema, path string, v any) int {
+	n := 0
+	for _, sub := range schemas {
+		var violations []error
+		if sub.validate(path, v, &violations); len(violations) == 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (t jsonTypes) match(v any) bool {
+	got := jsonType(v)
+	for _, want := range t {
+		if want == got || (want == "number" && got == "integer") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonType names the JSON Schema type of v, as decoded with UseNumber.
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+
+		return "number"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// jsonEqual compares two decoded JSON values, numbers by value.
+func jsonEqual(a, b any) bool {
+	ab, _ := json.Marshal(normalizeJSON(a))
+	bb, _ := json.Marshal(normalizeJSON(b))
+
+	return bytes.Equal(ab, bb)
+}
+
+func normalizeJSON(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = normalizeJSON(e)
+		}
+
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = normalizeJSON(e)
+		}
+
+		return s
+	}
+
+	return v
+}
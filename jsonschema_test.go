@@ -0,0 +1,82 @@
+package slogtripper
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestJSONSchemaValidator(t *testing.T) {
+	validate, err := JSONSchemaValidator([]byte(`{
+		"type": "object",
+		"required": ["id", "name"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+			"role": {"enum": ["admin", "user"]},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}},
+			"email": {"oneOf": [{"type": "null"}, {"type": "string"}]}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		body       string
+		status     int
+		violations []string
+	}{
+		{body: `{"id":1,"name":"gopher","role":"admin","tags":["a"],"email":null}`},
+		{body: `{"id":0,"name":"Gopher"}`, violations: []string{
+			"/id: less than 1",
+			`/name: doesn't match "^[a-z]+$"`,
+		}},
+		{body: `{"id":"1","extra":true,"tags":["a","b",3]}`, violations: []string{
+			`/: missing required property "name"`,
+			`/: unexpected property "extra"`,
+			"/id: expected integer, got string",
+			"/tags: more than 2 items",
+			"/tags/2: expected string, got integer",
+		}},
+		{body: `{"id":1.5,"name":"a","role":"root","email":3}`, violations: []string{
+			"/email: matches 0 of oneOf, not exactly one",
+			"/id: expected integer, got number",
+			"/role: not one of the allowed values",
+		}},
+		{body: `[]`, violations: []string{"/: expected object, got array"}},
+		{body: `<html>`, violations: []string{"body isn't JSON: invalid character '<' looking for beginning of value"}},
+		{body: `<html>`, status: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		if tt.status == 0 {
+			tt.status = http.StatusOK
+		}
+
+		err := validate(&http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(tt.body))})
+
+		var got []string
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				got = append(got, e.Error())
+			}
+		} else if err != nil {
+			got = []string{err.Error()}
+		}
+
+		if strings.Join(got, "\n") != strings.Join(tt.violations, "\n") {
+			t.Errorf("%s: expected violations\n%s\ngot\n%s", tt.body, strings.Join(tt.violations, "\n"), strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestJSONSchemaValidatorInvalid(t *testing.T) {
+	for _, schema := range []string{`{`, `{"pattern": "("}`} {
+		if _, err := JSONSchemaValidator([]byte(schema)); err == nil {
+			t.Errorf("Expected %s rejected", schema)
+		}
+	}
+}
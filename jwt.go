@@ -0,0 +1,83 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CaptureJWTClaims logs selected claims of a JWT bearer token as a jwt group
+// on the request, defaulting to sub, aud, exp and iss. The token is decoded
+// without being verified, and the Authorization header is redacted should
+// headers be captured.
+func CaptureJWTClaims(claims ...string) Option {
+	if len(claims) == 0 {
+		claims = []string{"sub", "aud", "exp", "iss"}
+	}
+
+	return func(st *SlogTripper) {
+		st.redactHeader("Authorization")
+		WithCapturer(jwtCapturer{claims: claims})(st)
+	}
+}
+
+type jwtCapturer struct {
+	claims []string
+}
+
+func (c jwtCapturer) CaptureRequest(req *http.Request) []slog.Attr {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil
+	}
+
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+
+	d := json.NewDecoder(bytes.NewReader(payload))
+	d.UseNumber()
+
+	claims := map[string]any{}
+	if err := d.Decode(&claims); err != nil {
+		return nil
+	}
+
+	attrs := []any{}
+	for _, name := range c.claims {
+		v, ok := claims[name]
+		if !ok {
+			continue
+		}
+
+		// Registered time claims are seconds since the epoch
+		if n, isNumber := v.(json.Number); isNumber && (name == "exp" || name == "iat" || name == "nbf") {
+			if secs, err := n.Int64(); err == nil {
+				attrs = append(attrs, slog.Time(name, time.Unix(secs, 0).UTC()))
+				continue
+			}
+		}
+
+		attrs = append(attrs, slog.Any(name, v))
+	}
+
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	return []slog.Attr{slog.Group("jwt", attrs...)}
+}
+
+func (jwtCapturer) CaptureResponse(res *http.Response) []slog.Attr {
+	return nil
+}
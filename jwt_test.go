@@ -0,0 +1,58 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCaptureJWTClaims(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		CaptureRequestHeaders(),
+		CaptureJWTClaims(),
+	)
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1","aud":["api"],"exp":1700000000,"email":"bob@example.com"}`))
+	token := "eyJhbGciOiJIUzI1NiJ9." + payload + ".c2lnbmF0dXJl"
+
+	req := Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	out := output.String()
+
+	if !strings.Contains(out, `"jwt":{"sub":"user-1","aud":["api"],"exp":"2023-11-14T22:13:20Z"}`) {
+		t.Errorf("Log does not contain claims: %s", out)
+	}
+
+	if strings.Contains(out, "bob@example.com") {
+		t.Errorf("Unselected claim logged: %s", out)
+	}
+
+	if strings.Contains(out, token) || !strings.Contains(out, `"Authorization":"[REDACTED]"`) {
+		t.Errorf("Token not redacted: %s", out)
+	}
+}
+
+func TestCaptureJWTClaimsNotJWT(t *testing.T) {
+	req := Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))
+	req.Header.Set("Authorization", "Bearer opaque-token")
+
+	if attrs := (jwtCapturer{claims: []string{"sub"}}).CaptureRequest(req); attrs != nil {
+		t.Errorf("Unexpected attrs for opaque token: %v", attrs)
+	}
+}
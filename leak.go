@@ -0,0 +1,112 @@
+package slogtripper
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DetectBodyLeaks watches for response bodies the caller never closes, which
+// keep their connections out of the pool until it runs dry. A body still open
+// after timeout, or garbage collected without being closed, is logged at Warn
+// as an "HTTP Response Body Leak" record naming the request, with how long it
+// was open and detected_by either "timeout" or "finalizer". A timeout of zero
+// relies on the garbage collector alone, which catches only bodies that were
+// dropped, not those held on to.
+func DetectBodyLeaks(timeout time.Duration) Option {
+	return func(st *SlogTripper) {
+		if timeout < 0 {
+			st.invalid("negative body leak timeout")
+			return
+		}
+
+		st.detectLeaks = true
+		st.leakTimeout = timeout
+	}
+}
+
+// sendLeakChecked sends req through send, watching the response body.
+func (st *SlogTripper) sendLeakChecked(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	res, err := send(req)
+	if err != nil || !wrappableBody(res) {
+		return res, err
+	}
+
+	b := &leakBody{ReadCloser: res.Body, st: st, req: req, status: res.StatusCode, opened: time.Now()}
+	res.Body = b
+
+	runtime.SetFinalizer(b, func(b *leakBody) {
+		b.report("finalizer")
+	})
+
+	if st.leakTimeout > 0 {
+		b.mu.Lock()
+		b.timer = time.AfterFunc(st.leakTimeout, func() {
+			b.report("timeout")
+		})
+		b.mu.Unlock()
+	}
+
+	return res, nil
+}
+
+// leakBody reports itself if it isn't closed in time.
+type leakBody struct {
+	io.ReadCloser
+	st     *SlogTripper
+	req    *http.Request
+	status int
+	opened time.Time
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	closed   bool
+	reported atomic.Bool
+}
+
+func (b *leakBody) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.mu.Unlock()
+
+	runtime.SetFinalizer(b, nil)
+
+	return b.ReadCloser.Close()
+}
+
+// report logs the body as leaked, once.
+func (b *leakBody) report(detectedBy string) {
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+
+	if closed || b.reported.Swap(true) || b.st.disabled.Load() {
+		return
+	}
+
+	requestGroup := []any{
+		slog.String("method", b.req.Method),
+		slog.String("url", b.req.URL.String()),
+	}
+
+	ctx := b.req.Context()
+	if id, ok := RequestIDFromContext(ctx); ok {
+		requestGroup = append(requestGroup, slog.String("id", id))
+	}
+
+	// The request may be long over, but the record belongs with it
+	b.st.logAt(context.WithoutCancel(ctx), slog.LevelWarn, "HTTP Response Body Leak",
+		slog.Group(b.st.requestKey, requestGroup...),
+		slog.Group(b.st.responseKey, slog.Int("status_code", b.status)),
+		slog.Duration("open_for", time.Since(b.opened)),
+		slog.String("detected_by", detectedBy),
+	)
+}
@@ -0,0 +1,98 @@
+package slogtripper
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectBodyLeaks(t *testing.T) {
+	newTripper := func(buf *syncBuffer, timeout time.Duration) *SlogTripper {
+		return NewSlogTripper(
+			WithLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelWarn}))),
+			WithRoundTripper(&MockRoundTripper{
+				MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("hello"))}, nil
+				},
+			}),
+			DetectBodyLeaks(timeout),
+		)
+	}
+
+	leak := func(buf *syncBuffer, detectedBy string) {
+		t.Helper()
+
+		if len(buf.Lines()) != 1 {
+			t.Fatalf("Expected one leak reported, got %d", len(buf.Lines()))
+		}
+
+		var record struct {
+			Msg     string `json:"msg"`
+			Request struct {
+				Method string `json:"method"`
+				URL    string `json:"url"`
+			} `json:"request"`
+			DetectedBy string `json:"detected_by"`
+		}
+
+		if err := json.Unmarshal(buf.Lines()[0], &record); err != nil {
+			t.Fatal(err)
+		}
+
+		if record.Msg != "HTTP Response Body Leak" || record.Request.URL != "http://localhost/leak" || record.DetectedBy != detectedBy {
+			t.Errorf("Unexpected record: %s", buf.Lines()[0])
+		}
+	}
+
+	t.Run("closed", func(t *testing.T) {
+		var buf syncBuffer
+		st := newTripper(&buf, 10*time.Millisecond)
+
+		res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/leak", nil)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		time.Sleep(30 * time.Millisecond)
+
+		if lines := buf.Lines(); len(lines) != 0 {
+			t.Errorf("Expected no leak reported, got %s", lines[0])
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		var buf syncBuffer
+		st := newTripper(&buf, 10*time.Millisecond)
+
+		res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/leak", nil)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		time.Sleep(50 * time.Millisecond)
+		leak(&buf, "timeout")
+	})
+
+	t.Run("finalizer", func(t *testing.T) {
+		var buf syncBuffer
+		st := newTripper(&buf, 0)
+
+		if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/leak", nil))); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 50 && len(buf.Lines()) == 0; i++ {
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+		}
+
+		leak(&buf, "finalizer")
+	})
+}
@@ -0,0 +1,81 @@
+package slogtripper
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+type loggingKey struct{}
+
+// WithLogSinkHosts names the hosts the logging pipeline ships records to, such
+// as a log collector an slog handler posts to over HTTP through this
+// tripper. Requests to them aren't logged, as each record shipped would
+// otherwise log another request to ship, even in audit mode. host is matched
+// as in WithHostConfig.
+//
+// Requests made with the context of a record being logged are skipped
+// without this, as they can only come from the logging pipeline; this is for
+// handlers which don't pass the context on.
+func WithLogSinkHosts(hosts ...string) Option {
+	return func(st *SlogTripper) {
+		for _, host := range hosts {
+			if host == "" {
+				st.invalid("empty log sink host")
+				return
+			}
+		}
+
+		st.logSinkHosts = append(st.logSinkHosts[:len(st.logSinkHosts):len(st.logSinkHosts)], hosts...)
+	}
+}
+
+// DowngradeLogSinks logs requests to the hosts given to WithLogSinkHosts at
+// level rather than skipping them, so that they can still be seen while
+// debugging. The logging pipeline must not ship records at level, or each
+// will ship another.
+func DowngradeLogSinks(level slog.Level) Option {
+	return func(st *SlogTripper) {
+		st.logSinkLevel = &level
+	}
+}
+
+// logging reports whether ctx is that of a record being logged.
+func logging(ctx context.Context) bool {
+	v, _ := ctx.Value(loggingKey{}).(bool)
+	return v
+}
+
+// logSinkHost reports whether req is to one of the log sink hosts.
+func (st *SlogTripper) logSinkHost(req *http.Request) bool {
+	if req == nil || req.URL == nil {
+		return false
+	}
+
+	for _, host := range st.logSinkHosts {
+		if matchHost(host, req.URL) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skipLogSink reports whether req is the logging pipeline's own, and isn't
+// to be logged.
+func (st *SlogTripper) skipLogSink(req *http.Request) bool {
+	if req == nil {
+		return false
+	}
+
+	return logging(req.Context()) || st.logSinkLevel == nil && st.logSinkHost(req)
+}
+
+// recordLevel returns the level req's record is logged at.
+func (st *SlogTripper) recordLevel(req *http.Request) slog.Level {
+	if st.logSinkLevel != nil && st.logSinkHost(req) {
+		return *st.logSinkLevel
+	}
+
+	return st.logAtLevel.Level()
+}
@@ -0,0 +1,155 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// shippingHandler ships every record it handles over HTTP through client,
+// as a handler posting to a log collector would.
+type shippingHandler struct {
+	slog.Handler
+	client  *http.Client
+	url     string
+	passCtx bool
+	shipped *atomic.Int32
+}
+
+func (h *shippingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.shipped.Add(1) > 10 {
+		return errors.New("log amplification")
+	}
+
+	if !h.passCtx {
+		ctx = context.Background()
+	}
+
+	req := Must(http.NewRequestWithContext(ctx, http.MethodPost, h.url, strings.NewReader(r.Message)))
+	res, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestLogSinkLoop(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		passCtx bool
+		opts    []Option
+	}{
+		{name: "context", passCtx: true},
+		{name: "host", opts: []Option{WithLogSinkHosts("*.collector.example")}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+			var shipped atomic.Int32
+
+			handler := &shippingHandler{
+				Handler: slog.NewJSONHandler(&output, nil),
+				url:     "http://logs.collector.example/ingest",
+				passCtx: tc.passCtx,
+				shipped: &shipped,
+			}
+
+			st := NewSlogTripper(append([]Option{
+				WithLogger(slog.New(handler)),
+				WithRoundTripper(&MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						return &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody}, nil
+					},
+				}),
+			}, tc.opts...)...)
+			handler.client = &http.Client{Transport: st}
+
+			if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+				t.Fatal(err)
+			}
+
+			if shipped.Load() != 1 {
+				t.Errorf("Expected one record shipped, got %d", shipped.Load())
+			}
+
+			if strings.Contains(output.String(), "collector") {
+				t.Errorf("Expected the shipping request not logged: %s", output.Bytes())
+			}
+		})
+	}
+}
+
+func TestDowngradeLogSinks(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody}, nil
+			},
+		}),
+		WithLogSinkHosts("collector.example"),
+		DowngradeLogSinks(slog.LevelDebug),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodPost, "http://collector.example/ingest", nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	var record struct {
+		Level string `json:"level"`
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Level != "DEBUG" {
+		t.Errorf("Expected the sink request downgraded, got %s", record.Level)
+	}
+}
+
+func TestWithLogSinkHostsInvalid(t *testing.T) {
+	if _, err := NewSlogTripperE(WithLogSinkHosts("")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected an invalid option, got %v", err)
+	}
+}
+
+func TestLogSinkAudit(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody}, nil
+			},
+		}),
+		WithLogSinkHosts("collector.example"),
+		AuditMode(nil),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodPost, "http://collector.example/ingest", nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.Len() != 0 {
+		t.Errorf("Expected audit mode to skip log sink requests: %s", output.Bytes())
+	}
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.Len() == 0 {
+		t.Error("Expected audit mode to log other requests")
+	}
+}
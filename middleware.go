@@ -0,0 +1,266 @@
+package slogtripper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"hash"
+	"log/slog"
+	"net"
+	"net/http"
+)
+
+// Middleware logs the requests a server handles with the same schema, capture
+// flags and redaction as the client side, configured by opts. Client only
+// options, such as retries or circuit breakers, are ignored. Use
+// (*SlogTripper).Middleware to share a tripper between a client and a server,
+// or to Close it.
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	return NewSlogTripper(opts...).Middleware
+}
+
+// Middleware wraps next to log every request it handles. Host scopes match
+// against the request's Host header, and the handler can find the ID it's
+// logged under with RequestIDFromContext.
+func (st *SlogTripper) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st := st.resolve(inboundRequest(r))
+		if st.traceContext {
+			r = incomingTraceContext(r)
+		}
+
+		if st.logBaggage || st.forwardBaggage {
+			r = incomingBaggage(r)
+		}
+
+		st.runOnRequest(r)
+
+		if (st.disabled.Load() && !st.audit) || !st.wantsRecord(r) {
+			st.serve(next, w, r)
+			return
+		}
+
+		start := st.clock.Now()
+		id := newRequestID()
+
+		requestGroup := st.requestAttrs(r, id, start)
+		requestGroup = append(requestGroup,
+			slog.String("host", r.Host),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+
+		var requestBody, responseBody slog.Value
+
+		if st.captureRequestBody.Load() && hasBody(r.Body) {
+			attrs, value, err := st.requestBodyAttrs(r, id)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			requestBody = value
+			requestGroup = append(requestGroup, attrs...)
+		}
+
+		requestGroup = append(requestGroup, st.requestCaptures(r)...)
+
+		var hashed *countingReader
+		if st.audit && hasBody(r.Body) {
+			hashed = &countingReader{ReadCloser: r.Body, hash: newBodyHash()}
+			r.Body = hashed
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, requestIDs{id: id, chain: id}))
+
+		rw := &responseRecorder{ResponseWriter: w, limit: st.maxBodySize}
+		if st.captureResponseBody.Load() {
+			rw.body = new(bytes.Buffer)
+		}
+
+		if st.audit {
+			rw.hash = newBodyHash()
+		}
+
+		host := r.Host
+		if st.stats != nil {
+			r = st.stats.countRequest(host, r)
+		}
+
+		total, perHost := st.inFlight.start(host)
+		requestGroup = append(requestGroup, slog.Int64("in_flight", total), slog.Int64("in_flight_host", perHost))
+
+		st.serveInFlight(next, rw, r, host)
+
+		finished := st.clock.Now()
+		elapsed := finished.Sub(start)
+
+		res := rw.response(r)
+		st.observe(host, r, res, nil, elapsed)
+
+		if st.stats != nil {
+			st.stats.addBytes(host, rw.written, true)
+		}
+
+		if hashed != nil {
+			if sum, ok := hashed.sum(); ok {
+				requestGroup = append(requestGroup, slog.String("body_sha256", sum))
+			}
+		}
+
+		responseGroup := make([]slog.Attr, 0, 16)
+		responseGroup = append(responseGroup, responseAttrs(res, elapsed, finished)...)
+
+		if rw.body != nil {
+			attrs, value := st.bodyAttrs(id+"-response", res.Header.Get("Content-Type"), rw.body.Bytes(), rw.truncated)
+			responseBody = value
+			responseGroup = append(responseGroup, attrs...)
+		}
+
+		if rw.hash != nil {
+			responseGroup = append(responseGroup, slog.String("body_sha256", hex.EncodeToString(rw.hash.Sum(nil))))
+		}
+
+		responseGroup = append(responseGroup, st.responseCaptures(res)...)
+
+		st.runOnResponse(r, res, nil, elapsed)
+		st.write(r, res, host, st.messageFor(r, res, nil), nil, requestGroup, responseGroup)
+
+		if st.recent != nil {
+			st.recent.add(r, res, nil, id, start, elapsed, requestBody, responseBody)
+		}
+	})
+}
+
+// serve hands r to next without logging it, keeping the tripper's
+// aggregates and hooks up to date.
+func (st *SlogTripper) serve(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if st.stats != nil {
+		r = st.stats.countRequest(host, r)
+	}
+
+	st.inFlight.start(host)
+
+	rw := &responseRecorder{ResponseWriter: w}
+
+	start := st.clock.Now()
+	st.serveInFlight(next, rw, r, host)
+	elapsed := st.clock.Now().Sub(start)
+
+	res := rw.response(r)
+	st.observe(host, r, res, nil, elapsed)
+
+	if st.stats != nil {
+		st.stats.addBytes(host, rw.written, true)
+	}
+
+	st.runOnResponse(r, res, nil, elapsed)
+}
+
+// serveInFlight hands r, counted in flight to host, to next, and stops
+// counting it once next returns or panics, as with http.ErrAbortHandler.
+func (st *SlogTripper) serveInFlight(next http.Handler, w http.ResponseWriter, r *http.Request, host string) {
+	defer st.inFlight.done(host)
+	next.ServeHTTP(w, r)
+}
+
+// inboundRequest returns r with the Host header in its URL, so host scopes
+// can match a request a server received.
+func inboundRequest(r *http.Request) *http.Request {
+	if r.URL == nil || r.URL.Host != "" {
+		return r
+	}
+
+	u := *r.URL
+	u.Host = r.Host
+
+	m := r.WithContext(r.Context())
+	m.URL = &u
+
+	return m
+}
+
+// responseRecorder notes the status and size of a response as it's written,
+// keeping up to limit bytes of the body when body is set.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	status    int
+	written   int64
+	body      *bytes.Buffer
+	limit     int64
+	truncated bool
+	hash      hash.Hash
+}
+
+func (rw *responseRecorder) WriteHeader(code int) {
+	// Informational responses come ahead of the status that counts
+	if rw.status == 0 && (code < 100 || code > 199 || code == http.StatusSwitchingProtocols) {
+		rw.status = code
+	}
+
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseRecorder) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+
+	n, err := rw.ResponseWriter.Write(p)
+	rw.written += int64(n)
+
+	if rw.hash != nil {
+		rw.hash.Write(p[:n])
+	}
+
+	if rw.body != nil {
+		keep := p[:n]
+		if rw.limit > 0 {
+			if room := rw.limit - int64(rw.body.Len()); int64(len(keep)) > room {
+				keep = keep[:max(room, 0)]
+				rw.truncated = true
+			}
+		}
+
+		rw.body.Write(keep)
+	}
+
+	return n, err
+}
+
+// Flush lets handlers that assert http.Flusher keep streaming.
+func (rw *responseRecorder) Flush() {
+	_ = http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Hijack lets handlers that assert http.Hijacker take over the connection.
+func (rw *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(rw.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// response describes what was written as an *http.Response, for the parts of
+// the tripper built around one.
+func (rw *responseRecorder) response(r *http.Request) *http.Response {
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(status),
+		StatusCode:    status,
+		Proto:         r.Proto,
+		ProtoMajor:    r.ProtoMajor,
+		ProtoMinor:    r.ProtoMinor,
+		Header:        rw.Header(),
+		ContentLength: rw.written,
+		Request:       r,
+	}
+}
@@ -0,0 +1,154 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var output bytes.Buffer
+	var handlerID string
+
+	handler := Middleware(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		CaptureRequestBody(),
+		CaptureResponseBody(),
+		CaptureResponseHeaders(),
+		WithMaxBodySize(4),
+		CaptureCookies(),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerID, _ = RequestIDFromContext(r.Context())
+
+		if b, _ := io.ReadAll(r.Body); string(b) != "hello" {
+			t.Errorf("Handler got a mangled body: %q", b)
+		}
+
+		w.Header().Set("Set-Cookie", "session=hunter2")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "created")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/things?a=b", strings.NewReader("hello"))
+	req.Host = "example.com"
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" {
+		t.Fatalf("Unexpected response: %d %q", rec.Code, rec.Body.String())
+	}
+
+	var record struct {
+		Request struct {
+			ID          string `json:"id"`
+			URL         string `json:"url"`
+			Host        string `json:"host"`
+			BodyContent string `json:"body_content"`
+		} `json:"request"`
+		Response struct {
+			StatusCode    int               `json:"status_code"`
+			ContentLength int64             `json:"content_length"`
+			BodyContent   string            `json:"body_content"`
+			BodyTruncated bool              `json:"body_truncated"`
+			Headers       map[string]string `json:"headers"`
+		} `json:"response"`
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("Error decoding record: %v: %s", err, output.String())
+	}
+
+	if record.Request.ID == "" || record.Request.ID != handlerID {
+		t.Errorf("Handler ID %q doesn't match the logged %q", handlerID, record.Request.ID)
+	}
+
+	if record.Request.URL != "/things?a=b" || record.Request.Host != "example.com" || record.Request.BodyContent != "hell" {
+		t.Errorf("Unexpected request: %s", output.String())
+	}
+
+	res := record.Response
+	if res.StatusCode != http.StatusCreated || res.ContentLength != 7 || res.BodyContent != "crea" || !res.BodyTruncated {
+		t.Errorf("Unexpected response: %s", output.String())
+	}
+
+	if res.Headers["Set-Cookie"] != redacted {
+		t.Errorf("Header not redacted: %s", output.String())
+	}
+}
+
+func TestMiddlewareHostConfig(t *testing.T) {
+	var output bytes.Buffer
+
+	handler := Middleware(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithHostConfig("internal.example.com", DisableLogging()),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for host, logged := range map[string]bool{
+		"internal.example.com": false,
+		"public.example.com":   true,
+	} {
+		output.Reset()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = host
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if (output.Len() != 0) != logged {
+			t.Errorf("%s: expected logged %v: %s", host, logged, output.String())
+		}
+	}
+}
+
+func TestMiddlewareFlusher(t *testing.T) {
+	handler := Middleware(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("ResponseWriter should still be a Flusher")
+		}
+
+		fmt.Fprint(w, "event")
+		flusher.Flush()
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !rec.Flushed {
+		t.Error("Response not flushed")
+	}
+}
+
+func TestMiddlewarePanicInFlight(t *testing.T) {
+	for _, opts := range [][]Option{nil, {DisableLogging()}} {
+		st := NewSlogTripper(append([]Option{WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil)))}, opts...)...)
+
+		handler := st.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		func() {
+			defer func() {
+				if v := recover(); v != http.ErrAbortHandler {
+					t.Errorf("Expected the panic passed on, got %v", v)
+				}
+			}()
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+
+		if n := st.InFlight(); n != 0 {
+			t.Errorf("Expected nothing in flight after a panic, got %d", n)
+		}
+	}
+}
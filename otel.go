@@ -0,0 +1,223 @@
+package slogtripper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// OTelMeter creates the instruments WithOTelMeter records to, with their
+// attributes as slog.Attrs. It's the part of an OpenTelemetry metric.Meter
+// the tripper uses, so the tripper doesn't depend on the OpenTelemetry SDK;
+// an adapter wraps each method of the Meter, i.e.
+//
+//	func (m meter) Float64Histogram(name, unit, description string) (slogtripper.OTelFloat64Histogram, error) {
+//		h, err := m.Meter.Float64Histogram(name, metric.WithUnit(unit), metric.WithDescription(description))
+//		return float64Histogram{h}, err
+//	}
+//
+//	func (h float64Histogram) Record(ctx context.Context, v float64, attrs ...slog.Attr) {
+//		h.Float64Histogram.Record(ctx, v, metric.WithAttributes(toKeyValues(attrs)...))
+//	}
+//
+// where toKeyValues turns string and int64 attributes into
+// attribute.String and attribute.Int64.
+type OTelMeter interface {
+	Float64Histogram(name, unit, description string) (OTelFloat64Histogram, error)
+	Int64Histogram(name, unit, description string) (OTelInt64Histogram, error)
+	Int64UpDownCounter(name, unit, description string) (OTelInt64UpDownCounter, error)
+}
+
+// OTelFloat64Histogram is a histogram created by an OTelMeter.
+type OTelFloat64Histogram interface {
+	Record(ctx context.Context, v float64, attrs ...slog.Attr)
+}
+
+// OTelInt64Histogram is a histogram created by an OTelMeter.
+type OTelInt64Histogram interface {
+	Record(ctx context.Context, v int64, attrs ...slog.Attr)
+}
+
+// OTelInt64UpDownCounter is an up down counter created by an OTelMeter.
+type OTelInt64UpDownCounter interface {
+	Add(ctx context.Context, v int64, attrs ...slog.Attr)
+}
+
+// WithOTelMeter records the OpenTelemetry semantic conventions' HTTP client
+// metrics with m, whether or not round trips are logged:
+// http.client.request.duration, http.client.request.body.size,
+// http.client.response.body.size and http.client.active_requests. Body
+// sizes are recorded when the Content-Length is known. Attributes are the
+// semantic conventions' too, http.request.method, server.address,
+// server.port, url.scheme, plus http.response.status_code,
+// network.protocol.version and error.type once there's an outcome. Requests
+// Middleware handles aren't client requests, and aren't recorded.
+func WithOTelMeter(m OTelMeter) Option {
+	return func(st *SlogTripper) {
+		if m == nil {
+			st.invalid("nil otel meter")
+			return
+		}
+
+		o := &otelMetrics{}
+
+		var err error
+		if o.duration, err = m.Float64Histogram("http.client.request.duration", "s", "Duration of HTTP client requests."); err != nil {
+			st.invalid("otel instrument: %v", err)
+			return
+		}
+
+		if o.requestSize, err = m.Int64Histogram("http.client.request.body.size", "By", "Size of HTTP client request bodies."); err != nil {
+			st.invalid("otel instrument: %v", err)
+			return
+		}
+
+		if o.responseSize, err = m.Int64Histogram("http.client.response.body.size", "By", "Size of HTTP client response bodies."); err != nil {
+			st.invalid("otel instrument: %v", err)
+			return
+		}
+
+		if o.active, err = m.Int64UpDownCounter("http.client.active_requests", "{request}", "Number of active HTTP requests."); err != nil {
+			st.invalid("otel instrument: %v", err)
+			return
+		}
+
+		st.otel = o
+	}
+}
+
+type otelMetrics struct {
+	duration     OTelFloat64Histogram
+	requestSize  OTelInt64Histogram
+	responseSize OTelInt64Histogram
+	active       OTelInt64UpDownCounter
+}
+
+// otelMethods are the methods the semantic conventions know, any other is
+// recorded as _OTHER.
+var otelMethods = map[string]bool{
+	http.MethodConnect: true,
+	http.MethodDelete:  true,
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPatch:   true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodTrace:   true,
+}
+
+// otelRequestAttrs are the attributes known before req is sent.
+func otelRequestAttrs(req *http.Request) []slog.Attr {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	} else if !otelMethods[method] {
+		method = "_OTHER"
+	}
+
+	attrs := make([]slog.Attr, 0, 7)
+	attrs = append(attrs, slog.String("http.request.method", method))
+
+	if u := req.URL; u != nil {
+		attrs = append(attrs, slog.String("server.address", u.Hostname()))
+
+		port := u.Port()
+		if port == "" {
+			port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+		}
+
+		if n, err := strconv.Atoi(port); err == nil {
+			attrs = append(attrs, slog.Int("server.port", n))
+		}
+
+		attrs = append(attrs, slog.String("url.scheme", u.Scheme))
+	}
+
+	return attrs
+}
+
+// started counts req as active.
+func (o *otelMetrics) started(req *http.Request) {
+	if req == nil {
+		return
+	}
+
+	o.active.Add(req.Context(), 1, otelRequestAttrs(req)...)
+}
+
+// record records the outcome of req and stops counting it as active.
+func (o *otelMetrics) record(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	if req == nil {
+		return
+	}
+
+	ctx := req.Context()
+	attrs := otelRequestAttrs(req)
+	o.active.Add(ctx, -1, attrs...)
+
+	attrs = append(attrs, otelOutcomeAttrs(res, err)...)
+
+	o.duration.Record(ctx, elapsed.Seconds(), attrs...)
+
+	if req.ContentLength >= 0 && (req.ContentLength > 0 || !hasBody(req.Body)) {
+		o.requestSize.Record(ctx, req.ContentLength, attrs...)
+	}
+
+	if res != nil && res.ContentLength >= 0 {
+		o.responseSize.Record(ctx, res.ContentLength, attrs...)
+	}
+}
+
+// otelOutcomeAttrs are the attributes describing how a round trip went.
+func otelOutcomeAttrs(res *http.Response, err error) []slog.Attr {
+	var attrs []slog.Attr
+	if res != nil {
+		attrs = append(attrs, slog.Int("http.response.status_code", res.StatusCode))
+
+		if res.ProtoMajor != 0 {
+			attrs = append(attrs, slog.String("network.protocol.version", otelProtocolVersion(res)))
+		}
+	}
+
+	if errorType := otelErrorType(res, err); errorType != "" {
+		attrs = append(attrs, slog.String("error.type", errorType))
+	}
+
+	return attrs
+}
+
+// otelProtocolVersion returns res's HTTP version as the semantic conventions
+// write it, i.e. 1.1 or 2.
+func otelProtocolVersion(res *http.Response) string {
+	if res.ProtoMajor >= 2 {
+		return strconv.Itoa(res.ProtoMajor)
+	}
+
+	return fmt.Sprintf("%d.%d", res.ProtoMajor, res.ProtoMinor)
+}
+
+// otelErrorType describes why a round trip failed, in few enough ways to
+// record as an attribute: the status code of an error response, timeout,
+// or the type of err.
+func otelErrorType(res *http.Response, err error) string {
+	if err == nil {
+		if res != nil && res.StatusCode >= http.StatusBadRequest {
+			return strconv.Itoa(res.StatusCode)
+		}
+
+		return ""
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+
+	return fmt.Sprintf("%T", err)
+}
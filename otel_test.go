@@ -0,0 +1,168 @@
+package slogtripper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeMeter keeps what's recorded to its instruments.
+type fakeMeter struct {
+	mu       sync.Mutex
+	recorded map[string][]fakeMeasurement
+	fail     string
+}
+
+type fakeMeasurement struct {
+	value float64
+	attrs map[string]any
+}
+
+type fakeInstrument struct {
+	m    *fakeMeter
+	name string
+}
+
+func (m *fakeMeter) instrument(name string) (fakeInstrument, error) {
+	if name == m.fail {
+		return fakeInstrument{}, errors.New("no " + name)
+	}
+
+	return fakeInstrument{m: m, name: name}, nil
+}
+
+func (m *fakeMeter) Float64Histogram(name, unit, description string) (OTelFloat64Histogram, error) {
+	return m.instrument(name)
+}
+
+func (m *fakeMeter) Int64Histogram(name, unit, description string) (OTelInt64Histogram, error) {
+	i, err := m.instrument(name)
+	return fakeInt64Histogram{i}, err
+}
+
+func (m *fakeMeter) Int64UpDownCounter(name, unit, description string) (OTelInt64UpDownCounter, error) {
+	return m.instrument(name)
+}
+
+func (i fakeInstrument) add(v float64, attrs []slog.Attr) {
+	i.m.mu.Lock()
+	defer i.m.mu.Unlock()
+
+	if i.m.recorded == nil {
+		i.m.recorded = map[string][]fakeMeasurement{}
+	}
+
+	i.m.recorded[i.name] = append(i.m.recorded[i.name], fakeMeasurement{value: v, attrs: AttrMap(attrs)})
+}
+
+func (i fakeInstrument) Record(ctx context.Context, v float64, attrs ...slog.Attr) {
+	i.add(v, attrs)
+}
+
+func (i fakeInstrument) Add(ctx context.Context, v int64, attrs ...slog.Attr) {
+	i.add(float64(v), attrs)
+}
+
+// fakeInt64Histogram records int64s, as the fake's instruments take
+// float64s.
+type fakeInt64Histogram struct{ fakeInstrument }
+
+func (h fakeInt64Histogram) Record(ctx context.Context, v int64, attrs ...slog.Attr) {
+	h.add(float64(v), attrs)
+}
+
+func TestWithOTelMeter(t *testing.T) {
+	meter := &fakeMeter{}
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.Method == http.MethodDelete {
+					return nil, context.DeadlineExceeded
+				}
+
+				return &http.Response{
+					StatusCode:    http.StatusNotFound,
+					ProtoMajor:    1,
+					ProtoMinor:    1,
+					ContentLength: 9,
+					Body:          io.NopCloser(strings.NewReader("not found")),
+				}, nil
+			},
+		}),
+		WithOTelMeter(meter),
+		DisableLogging(),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodPost, "https://api.example/users", strings.NewReader(`{"a":1}`)))); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodDelete, "http://api.example:8080/users/1", nil))); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	duration := meter.recorded["http.client.request.duration"]
+	if len(duration) != 2 {
+		t.Fatalf("Expected two durations, got %d", len(duration))
+	}
+
+	if a := duration[0].attrs; a["http.request.method"] != "POST" || a["server.address"] != "api.example" ||
+		a["server.port"] != int64(443) || a["url.scheme"] != "https" || a["http.response.status_code"] != int64(404) ||
+		a["network.protocol.version"] != "1.1" || a["error.type"] != "404" {
+		t.Errorf("Unexpected attributes %v", a)
+	}
+
+	if a := duration[1].attrs; a["server.port"] != int64(8080) || a["error.type"] != "timeout" {
+		t.Errorf("Unexpected attributes %v", a)
+	}
+
+	if sizes := meter.recorded["http.client.request.body.size"]; len(sizes) != 2 || sizes[0].value != 7 || sizes[1].value != 0 {
+		t.Errorf("Unexpected request sizes %v", sizes)
+	}
+
+	if sizes := meter.recorded["http.client.response.body.size"]; len(sizes) != 1 || sizes[0].value != 9 {
+		t.Errorf("Unexpected response sizes %v", sizes)
+	}
+
+	var active float64
+	for _, m := range meter.recorded["http.client.active_requests"] {
+		active += m.value
+	}
+
+	if n := len(meter.recorded["http.client.active_requests"]); n != 4 || active != 0 {
+		t.Errorf("Expected requests counted in and out, got %d changes to %v", n, active)
+	}
+}
+
+func TestWithOTelMeterInvalid(t *testing.T) {
+	if _, err := NewSlogTripperE(WithOTelMeter(nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected an invalid option, got %v", err)
+	}
+
+	if _, err := NewSlogTripperE(WithOTelMeter(&fakeMeter{fail: "http.client.active_requests"})); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected an invalid option, got %v", err)
+	}
+}
+
+func TestWithOTelMeterMiddleware(t *testing.T) {
+	meter := &fakeMeter{}
+
+	handler := Middleware(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithOTelMeter(meter),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(meter.recorded) != 0 {
+		t.Errorf("Expected server requests left out of client metrics, got %v", meter.recorded)
+	}
+}
@@ -0,0 +1,112 @@
+package slogtripper
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// OTelTracer starts the client spans WithOTelTracer creates, with their
+// attributes as slog.Attrs. It stands in for an OpenTelemetry trace.Tracer,
+// so the tripper doesn't depend on the OpenTelemetry SDK; an adapter starts
+// a span with
+//
+//	ctx, span := t.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(toKeyValues(attrs)...))
+//
+// from a Tracer got from the TracerProvider, and wraps it in an OTelSpan.
+type OTelTracer interface {
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, OTelSpan)
+}
+
+// OTelSpan is a span started by an OTelTracer.
+type OTelSpan interface {
+	// TraceContext returns the span's trace context, from its
+	// SpanContext
+	TraceContext() TraceContext
+	SetAttributes(attrs ...slog.Attr)
+	// RecordError adds an exception event for err
+	RecordError(err error)
+	// SetError sets the span's status to Error with description
+	SetError(description string)
+	End()
+}
+
+// WithOTelTracer starts a client span with t for each request sent,
+// retries included, named after its method and route, with the
+// OpenTelemetry semantic conventions' attributes: those WithOTelMeter
+// records plus url.full, with any password redacted. The span continues the
+// trace in the request's context, goes upstream as a traceparent, replacing
+// any PropagateTraceContext adds, and ends once the response's headers are
+// in. Failures are recorded on the span and set its status, transport errors
+// and 4xx and 5xx responses alike.
+//
+// The span's trace_id and span_id are logged at the top level, outside any
+// WithGroupName group, linking the record to the span.
+func WithOTelTracer(t OTelTracer) Option {
+	return func(st *SlogTripper) {
+		if t == nil {
+			st.invalid("nil otel tracer")
+			return
+		}
+
+		st.tracer = t
+	}
+}
+
+// roundTripInSpan sends req through roundTrip within a client span.
+func (st *SlogTripper) roundTripInSpan(req *http.Request, roundTrip func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	name := method
+	if route := st.route(req); route != "" {
+		name += " " + route
+	}
+
+	attrs := otelRequestAttrs(req)
+	if req.URL != nil {
+		attrs = append(attrs, slog.String("url.full", req.URL.Redacted()))
+	}
+
+	ctx, span := st.tracer.Start(req.Context(), name, attrs...)
+	defer span.End()
+
+	req = req.WithContext(ctx)
+
+	// A span which isn't recording may have no IDs to send
+	if tc := span.TraceContext(); validTraceContext(tc) {
+		req.Header = req.Header.Clone()
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+
+		req.Header.Set("Traceparent", tc.TraceParent())
+		if tc.TraceState != "" {
+			req.Header.Set("Tracestate", tc.TraceState)
+		} else {
+			req.Header.Del("Tracestate")
+		}
+	}
+
+	res, err := roundTrip(req)
+
+	span.SetAttributes(otelOutcomeAttrs(res, err)...)
+
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	if errorType := otelErrorType(res, err); errorType != "" {
+		span.SetError(errorType)
+	}
+
+	return res, err
+}
+
+// validTraceContext reports whether tc can be sent as a traceparent.
+func validTraceContext(tc TraceContext) bool {
+	_, ok := ParseTraceParent(tc.TraceParent(), "")
+	return ok
+}
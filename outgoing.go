@@ -0,0 +1,135 @@
+package slogtripper
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithUserAgent sets the User-Agent header of requests sent without one to
+// ua, so servers can attribute the traffic without every call site setting
+// it, and logs the User-Agent each request goes out with as its user_agent.
+// A request whose User-Agent is set, even to nothing, keeps it.
+func WithUserAgent(ua string) Option {
+	return func(st *SlogTripper) {
+		if ua == "" {
+			st.invalid("empty user agent")
+			return
+		}
+
+		st.userAgent = ua
+	}
+}
+
+// WithOutgoingHeaders adds the headers f returns for a request's context to
+// the request, such as a tenant ID or a correlation ID carried through from
+// the request being served, and logs those added as injected_headers on the
+// request, redacted like any other headers. Headers the request already has
+// are left as they are. Header funcs run in the order they were added, so
+// the first to return a header wins.
+func WithOutgoingHeaders(f func(ctx context.Context) http.Header) Option {
+	return func(st *SlogTripper) {
+		if f == nil {
+			st.invalid("nil outgoing headers func")
+			return
+		}
+
+		st.outgoingHeaders = append(st.outgoingHeaders[:len(st.outgoingHeaders):len(st.outgoingHeaders)], f)
+	}
+}
+
+type injectedHeadersKey struct{}
+
+// outgoing returns req with the headers the tripper adds to requests. req
+// itself is never changed, as RoundTrippers mustn't, so it's copied if
+// anything needs adding, with what was injected in its context.
+func (st *SlogTripper) outgoing(req *http.Request) *http.Request {
+	if req == nil || st.userAgent == "" && len(st.outgoingHeaders) == 0 && !st.traceContext &&
+		!st.forwardBaggage && !st.idempotencyKeys && st.silenceHeader == "" {
+		return req
+	}
+
+	_, hasSilenceHeader := req.Header[st.silenceHeader]
+	silence := st.silenceHeader != "" && hasSilenceHeader
+
+	_, hasUserAgent := req.Header["User-Agent"]
+	stampUserAgent := st.userAgent != "" && !hasUserAgent
+	stampTraceParent := st.needsTraceParent(req)
+	baggage := st.outgoingBaggage(req)
+	idempotencyKey := st.outgoingIdempotencyKey(req)
+
+	var injected http.Header
+	for _, f := range st.outgoingHeaders {
+		for name, values := range f(req.Context()) {
+			name = http.CanonicalHeaderKey(name)
+			if _, ok := req.Header[name]; ok || len(values) == 0 {
+				continue
+			}
+
+			if _, ok := injected[name]; ok {
+				continue
+			}
+
+			if injected == nil {
+				injected = make(http.Header)
+			}
+
+			injected[name] = values
+		}
+	}
+
+	if !stampUserAgent && !stampTraceParent && baggage == "" && idempotencyKey == "" && injected == nil && !silence {
+		return req
+	}
+
+	ctx := req.Context()
+	if injected != nil {
+		ctx = context.WithValue(ctx, injectedHeadersKey{}, injected)
+	}
+
+	if silence {
+		ctx = Silence(ctx)
+	}
+
+	out := req.WithContext(ctx)
+	out.Header = req.Header.Clone()
+	if out.Header == nil {
+		out.Header = make(http.Header)
+	}
+
+	if silence {
+		out.Header.Del(st.silenceHeader)
+	}
+
+	if stampUserAgent {
+		out.Header.Set("User-Agent", st.userAgent)
+	}
+
+	if stampTraceParent {
+		tc := childTraceContext(ctx)
+		out.Header.Set("Traceparent", tc.TraceParent())
+		if tc.TraceState != "" {
+			out.Header.Set("Tracestate", tc.TraceState)
+		}
+	}
+
+	if baggage != "" {
+		out.Header.Set("Baggage", baggage)
+	}
+
+	if idempotencyKey != "" {
+		out.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	for name, values := range injected {
+		out.Header[name] = append([]string(nil), values...)
+	}
+
+	return out
+}
+
+// injectedHeaders returns the headers WithOutgoingHeaders added to the
+// request made with ctx.
+func injectedHeaders(ctx context.Context) http.Header {
+	h, _ := ctx.Value(injectedHeadersKey{}).(http.Header)
+	return h
+}
@@ -0,0 +1,138 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"testing"
+)
+
+func TestWithUserAgent(t *testing.T) {
+	for name, test := range map[string]struct {
+		header http.Header
+		want   string
+	}{
+		"absent":   {header: nil, want: "billing/1.2"},
+		"set":      {header: http.Header{"User-Agent": {"curl/8.0"}}, want: "curl/8.0"},
+		"disabled": {header: http.Header{"User-Agent": {""}}, want: ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var output bytes.Buffer
+			var sent http.Header
+
+			st := NewSlogTripper(
+				WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+				WithRoundTripper(&MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						sent = r.Header
+						return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+					},
+				}),
+				WithUserAgent("billing/1.2"),
+			)
+
+			req := Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))
+			req.Header = test.header
+
+			if _, err := st.RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := sent.Get("User-Agent"); got != test.want {
+				t.Errorf("Expected %q sent, got %q", test.want, got)
+			}
+
+			if test.header == nil && req.Header != nil {
+				t.Error("Expected the caller's request left alone")
+			}
+
+			var record struct {
+				Request struct {
+					UserAgent string `json:"user_agent"`
+				} `json:"request"`
+			}
+
+			if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+				t.Fatal(err)
+			}
+
+			if record.Request.UserAgent != test.want {
+				t.Errorf("Expected %q logged, got %q", test.want, record.Request.UserAgent)
+			}
+		})
+	}
+}
+
+func TestWithUserAgentEmpty(t *testing.T) {
+	if _, err := NewSlogTripperE(WithUserAgent("")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected an invalid option, got %v", err)
+	}
+}
+
+type tenantKey struct{}
+
+func TestWithOutgoingHeaders(t *testing.T) {
+	var output bytes.Buffer
+	var sent http.Header
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				sent = r.Header
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			},
+		}),
+		WithOutgoingHeaders(func(ctx context.Context) http.Header {
+			tenant, _ := ctx.Value(tenantKey{}).(string)
+			return http.Header{
+				"x-tenant-id":      {tenant},
+				"X-Correlation-Id": {"abc"},
+				"Cookie":           {"session=1"},
+			}
+		}),
+		WithOutgoingHeaders(func(ctx context.Context) http.Header {
+			return http.Header{"X-Tenant-Id": {"ignored"}, "X-Empty": {}}
+		}),
+		CaptureCookies(),
+	)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	req := Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil))
+	req.Header.Set("X-Correlation-Id", "from-caller")
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent.Get("X-Tenant-Id") != "acme" || sent.Get("X-Correlation-Id") != "from-caller" || sent.Get("Cookie") != "session=1" {
+		t.Errorf("Unexpected headers sent: %v", sent)
+	}
+
+	if _, ok := sent["X-Empty"]; ok {
+		t.Error("Expected a header without values skipped")
+	}
+
+	if len(req.Header) != 1 {
+		t.Errorf("Expected the caller's request left alone, got %v", req.Header)
+	}
+
+	var record struct {
+		Request struct {
+			Injected map[string]string `json:"injected_headers"`
+		} `json:"request"`
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"X-Tenant-Id": "acme", "Cookie": redacted}
+	if !maps.Equal(record.Request.Injected, want) {
+		t.Errorf("Expected %v logged, got %v", want, record.Request.Injected)
+	}
+}
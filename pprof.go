@@ -0,0 +1,31 @@
+package slogtripper
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+)
+
+// WithProfilerLabels sets pprof labels around each round trip, so CPU and
+// heap profiles can be broken down by the dependency being called: http_host,
+// http_method and, with WithRouteResolver, http_route. Goroutines the
+// transport starts meanwhile, such as for a new connection, inherit them.
+func WithProfilerLabels() Option {
+	return func(st *SlogTripper) {
+		st.profilerLabels = true
+	}
+}
+
+// sendLabelled sends req with the tripper's pprof labels set.
+func (st *SlogTripper) sendLabelled(req *http.Request, send func(*http.Request) (*http.Response, error)) (res *http.Response, err error) {
+	labels := []string{"http_host", requestHost(req), "http_method", req.Method}
+	if route := st.route(req); route != "" {
+		labels = append(labels, "http_route", route)
+	}
+
+	pprof.Do(req.Context(), pprof.Labels(labels...), func(ctx context.Context) {
+		res, err = send(req.WithContext(ctx))
+	})
+
+	return res, err
+}
@@ -0,0 +1,34 @@
+package slogtripper
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestWithProfilerLabels(t *testing.T) {
+	var host, method string
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				host, _ = pprof.Label(r.Context(), "http_host")
+				method, _ = pprof.Label(r.Context(), "http_method")
+
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithProfilerLabels(),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodPut, "http://api.example.com/things", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if host != "api.example.com" || method != http.MethodPut {
+		t.Errorf("Unexpected labels: host=%q method=%q", host, method)
+	}
+}
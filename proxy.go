@@ -0,0 +1,71 @@
+package slogtripper
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// CaptureProxy logs the proxy the transport picked for a request as a proxy
+// group on the request: its url, with any password redacted, or direct=true
+// when it went straight to the server, and connect_failed=true when the
+// request failed before a connection through the proxy was made, such as a
+// CONNECT tunnel being refused. The proxy is only known when the proxied
+// transport is an *http.Transport, which picks it with its Proxy func.
+func CaptureProxy() Option {
+	return func(st *SlogTripper) {
+		st.captureProxy = true
+	}
+}
+
+// proxyChoice is the proxy a transport picked for a request.
+type proxyChoice struct {
+	url *url.URL
+	err error
+}
+
+// pickedProxy returns the proxy the proxied transport would pick for req,
+// and whether that can be known.
+func (st *SlogTripper) pickedProxy(req *http.Request) (*proxyChoice, bool) {
+	t, ok := st.proxyTransport.(*http.Transport)
+	if !ok || req.URL == nil {
+		return nil, false
+	}
+
+	if t.Proxy == nil {
+		return &proxyChoice{}, true
+	}
+
+	u, err := t.Proxy(req)
+
+	return &proxyChoice{url: u, err: err}, true
+}
+
+// attr returns the proxy group for a request which went through p, failing
+// with err.
+func (p *proxyChoice) attr(trace *roundTripTrace, err error) slog.Attr {
+	if p.err != nil {
+		return slog.Group("proxy", slog.String("error", p.err.Error()))
+	}
+
+	if p.url == nil {
+		return slog.Group("proxy", slog.Bool("direct", true))
+	}
+
+	attrs := []any{slog.String("url", p.url.Redacted())}
+
+	if err != nil && (isProxyConnectError(err) || trace != nil && !trace.connected()) {
+		attrs = append(attrs, slog.Bool("connect_failed", true))
+	}
+
+	return slog.Group("proxy", attrs...)
+}
+
+// isProxyConnectError reports whether err is the transport failing to dial
+// its proxy.
+func isProxyConnectError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "proxyconnect"
+}
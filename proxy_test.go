@@ -0,0 +1,124 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type proxyRecord struct {
+	Request struct {
+		Proxy struct {
+			URL           string `json:"url"`
+			Direct        bool   `json:"direct"`
+			Error         string `json:"error"`
+			ConnectFailed bool   `json:"connect_failed"`
+		} `json:"proxy"`
+	} `json:"request"`
+}
+
+func roundTripProxied(t *testing.T, transport http.RoundTripper, target string) proxyRecord {
+	t.Helper()
+
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(transport),
+		CaptureProxy(),
+	)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, target, nil)))
+	if err == nil {
+		res.Body.Close()
+	}
+
+	var record proxyRecord
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	return record
+}
+
+func TestCaptureProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	proxyURL := Must(url.Parse(proxy.URL))
+	proxyURL.User = url.UserPassword("user", "secret")
+
+	transport := &http.Transport{Proxy: http.ProxyURL(proxyURL)}
+	defer transport.CloseIdleConnections()
+
+	t.Run("proxied", func(t *testing.T) {
+		record := roundTripProxied(t, transport, "http://example.invalid/")
+
+		if p := record.Request.Proxy; p.URL != proxyURL.Redacted() || p.ConnectFailed || p.Direct {
+			t.Errorf("Unexpected proxy: %+v", p)
+		}
+	})
+
+	t.Run("tunnel refused", func(t *testing.T) {
+		record := roundTripProxied(t, transport, "https://example.invalid/")
+
+		if p := record.Request.Proxy; p.URL == "" || !p.ConnectFailed {
+			t.Errorf("Expected the CONNECT failure noted, got %+v", p)
+		}
+	})
+
+	t.Run("direct", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		record := roundTripProxied(t, &http.Transport{}, server.URL)
+
+		if p := record.Request.Proxy; !p.Direct || p.URL != "" {
+			t.Errorf("Expected a direct request, got %+v", p)
+		}
+	})
+
+	t.Run("proxy error", func(t *testing.T) {
+		failing := &http.Transport{Proxy: func(*http.Request) (*url.URL, error) {
+			return nil, errors.New("no proxy for you")
+		}}
+
+		record := roundTripProxied(t, failing, "http://example.invalid/")
+
+		if p := record.Request.Proxy; p.Error != "no proxy for you" {
+			t.Errorf("Expected the proxy func's error, got %+v", p)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		var output bytes.Buffer
+
+		st := NewSlogTripper(
+			WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+			WithRoundTripper(&MockRoundTripper{
+				MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+				},
+			}),
+			CaptureProxy(),
+		)
+
+		st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil)))
+
+		if bytes.Contains(output.Bytes(), []byte(`"proxy"`)) {
+			t.Errorf("Expected no proxy logged for a transport that can't say: %s", output.Bytes())
+		}
+	})
+}
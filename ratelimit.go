@@ -0,0 +1,83 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CaptureRateLimits logs the rate limit headers of a response as a rate_limit
+// group, covering the X-RateLimit-*, X-Rate-Limit-* and RateLimit-* variants,
+// GitHub's used/resource headers, Stripe-Should-Retry and Retry-After.
+func CaptureRateLimits() Option {
+	return WithCapturer(rateLimitCapturer{})
+}
+
+type rateLimitCapturer struct{}
+
+func (rateLimitCapturer) CaptureRequest(req *http.Request) []slog.Attr {
+	return nil
+}
+
+func (rateLimitCapturer) CaptureResponse(res *http.Response) []slog.Attr {
+	attrs := []any{}
+
+	for _, name := range []string{"limit", "remaining", "used"} {
+		if n, ok := rateLimitInt(res.Header, name); ok {
+			attrs = append(attrs, slog.Int64(name, n))
+		}
+	}
+
+	if n, ok := rateLimitInt(res.Header, "reset"); ok {
+		// Providers send either epoch seconds or seconds until the reset
+		if n > 1_000_000_000 {
+			attrs = append(attrs, slog.Time("reset", time.Unix(n, 0).UTC()))
+		} else {
+			attrs = append(attrs, slog.Duration("reset_in", time.Duration(n)*time.Second))
+		}
+	}
+
+	if v := rateLimitHeader(res.Header, "resource"); v != "" {
+		attrs = append(attrs, slog.String("resource", v))
+	}
+
+	if v := res.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			attrs = append(attrs, slog.Duration("retry_after", time.Duration(secs)*time.Second))
+		} else if at, err := http.ParseTime(v); err == nil {
+			attrs = append(attrs, slog.Time("retry_at", at))
+		}
+	}
+
+	if v, err := strconv.ParseBool(res.Header.Get("Stripe-Should-Retry")); err == nil {
+		attrs = append(attrs, slog.Bool("should_retry", v))
+	}
+
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	return []slog.Attr{slog.Group("rate_limit", attrs...)}
+}
+
+func rateLimitHeader(h http.Header, name string) string {
+	for _, prefix := range []string{"X-RateLimit-", "X-Rate-Limit-", "RateLimit-"} {
+		if v := h.Get(prefix + name); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+func rateLimitInt(h http.Header, name string) (int64, bool) {
+	v := rateLimitHeader(h, name)
+	if v == "" {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+
+	return n, err == nil
+}
@@ -0,0 +1,70 @@
+package slogtripper
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCaptureRateLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected string
+	}{
+		{
+			name: "GitHub",
+			header: http.Header{
+				"X-Ratelimit-Limit":     []string{"5000"},
+				"X-Ratelimit-Remaining": []string{"4999"},
+				"X-Ratelimit-Used":      []string{"1"},
+				"X-Ratelimit-Reset":     []string{"1700000000"},
+				"X-Ratelimit-Resource":  []string{"core"},
+			},
+			expected: `"rate_limit":{"limit":5000,"remaining":4999,"used":1,"reset":"2023-11-14T22:13:20Z","resource":"core"}`,
+		},
+		{
+			name: "IETF draft with Retry-After",
+			header: http.Header{
+				"Ratelimit-Limit":     []string{"100"},
+				"Ratelimit-Remaining": []string{"0"},
+				"Ratelimit-Reset":     []string{"30"},
+				"Retry-After":         []string{"30"},
+			},
+			expected: `"rate_limit":{"limit":100,"remaining":0,"reset_in":30000000000,"retry_after":30000000000}`,
+		},
+		{
+			name: "Stripe",
+			header: http.Header{
+				"Stripe-Should-Retry": []string{"true"},
+			},
+			expected: `"rate_limit":{"should_retry":true}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			st := NewSlogTripper(
+				WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+				WithRoundTripper(&MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						return &http.Response{StatusCode: http.StatusTooManyRequests, Header: test.header}, nil
+					},
+				}),
+				CaptureRateLimits(),
+			)
+
+			if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+				t.Fatalf("Error in roundtrip: %v", err)
+			}
+
+			if !strings.Contains(output.String(), test.expected) {
+				t.Errorf("Expected %s in log: %s", test.expected, output.String())
+			}
+		})
+	}
+}
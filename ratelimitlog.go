@@ -0,0 +1,127 @@
+package slogtripper
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WithLogRateLimit limits the records logged per host to perSecond, allowing
+// bursts of up to burst, so a tight loop against a dead host can't flood the
+// logs. Round trips over the limit still happen, they just aren't logged; how
+// many were suppressed per host is logged periodically as an "HTTP Logs
+// Suppressed" record. Call Close to stop the reports, logging the last.
+func WithLogRateLimit(perSecond float64, burst int) Option {
+	return func(st *SlogTripper) {
+		if perSecond <= 0 || burst <= 0 {
+			st.invalid("non-positive log rate limit %v burst %d", perSecond, burst)
+			return
+		}
+
+		st.logLimit = &logLimiter{
+			rate:     perSecond,
+			burst:    float64(burst),
+			interval: 10 * time.Second,
+			hosts:    map[string]*tokenBucket{},
+			done:     make(chan struct{}),
+		}
+	}
+}
+
+type logLimiter struct {
+	rate     float64
+	burst    float64
+	interval time.Duration
+	done     chan struct{}
+
+	mu    sync.Mutex
+	hosts map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed int
+}
+
+// allow reports whether a record for host may be logged, counting it as
+// suppressed when not.
+func (l *logLimiter) allow(host string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	b, ok := l.hosts[host]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.hosts[host] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		b.suppressed++
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// flush returns the suppressed counts per host since the last flush,
+// forgetting hosts whose buckets have refilled, which are as good as new.
+func (l *logLimiter) flush() []any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	hosts := make([]string, 0, len(l.hosts))
+	for host, b := range l.hosts {
+		if b.suppressed != 0 {
+			hosts = append(hosts, host)
+		} else if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+
+	attrs := make([]any, 0, len(hosts))
+	for _, host := range hosts {
+		attrs = append(attrs, slog.Int(host, l.hosts[host].suppressed))
+		l.hosts[host].suppressed = 0
+	}
+
+	return attrs
+}
+
+// run reports suppressed records every interval until stop is closed, then
+// reports the rest.
+func (l *logLimiter) run(st *SlogTripper, stop <-chan struct{}) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			l.report(st)
+			return
+		case <-ticker.C:
+			l.report(st)
+		}
+	}
+}
+
+// report logs the records suppressed since the last report, if any were.
+func (l *logLimiter) report(st *SlogTripper) {
+	if hosts := l.flush(); len(hosts) != 0 {
+		st.log(context.Background(), "HTTP Logs Suppressed", slog.Group("suppressed", hosts...))
+	}
+}
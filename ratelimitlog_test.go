@@ -0,0 +1,92 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogRateLimit(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithLogRateLimit(0.001, 2),
+	)
+	defer st.Close()
+
+	for i := 0; i < 5; i++ {
+		_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://dead/", nil)))
+	}
+	_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://alive/", nil)))
+
+	if n := strings.Count(output.String(), `"msg":"HTTP Request"`); n != 3 {
+		t.Errorf("Expected the burst of 2 plus another host logged, got %d: %s", n, output.String())
+	}
+
+	output.Reset()
+
+	hosts := st.logLimit.flush()
+	st.log(context.Background(), "HTTP Logs Suppressed", slog.Group("suppressed", hosts...))
+
+	if !strings.Contains(output.String(), `"suppressed":{"dead":3}`) {
+		t.Errorf("Unexpected suppressed counts: %s", output.String())
+	}
+
+	if hosts := st.logLimit.flush(); len(hosts) != 0 {
+		t.Errorf("Counts should reset after a report: %v", hosts)
+	}
+}
+
+func TestLogRateLimitIdleHosts(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithLogRateLimit(1000, 1),
+	)
+
+	for i := 0; i < 100; i++ {
+		_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, fmt.Sprintf("http://host%d/", i), nil)))
+	}
+
+	// Suppress one for a host which then goes quiet
+	for i := 0; i < 2; i++ {
+		_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://busy/", nil)))
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if hosts := st.logLimit.flush(); len(hosts) != 1 || len(st.logLimit.hosts) != 1 {
+		t.Errorf("Expected only the host with suppressed records kept, reported %v and kept %d", hosts, len(st.logLimit.hosts))
+	}
+
+	if st.logLimit.flush(); len(st.logLimit.hosts) != 0 {
+		t.Errorf("Expected idle hosts forgotten, kept %d", len(st.logLimit.hosts))
+	}
+
+	// What's suppressed after the last report is reported on Close
+	output.Reset()
+	_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://busy/", nil)))
+	_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://busy/", nil)))
+	st.Close()
+
+	if !strings.Contains(output.String(), `"suppressed":{"busy":1}`) {
+		t.Errorf("Expected the last suppressed count reported on Close: %s", output.String())
+	}
+}
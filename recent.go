@@ -0,0 +1,173 @@
+package slogtripper
+
+import (
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRecentBody caps the length of a body kept as a string by
+// WithRecentRoundTrips.
+const maxRecentBody = 4096
+
+// RecentRoundTrip is a round trip kept by WithRecentRoundTrips. Bodies are
+// only kept when captured, in the same (redacted) form they're logged in.
+type RecentRoundTrip struct {
+	ID           string        `json:"id"`
+	StartedAt    time.Time     `json:"started_at"`
+	Method       string        `json:"method"`
+	URL          string        `json:"url"`
+	StatusCode   int           `json:"status_code,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	TimeTaken    time.Duration `json:"time_taken"`
+	RequestBody  any           `json:"request_body,omitempty"`
+	ResponseBody any           `json:"response_body,omitempty"`
+}
+
+// WithRecentRoundTrips keeps the last n logged round trips in memory, read
+// with RecentRoundTrips or served by DebugHandler.
+func WithRecentRoundTrips(n int) Option {
+	return func(st *SlogTripper) {
+		if n <= 0 {
+			st.invalid("non-positive recent round trips %d", n)
+			return
+		}
+
+		st.recent = &recentRoundTrips{entries: make([]recentEntry, 0, n), size: n}
+	}
+}
+
+type recentRoundTrips struct {
+	mu      sync.Mutex
+	entries []recentEntry
+	size    int
+	next    int
+}
+
+// recentEntry is a kept round trip, with its bodies as logged. They're only
+// turned into RecentRoundTrip's form when read, so that lazy body values
+// aren't resolved for round trips nobody looks at.
+type recentEntry struct {
+	RecentRoundTrip
+	requestBody, responseBody slog.Value
+}
+
+func (r *recentRoundTrips) add(req *http.Request, res *http.Response, err error, id string, start time.Time, elapsed time.Duration, requestBody, responseBody slog.Value) {
+	entry := RecentRoundTrip{
+		ID:        id,
+		StartedAt: start,
+		TimeTaken: elapsed,
+	}
+
+	if req != nil {
+		entry.Method = req.Method
+		if req.URL != nil {
+			entry.URL = req.URL.String()
+		}
+	}
+
+	if res != nil {
+		entry.StatusCode = res.StatusCode
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) < r.size {
+		r.entries = append(r.entries, recentEntry{entry, requestBody, responseBody})
+	} else {
+		r.entries[r.next] = recentEntry{entry, requestBody, responseBody}
+	}
+
+	r.next = (r.next + 1) % r.size
+}
+
+// recentBody turns a logged body into a value which marshals to JSON.
+func recentBody(v slog.Value) any {
+	switch v = v.Resolve(); v.Kind() {
+	case slog.KindGroup:
+		group := map[string]any{}
+		for _, attr := range v.Group() {
+			group[attr.Key] = recentBody(attr.Value)
+		}
+
+		return group
+	case slog.KindString:
+		s := v.String()
+		if len(s) > maxRecentBody {
+			s = string(trimPartialRune([]byte(s[:maxRecentBody]))) + "…"
+		}
+
+		return s
+	case slog.KindAny:
+		if v.Any() == nil {
+			return nil
+		}
+	}
+
+	return v.Any()
+}
+
+// RecentRoundTrips returns the round trips kept by WithRecentRoundTrips,
+// newest first.
+func (st *SlogTripper) RecentRoundTrips() []RecentRoundTrip {
+	if st.recent == nil {
+		return nil
+	}
+
+	r := st.recent
+	r.mu.Lock()
+	kept := make([]recentEntry, 0, len(r.entries))
+	for i := 1; i <= len(r.entries); i++ {
+		kept = append(kept, r.entries[(r.next-i+len(r.entries))%len(r.entries)])
+	}
+	r.mu.Unlock()
+
+	entries := make([]RecentRoundTrip, len(kept))
+	for i, e := range kept {
+		entries[i] = e.RecentRoundTrip
+		entries[i].RequestBody = recentBody(e.requestBody)
+		entries[i].ResponseBody = recentBody(e.responseBody)
+	}
+
+	return entries
+}
+
+var recentTemplate = template.Must(template.New("recent").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Recent round trips</title></head>
+<body>
+<table>
+<tr><th>Started</th><th>ID</th><th>Method</th><th>URL</th><th>Status</th><th>Time taken</th><th>Error</th></tr>
+{{range .}}<tr><td>{{.StartedAt.Format "15:04:05.000"}}</td><td>{{.ID}}</td><td>{{.Method}}</td><td>{{.URL}}</td><td>{{if .StatusCode}}{{.StatusCode}}{{end}}</td><td>{{.TimeTaken}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// DebugHandler serves the round trips kept by WithRecentRoundTrips, as JSON
+// or, when the client accepts text/html or asks with ?format=html, as an HTML
+// table. It exposes request details, so mount it somewhere private.
+func (st *SlogTripper) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entries := st.RecentRoundTrips()
+
+		if r.URL.Query().Get("format") == "html" || strings.Contains(r.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			recentTemplate.Execute(w, entries)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	})
+}
@@ -0,0 +1,115 @@
+package slogtripper
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestRecentRoundTrips(t *testing.T) {
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("pong")),
+				}, nil
+			},
+		}),
+		CaptureRequestBody(),
+		CaptureResponseBody(),
+		WithRecentRoundTrips(2),
+	)
+
+	for _, path := range []string{"/1", "/2", "/3"} {
+		req := Must(http.NewRequest(http.MethodPost, "http://localhost"+path, strings.NewReader("client_secret=hunter2")))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		if _, err := st.RoundTrip(req); err != nil {
+			t.Fatalf("Error in roundtrip: %v", err)
+		}
+	}
+
+	entries := st.RecentRoundTrips()
+	if len(entries) != 2 || entries[0].URL != "http://localhost/3" || entries[1].URL != "http://localhost/2" {
+		t.Fatalf("Unexpected entries: %+v", entries)
+	}
+
+	if entries[0].ResponseBody != "pong" {
+		t.Errorf("Unexpected response body: %v", entries[0].ResponseBody)
+	}
+
+	if body, _ := entries[0].RequestBody.(map[string]any); body["client_secret"] != redacted {
+		t.Errorf("Request body should be kept redacted: %v", entries[0].RequestBody)
+	}
+}
+
+func TestDebugHandler(t *testing.T) {
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusTeapot}, nil
+			},
+		}),
+		WithRecentRoundTrips(10),
+	)
+
+	_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/<script>", nil)))
+
+	rec := httptest.NewRecorder()
+	st.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/requests", nil))
+
+	var entries []RecentRoundTrip
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("Error unmarshalling response: %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].StatusCode != http.StatusTeapot {
+		t.Errorf("Unexpected entries: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	st.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/requests?format=html", nil))
+
+	if !strings.Contains(rec.Body.String(), "<td>418</td>") || strings.Contains(rec.Body.String(), "<script>") {
+		t.Errorf("Unexpected HTML: %s", rec.Body.String())
+	}
+}
+
+// countingValuer counts the times it's resolved.
+type countingValuer struct {
+	value    string
+	resolved *int
+}
+
+func (v countingValuer) LogValue() slog.Value {
+	*v.resolved++
+	return slog.StringValue(v.value)
+}
+
+func TestRecentBodyLazy(t *testing.T) {
+	st := NewSlogTripper(WithRecentRoundTrips(1))
+
+	// A body of two byte runes, cut in the middle of one
+	resolved := 0
+	body := slog.AnyValue(countingValuer{value: strings.Repeat("é", maxRecentBody), resolved: &resolved})
+
+	st.recent.add(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil)), nil, nil, "id", time.Now(), 0, slog.Value{}, body)
+
+	if resolved != 0 {
+		t.Errorf("Expected the body left unresolved until read, resolved %d times", resolved)
+	}
+
+	s, _ := st.RecentRoundTrips()[0].ResponseBody.(string)
+	if resolved != 1 || !strings.HasSuffix(s, "…") || !utf8.ValidString(s) {
+		t.Errorf("Expected the body resolved and cut between runes, got %q", s)
+	}
+}
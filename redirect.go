@@ -0,0 +1,132 @@
+package slogtripper
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+)
+
+type requestIDKey struct{}
+
+// requestIDs identifies a round trip, and the first round trip of the
+// redirect chain it belongs to.
+type requestIDs struct {
+	id    string
+	chain string
+	hop   int
+}
+
+// RequestIDFromContext returns the ID the tripper logged a round trip under.
+// It's available from the context of the request the proxied transport
+// receives, and so from res.Request when the transport links it.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	ids, ok := ctx.Value(requestIDKey{}).(requestIDs)
+	return ids.id, ok
+}
+
+func newRequestID() string {
+	return strconv.FormatUint(rand.Uint64(), 16)
+}
+
+// redirectIDs returns the IDs for a round trip. A redirected request carries
+// the response that caused it, whose request carries the previous hop's IDs.
+func redirectIDs(req *http.Request, id string) (requestIDs, *http.Response) {
+	ids := requestIDs{id: id, chain: id}
+
+	prev := req.Response
+	if prev == nil || prev.Request == nil {
+		return ids, nil
+	}
+
+	if prevIDs, ok := prev.Request.Context().Value(requestIDKey{}).(requestIDs); ok {
+		ids.chain = prevIDs.chain
+		ids.hop = prevIDs.hop + 1
+	} else {
+		ids.hop = 1
+	}
+
+	return ids, prev
+}
+
+// redirectAttr links a redirected request to the hop before it.
+func redirectAttr(ids requestIDs, prev *http.Response) slog.Attr {
+	attrs := []any{
+		slog.Int("hop", ids.hop),
+		slog.String("chain_id", ids.chain),
+		slog.Int("from_status", prev.StatusCode),
+	}
+
+	if prevID, ok := RequestIDFromContext(prev.Request.Context()); ok {
+		attrs = append(attrs, slog.String("from_id", prevID))
+	}
+
+	if prev.Request.URL != nil {
+		attrs = append(attrs, slog.String("from_url", prev.Request.URL.String()))
+	}
+
+	return slog.Group("redirect", attrs...)
+}
+
+// LogRedirects wraps client's CheckRedirect to log an "HTTP Redirect" record
+// for every redirect followed, or refused, with the chain of URLs so far.
+// Records go through the client's SlogTripper when it has one, otherwise
+// slog.Default. The existing CheckRedirect, or the default policy of 10
+// redirects, still decides whether the redirect is followed.
+func LogRedirects(client *http.Client) {
+	check := client.CheckRedirect
+	if check == nil {
+		check = defaultCheckRedirect
+	}
+
+	st, ok := client.Transport.(*SlogTripper)
+	if !ok {
+		st = NewSlogTripper()
+	}
+
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		err := check(req, via)
+
+		chain := make([]string, 0, len(via)+1)
+		for _, r := range via {
+			chain = append(chain, r.URL.String())
+		}
+		chain = append(chain, req.URL.String())
+
+		attrs := []any{
+			slog.Int("hop", len(via)),
+			slog.Any("chain", chain),
+			slog.Bool("followed", err == nil),
+		}
+
+		if req.Response != nil {
+			attrs = append(attrs, slog.Int("status_code", req.Response.StatusCode))
+
+			if req.Response.Request != nil {
+				if ids, ok := req.Response.Request.Context().Value(requestIDKey{}).(requestIDs); ok {
+					attrs = append(attrs, slog.String("chain_id", ids.chain))
+				}
+			}
+		}
+
+		if err != nil && !errors.Is(err, http.ErrUseLastResponse) {
+			attrs = append(attrs, errorAttr(err))
+		}
+
+		st.resolve(req).log(req.Context(), "HTTP Redirect", slog.Group("redirect", attrs...))
+
+		return err
+	}
+}
+
+// defaultCheckRedirect mirrors the policy http.Client uses without a
+// CheckRedirect.
+func defaultCheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	return nil
+}
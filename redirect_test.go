@@ -0,0 +1,86 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedirectChain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+		case "/b":
+			http.Redirect(w, r, "/c", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+
+	client := NewClient(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(server.Client().Transport),
+	)
+	LogRedirects(client)
+
+	res, err := client.Get(server.URL + "/a")
+	if err != nil {
+		t.Fatalf("Error in request: %v", err)
+	}
+	res.Body.Close()
+
+	type record struct {
+		Msg     string `json:"msg"`
+		Request struct {
+			ID       string `json:"id"`
+			URL      string `json:"url"`
+			Redirect struct {
+				Hop     int    `json:"hop"`
+				ChainID string `json:"chain_id"`
+				FromID  string `json:"from_id"`
+			} `json:"redirect"`
+		} `json:"request"`
+		Redirect struct {
+			Hop     int      `json:"hop"`
+			Chain   []string `json:"chain"`
+			ChainID string   `json:"chain_id"`
+		} `json:"redirect"`
+	}
+
+	var roundTrips, redirects []record
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("Error unmarshalling log: %v", err)
+		}
+
+		if r.Msg == "HTTP Redirect" {
+			redirects = append(redirects, r)
+		} else {
+			roundTrips = append(roundTrips, r)
+		}
+	}
+
+	if len(roundTrips) != 3 || len(redirects) != 2 {
+		t.Fatalf("Expected 3 round trips and 2 redirects, got %d and %d: %s", len(roundTrips), len(redirects), output.String())
+	}
+
+	chainID := roundTrips[0].Request.ID
+	for i, r := range roundTrips[1:] {
+		if r.Request.Redirect.Hop != i+1 || r.Request.Redirect.ChainID != chainID || r.Request.Redirect.FromID != roundTrips[i].Request.ID {
+			t.Errorf("Hop %d not linked to the chain: %+v", i+1, r.Request.Redirect)
+		}
+	}
+
+	if redirects[1].Redirect.ChainID != chainID || len(redirects[1].Redirect.Chain) != 3 {
+		t.Errorf("Redirect summary not linked to the chain: %+v", redirects[1].Redirect)
+	}
+}
@@ -0,0 +1,72 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// ErrorReporter is called for every failed round trip, one which returned an
+// error or a 5xx response, with the attributes of its record. res is nil when
+// err isn't.
+type ErrorReporter func(req *http.Request, res *http.Response, err error, attrs []slog.Attr)
+
+// WithErrorReporter calls f for every failed round trip alongside its record,
+// with the same attributes, redacted as they're logged. Round trips are still
+// reported when the logger's level drops their records. AttrMap turns the
+// attributes into the nested maps error trackers take, so a Sentry adapter
+// can leave a breadcrumb with
+//
+//	slogtripper.WithErrorReporter(func(req *http.Request, res *http.Response, err error, attrs []slog.Attr) {
+//		sentry.AddBreadcrumb(&sentry.Breadcrumb{
+//			Type:     "http",
+//			Category: "http.client",
+//			Level:    sentry.LevelError,
+//			Data:     slogtripper.AttrMap(attrs),
+//		})
+//	})
+//
+// or capture an event with sentry.CaptureException(err) in place of the
+// breadcrumb.
+func WithErrorReporter(f ErrorReporter) Option {
+	return func(st *SlogTripper) {
+		if f == nil {
+			st.invalid("nil error reporter")
+			return
+		}
+
+		st.errorReporter = f
+	}
+}
+
+// AttrMap resolves attrs into a map, with groups as nested maps.
+func AttrMap(attrs []slog.Attr) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, attr := range attrs {
+		v := attr.Value.Resolve()
+		if v.Kind() == slog.KindGroup {
+			m[attr.Key] = AttrMap(v.Group())
+			continue
+		}
+
+		m[attr.Key] = v.Any()
+	}
+
+	return m
+}
+
+// failed reports whether a round trip should go to the error reporter.
+func failed(res *http.Response, err error) bool {
+	return err != nil || (res != nil && res.StatusCode >= http.StatusInternalServerError)
+}
+
+// toAttrs returns args, which are all slog.Attr, as a slice of them.
+func toAttrs(args []any) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(args))
+	for _, arg := range args {
+		if attr, ok := arg.(slog.Attr); ok {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	return attrs
+}
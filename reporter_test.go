@@ -0,0 +1,72 @@
+package slogtripper
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+func TestWithErrorReporter(t *testing.T) {
+	type report struct {
+		status int
+		err    error
+		attrs  map[string]any
+	}
+
+	var reports []report
+
+	st := NewSlogTripper(
+		// Nothing is logged at this level, but failures are still reported
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				switch r.URL.Path {
+				case "/error":
+					return nil, errors.New("connection refused")
+				case "/unavailable":
+					return &http.Response{StatusCode: http.StatusServiceUnavailable}, nil
+				}
+
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		CaptureJWTClaims(),
+		CaptureRequestHeaders(),
+		WithErrorReporter(func(req *http.Request, res *http.Response, err error, attrs []slog.Attr) {
+			r := report{err: err, attrs: AttrMap(attrs)}
+			if res != nil {
+				r.status = res.StatusCode
+			}
+
+			reports = append(reports, r)
+		}),
+	)
+
+	for _, path := range []string{"/ok", "/error", "/unavailable"} {
+		req := Must(http.NewRequest(http.MethodGet, "http://localhost"+path, nil))
+		req.Header.Set("Authorization", "Bearer secret")
+
+		_, _ = st.RoundTrip(req)
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("Expected the two failures reported, got %d", len(reports))
+	}
+
+	if reports[0].err == nil || reports[1].status != http.StatusServiceUnavailable {
+		t.Errorf("Unexpected reports: %+v", reports)
+	}
+
+	headers := reports[0].attrs["request"].(map[string]any)["headers"].(map[string]any)
+	if headers["Authorization"] != redacted {
+		t.Errorf("Reported attributes should be redacted: %v", headers)
+	}
+}
+
+func TestWithErrorReporterNil(t *testing.T) {
+	if _, err := NewSlogTripperE(WithErrorReporter(nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected an invalid option error, got %v", err)
+	}
+}
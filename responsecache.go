@@ -0,0 +1,440 @@
+package slogtripper
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCachedBody is the largest body WithCache keeps.
+const maxCachedBody = 1 << 20
+
+// cacheKeyHeaders are the request headers carrying credentials, which
+// requests must share to be served the same cached response.
+var cacheKeyHeaders = []string{"Authorization", "Cookie"}
+
+// WithCache keeps up to size responses to GET requests in memory, serving
+// requests from them while they're fresh by their Cache-Control max-age or
+// Expires header. A stale response with an ETag or Last-Modified header is
+// revalidated with a conditional request, and served again if the upstream
+// answers 304 Not Modified. Responses marked no-store or private, varying on
+// every header or with bodies over 1MiB aren't kept, and a successful
+// request with any other method to the same URL drops what's kept for it.
+//
+// As a tripper may send requests for many users, responses are only served
+// to requests with the same URL and the same Authorization and Cookie
+// headers as the one they answered.
+//
+// Every request is logged with a cache attribute: hit when served from the
+// cache, revalidated when served from it after a 304, miss when sent
+// upstream, and bypass for requests the cache doesn't handle, such as ones
+// with other methods, their own conditional headers or Cache-Control:
+// no-store. Time is told by WithClock's clock.
+func WithCache(size int) Option {
+	return func(st *SlogTripper) {
+		if size <= 0 {
+			st.invalid("cache size %d", size)
+			return
+		}
+
+		st.cache = newResponseCache(size)
+	}
+}
+
+// setCacheStatus records how the cache handled the request made with ctx,
+// for roundTrip to log.
+func setCacheStatus(ctx context.Context, status string) {
+	if n := notesFrom(ctx); n != nil {
+		n.cache = status
+	}
+}
+
+// responseCache is an LRU of responses, keyed by URL and credentials.
+type responseCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+func newResponseCache(size int) *responseCache {
+	return &responseCache{size: size, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+// cacheEntry is a response kept by the cache.
+type cacheEntry struct {
+	key        string
+	url        string
+	vary       map[string]string
+	statusCode int
+	header     http.Header
+	body       []byte
+
+	stored   time.Time
+	age      time.Duration
+	lifetime time.Duration
+	noCache  bool
+}
+
+func (c *responseCache) get(key string, req *http.Request) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	e := el.Value.(*cacheEntry)
+	for name, value := range e.vary {
+		if req.Header.Get(name) != value {
+			return nil
+		}
+	}
+
+	c.lru.MoveToFront(el)
+
+	return e
+}
+
+func (c *responseCache) put(e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+
+		return
+	}
+
+	c.entries[e.key] = c.lru.PushFront(e)
+
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// remove drops the responses kept for url, whatever their credentials.
+func (c *responseCache) remove(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.entries {
+		if el.Value.(*cacheEntry).url == url {
+			c.lru.Remove(el)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cacheKey identifies the requests which can be served the response to req.
+func cacheKey(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.URL.String())
+
+	for _, name := range cacheKeyHeaders {
+		b.WriteByte('\n')
+		b.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+
+	return b.String()
+}
+
+// sendCached sends req through send, unless it can be answered from the
+// cache.
+func (st *SlogTripper) sendCached(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	ctx := req.Context()
+	key := cacheKey(req)
+
+	if req.Method != http.MethodGet && req.Method != "" {
+		setCacheStatus(ctx, "bypass")
+
+		res, err := send(req)
+		if err == nil && req.Method != http.MethodHead && res.StatusCode < http.StatusBadRequest {
+			st.cache.remove(req.URL.String())
+		}
+
+		return res, err
+	}
+
+	directives := parseCacheControl(req.Header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok || isConditional(req) || req.Header.Get("Range") != "" {
+		setCacheStatus(ctx, "bypass")
+		return send(req)
+	}
+
+	_, revalidate := directives["no-cache"]
+
+	now := st.clock.Now()
+	e := st.cache.get(key, req)
+
+	if e != nil && !revalidate && e.fresh(now) {
+		setCacheStatus(ctx, "hit")
+		return e.response(req, now), nil
+	}
+
+	if e != nil && e.validated() {
+		conditional := req.WithContext(ctx)
+		conditional.Header = req.Header.Clone()
+		if conditional.Header == nil {
+			conditional.Header = make(http.Header)
+		}
+
+		if etag := e.header.Get("ETag"); etag != "" {
+			conditional.Header.Set("If-None-Match", etag)
+		}
+
+		if modified := e.header.Get("Last-Modified"); modified != "" {
+			conditional.Header.Set("If-Modified-Since", modified)
+		}
+
+		res, err := send(conditional)
+		if err == nil && res.StatusCode == http.StatusNotModified {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+
+			now = st.clock.Now()
+			e = e.revalidated(res, now)
+			st.cache.put(e)
+			setCacheStatus(ctx, "revalidated")
+
+			return e.response(req, now), nil
+		}
+
+		setCacheStatus(ctx, "miss")
+
+		return st.cache.store(key, req, res, err, st.clock.Now())
+	}
+
+	setCacheStatus(ctx, "miss")
+
+	res, err := send(req)
+
+	return st.cache.store(key, req, res, err, st.clock.Now())
+}
+
+// store keeps res, the response to req received at now, once its body has
+// been read, if it can be cached.
+func (c *responseCache) store(key string, req *http.Request, res *http.Response, err error, now time.Time) (*http.Response, error) {
+	if err != nil || res == nil {
+		return res, err
+	}
+
+	e, ok := newCacheEntry(key, req, res, now)
+	if !ok {
+		return res, nil
+	}
+
+	if !hasBody(res.Body) {
+		c.put(e)
+		return res, nil
+	}
+
+	res.Body = &cachingBody{ReadCloser: res.Body, buf: new(bytes.Buffer), stored: func(body []byte) {
+		e.body = body
+		c.put(e)
+	}}
+
+	return res, nil
+}
+
+// newCacheEntry returns the entry for res, received at now, if it can be
+// cached.
+func newCacheEntry(key string, req *http.Request, res *http.Response, now time.Time) (*cacheEntry, bool) {
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent, http.StatusMultipleChoices,
+		http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+	default:
+		return nil, false
+	}
+
+	directives := parseCacheControl(res.Header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return nil, false
+	}
+
+	if _, ok := directives["private"]; ok {
+		return nil, false
+	}
+
+	e := &cacheEntry{
+		key:        key,
+		url:        req.URL.String(),
+		statusCode: res.StatusCode,
+		header:     res.Header.Clone(),
+		stored:     now,
+	}
+
+	for _, name := range res.Header.Values("Vary") {
+		for _, name := range strings.Split(name, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			}
+
+			if e.vary == nil {
+				e.vary = make(map[string]string)
+			}
+
+			e.vary[name] = req.Header.Get(name)
+		}
+	}
+
+	_, e.noCache = directives["no-cache"]
+
+	if age, err := strconv.ParseInt(res.Header.Get("Age"), 10, 64); err == nil && age > 0 {
+		e.age = time.Duration(age) * time.Second
+	}
+
+	e.lifetime = freshnessLifetime(res.Header, directives, now)
+
+	if e.lifetime <= 0 && !e.validated() {
+		return nil, false
+	}
+
+	return e, true
+}
+
+// freshnessLifetime returns how long a response with header, received at
+// now, is fresh for.
+func freshnessLifetime(header http.Header, directives map[string]string, now time.Time) time.Duration {
+	if v, ok := directives["max-age"]; ok {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+
+		return 0
+	}
+
+	expires, err := http.ParseTime(header.Get("Expires"))
+	if err != nil {
+		return 0
+	}
+
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		date = now
+	}
+
+	return expires.Sub(date)
+}
+
+// fresh reports whether e can be served at now without revalidating it.
+func (e *cacheEntry) fresh(now time.Time) bool {
+	return !e.noCache && e.currentAge(now) < e.lifetime
+}
+
+func (e *cacheEntry) currentAge(now time.Time) time.Duration {
+	return e.age + now.Sub(e.stored)
+}
+
+// validated reports whether e can be revalidated.
+func (e *cacheEntry) validated() bool {
+	return e.header.Get("ETag") != "" || e.header.Get("Last-Modified") != ""
+}
+
+// revalidated returns a copy of e updated by res, a 304 received at now.
+func (e *cacheEntry) revalidated(res *http.Response, now time.Time) *cacheEntry {
+	u := *e
+	u.header = e.header.Clone()
+	for name, values := range res.Header {
+		u.header[name] = values
+	}
+
+	u.stored, u.age = now, 0
+	directives := parseCacheControl(u.header.Get("Cache-Control"))
+	_, u.noCache = directives["no-cache"]
+	u.lifetime = freshnessLifetime(u.header, directives, now)
+
+	return &u
+}
+
+// response returns e as the response to req at now.
+func (e *cacheEntry) response(req *http.Request, now time.Time) *http.Response {
+	header := e.header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(e.currentAge(now)/time.Second), 10))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.statusCode, http.StatusText(e.statusCode)),
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		ContentLength: int64(len(e.body)),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		Request:       req,
+	}
+}
+
+// isConditional reports whether req is already a conditional request.
+func isConditional(req *http.Request) bool {
+	for _, name := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range"} {
+		if req.Header.Get(name) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseCacheControl returns the directives in a Cache-Control header, with
+// lower case names and unquoted values.
+func parseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if name == "" {
+			continue
+		}
+
+		directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+	}
+
+	return directives
+}
+
+// cachingBody keeps a copy of the body read through it, handing it to stored
+// once read to the end. Bodies larger than maxCachedBody, or closed early,
+// aren't kept.
+type cachingBody struct {
+	io.ReadCloser
+	buf    *bytes.Buffer
+	stored func([]byte)
+	done   bool
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	if !b.done {
+		if b.buf.Len()+n > maxCachedBody {
+			b.done = true
+		} else {
+			b.buf.Write(p[:n])
+		}
+
+		if err == io.EOF && !b.done {
+			b.done = true
+			b.stored(b.buf.Bytes())
+		}
+	}
+
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	b.done = true
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,248 @@
+package slogtripper
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cacheTest is a tripper with a cache in front of an upstream which counts
+// the requests it gets.
+type cacheTest struct {
+	t      *testing.T
+	st     *SlogTripper
+	output syncBuffer
+	now    time.Time
+	calls  int
+
+	// respond answers the upstream requests
+	respond func(r *http.Request) *http.Response
+}
+
+func newCacheTest(t *testing.T, size int) *cacheTest {
+	ct := &cacheTest{t: t, now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	ct.st = NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&ct.output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				ct.calls++
+				return ct.respond(r), nil
+			},
+		}),
+		WithClock(ClockFunc(func() time.Time { return ct.now })),
+		WithCache(size),
+	)
+
+	return ct
+}
+
+// do sends a request, reading its body, and returns the body and how the
+// cache handled it.
+func (ct *cacheTest) do(method, url string, header http.Header) (string, string) {
+	ct.t.Helper()
+
+	req := Must(http.NewRequest(method, url, nil))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	res, err := ct.st.RoundTrip(req)
+	if err != nil {
+		ct.t.Fatal(err)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		ct.t.Fatal(err)
+	}
+	res.Body.Close()
+
+	lines := ct.output.Lines()
+
+	var record struct {
+		Request struct {
+			Cache string `json:"cache"`
+		} `json:"request"`
+	}
+
+	if err := json.Unmarshal(lines[len(lines)-1], &record); err != nil {
+		ct.t.Fatal(err)
+	}
+
+	return string(body), record.Request.Cache
+}
+
+func cached(body string, header http.Header) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestCacheHit(t *testing.T) {
+	ct := newCacheTest(t, 10)
+	ct.respond = func(r *http.Request) *http.Response {
+		return cached("hello", http.Header{"Cache-Control": {"max-age=60"}})
+	}
+
+	var statuses []string
+	for i := 0; i < 2; i++ {
+		body, status := ct.do(http.MethodGet, "http://localhost/a", nil)
+		if body != "hello" {
+			t.Errorf("Request %d: unexpected body %q", i, body)
+		}
+
+		statuses = append(statuses, status)
+		ct.now = ct.now.Add(30 * time.Second)
+	}
+
+	if ct.calls != 1 || !slices.Equal(statuses, []string{"miss", "hit"}) {
+		t.Errorf("Expected a miss then a hit, got %v with %d calls", statuses, ct.calls)
+	}
+
+	// Past max-age, with nothing to revalidate with, it's fetched again
+	if _, status := ct.do(http.MethodGet, "http://localhost/a", nil); status != "miss" || ct.calls != 2 {
+		t.Errorf("Expected a stale response refetched, got %s", status)
+	}
+
+	if _, status := ct.do(http.MethodGet, "http://localhost/a", http.Header{"Cache-Control": {"no-store"}}); status != "bypass" || ct.calls != 3 {
+		t.Errorf("Expected no-store to bypass the cache, got %s", status)
+	}
+}
+
+func TestCacheRevalidated(t *testing.T) {
+	ct := newCacheTest(t, 10)
+
+	version := `"v1"`
+	ct.respond = func(r *http.Request) *http.Response {
+		if r.Header.Get("If-None-Match") == version {
+			return &http.Response{StatusCode: http.StatusNotModified, Header: http.Header{"Cache-Control": {"max-age=10"}}, Body: http.NoBody}
+		}
+
+		return cached("body "+version, http.Header{"Etag": {version}, "Cache-Control": {"no-cache"}})
+	}
+
+	var statuses []string
+	var bodies []string
+	for i := 0; i < 4; i++ {
+		if i == 3 {
+			version = `"v2"`
+			ct.now = ct.now.Add(time.Minute)
+		}
+
+		body, status := ct.do(http.MethodGet, "http://localhost/a", nil)
+		statuses = append(statuses, status)
+		bodies = append(bodies, body)
+	}
+
+	// The 304's max-age makes the third request a hit
+	if want := []string{"miss", "revalidated", "hit", "miss"}; !slices.Equal(statuses, want) {
+		t.Errorf("Expected %v, got %v", want, statuses)
+	}
+
+	if want := []string{`body "v1"`, `body "v1"`, `body "v1"`, `body "v2"`}; !slices.Equal(bodies, want) {
+		t.Errorf("Expected %v, got %v", want, bodies)
+	}
+
+	if ct.calls != 3 {
+		t.Errorf("Expected 3 upstream calls, got %d", ct.calls)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	ct := newCacheTest(t, 1)
+	ct.respond = func(r *http.Request) *http.Response {
+		return cached(r.URL.Path, http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept"}})
+	}
+
+	ct.do(http.MethodGet, "http://localhost/a", nil)
+
+	if _, status := ct.do(http.MethodGet, "http://localhost/a", http.Header{"Accept": {"text/html"}}); status != "miss" {
+		t.Errorf("Expected a different Accept to miss, got %s", status)
+	}
+
+	if _, status := ct.do(http.MethodPut, "http://localhost/a", nil); status != "bypass" {
+		t.Errorf("Expected a PUT to bypass the cache, got %s", status)
+	}
+
+	if _, status := ct.do(http.MethodGet, "http://localhost/a", nil); status != "miss" {
+		t.Errorf("Expected the PUT to drop the cached response, got %s", status)
+	}
+
+	// Only one response is kept
+	ct.do(http.MethodGet, "http://localhost/b", nil)
+	if _, status := ct.do(http.MethodGet, "http://localhost/a", nil); status != "miss" {
+		t.Errorf("Expected the least recently used response evicted, got %s", status)
+	}
+}
+
+func TestCacheCredentials(t *testing.T) {
+	ct := newCacheTest(t, 10)
+	ct.respond = func(r *http.Request) *http.Response {
+		header := http.Header{"Cache-Control": {"max-age=60"}}
+		if r.URL.Path == "/private" {
+			header.Set("Cache-Control", "private, max-age=60")
+		}
+
+		return cached("for "+r.Header.Get("Authorization"), header)
+	}
+
+	alice := http.Header{"Authorization": {"Bearer alice"}}
+	bob := http.Header{"Authorization": {"Bearer bob"}}
+
+	ct.do(http.MethodGet, "http://localhost/a", alice)
+
+	if body, status := ct.do(http.MethodGet, "http://localhost/a", bob); body != "for Bearer bob" || status != "miss" {
+		t.Errorf("Expected another token's request sent upstream, got %q from a %s", body, status)
+	}
+
+	if body, status := ct.do(http.MethodGet, "http://localhost/a", alice); body != "for Bearer alice" || status != "hit" {
+		t.Errorf("Expected the first token's response served to it, got %q from a %s", body, status)
+	}
+
+	if _, status := ct.do(http.MethodGet, "http://localhost/a", http.Header{"Cookie": {"session=alice"}}); status != "miss" {
+		t.Errorf("Expected a request with a cookie not served another's response, got %s", status)
+	}
+
+	// A write drops what's kept for every user
+	ct.do(http.MethodPost, "http://localhost/a", bob)
+	if _, status := ct.do(http.MethodGet, "http://localhost/a", alice); status != "miss" {
+		t.Errorf("Expected the POST to drop every cached response, got %s", status)
+	}
+
+	ct.do(http.MethodGet, "http://localhost/private", alice)
+	if _, status := ct.do(http.MethodGet, "http://localhost/private", alice); status != "miss" {
+		t.Errorf("Expected a private response not cached, got %s", status)
+	}
+}
+
+func TestCacheUnreadBody(t *testing.T) {
+	ct := newCacheTest(t, 10)
+	ct.respond = func(r *http.Request) *http.Response {
+		return cached("hello", http.Header{"Cache-Control": {"max-age=60"}})
+	}
+
+	res, err := ct.st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/a", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if _, status := ct.do(http.MethodGet, "http://localhost/a", nil); status != "miss" {
+		t.Errorf("Expected a body closed early not cached, got %s", status)
+	}
+}
+
+func TestWithCacheInvalid(t *testing.T) {
+	if _, err := NewSlogTripperE(WithCache(0)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected an invalid option, got %v", err)
+	}
+}
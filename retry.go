@@ -0,0 +1,283 @@
+package slogtripper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type attemptKey struct{}
+
+type retryTrackerKey struct{}
+
+// WithAttempt marks requests made with the returned context as attempt n of a
+// retried call, logged as the request's attempt attribute.
+func WithAttempt(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, n)
+}
+
+// SetAttempt is WithAttempt for retry libraries whose hooks are only handed
+// the request about to be sent, i.e. go-retryablehttp's RequestLogHook:
+//
+//	client.RequestLogHook = func(_ retryablehttp.Logger, req *http.Request, n int) {
+//		slogtripper.SetAttempt(req, n+1)
+//	}
+func SetAttempt(req *http.Request, n int) {
+	*req = *req.WithContext(WithAttempt(req.Context(), n))
+}
+
+// retryTracker counts the round trips made with a context from TrackRetries.
+type retryTracker struct {
+	mu       sync.Mutex
+	started  time.Time
+	attempts int
+	total    time.Duration
+	status   int
+	err      error
+}
+
+// TrackRetries returns a context which counts the round trips made with it,
+// numbering their attempts when WithAttempt isn't used, and a func which logs
+// an "HTTP Retry Summary" record with the total attempts, the time spent in
+// round trips and the final outcome. Retry libraries reuse the request's
+// context between attempts, so the calls are tracked without them knowing.
+func (st *SlogTripper) TrackRetries(ctx context.Context) (context.Context, func()) {
+	tracker := &retryTracker{started: st.clock.Now()}
+
+	return context.WithValue(ctx, retryTrackerKey{}, tracker), func() {
+		tracker.mu.Lock()
+		attrs := []any{
+			slog.Int("attempts", tracker.attempts),
+			slog.Duration("time_taken", tracker.total),
+			slog.Duration("elapsed", st.clock.Now().Sub(tracker.started)),
+		}
+
+		if tracker.status != 0 {
+			attrs = append(attrs, slog.Int("status_code", tracker.status))
+		}
+
+		if tracker.err != nil {
+			attrs = append(attrs, errorAttr(tracker.err))
+		}
+		tracker.mu.Unlock()
+
+		st.log(ctx, "HTTP Retry Summary", slog.Group("retries", attrs...))
+	}
+}
+
+// startAttempt returns the attempt number for a round trip made with ctx, 0
+// when it isn't known, and the tracker to record the outcome with.
+func startAttempt(ctx context.Context) (int, *retryTracker) {
+	tracker, _ := ctx.Value(retryTrackerKey{}).(*retryTracker)
+
+	attempt, explicit := ctx.Value(attemptKey{}).(int)
+
+	if tracker != nil {
+		tracker.mu.Lock()
+		tracker.attempts++
+		if !explicit {
+			attempt = tracker.attempts
+		}
+		tracker.mu.Unlock()
+	}
+
+	return attempt, tracker
+}
+
+func (t *retryTracker) record(elapsed time.Duration, res *http.Response, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total += elapsed
+	t.err = err
+	t.status = 0
+	if res != nil {
+		t.status = res.StatusCode
+	}
+}
+
+// BackoffFunc returns how long to wait before retry n, starting at 1.
+type BackoffFunc func(n int) time.Duration
+
+// ExponentialBackoff doubles the wait from base with every retry, up to max.
+func ExponentialBackoff(base, max time.Duration) BackoffFunc {
+	return func(n int) time.Duration {
+		d := base
+		for i := 1; i < n && d < max; i++ {
+			d *= 2
+		}
+
+		return min(d, max)
+	}
+}
+
+// DefaultRetryIf retries transport errors, other than a cancelled or expired
+// context or an open circuit breaker, and 429, 502, 503 and 504 responses.
+func DefaultRetryIf(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrCircuitOpen)
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+type retryPolicy struct {
+	max     int
+	backoff BackoffFunc
+	retryIf func(*http.Response, error) bool
+}
+
+// defaultMaxRetryAfter is the longest a Retry-After header makes WithRetry
+// wait, unless WithMaxRetryAfter says otherwise.
+const defaultMaxRetryAfter = time.Minute
+
+// WithRetry retries idempotent requests up to max times, waiting backoff
+// between attempts (or longer, when a response asks with Retry-After, up to
+// WithMaxRetryAfter) for as long as retryIf reports true. A nil backoff waits ExponentialBackoff(100ms,
+// 10s), a nil retryIf is DefaultRetryIf. Every attempt is logged with its
+// attempt number, every retry with an "HTTP Retry" record giving the delay,
+// and the outcome with an "HTTP Retry Summary" record.
+//
+// Requests are idempotent when their method is, or when they carry an
+// Idempotency-Key header. A request whose body can't be rewound with GetBody
+// is never retried.
+func WithRetry(max int, backoff BackoffFunc, retryIf func(*http.Response, error) bool) Option {
+	return func(st *SlogTripper) {
+		if max < 0 {
+			st.invalid("negative max retries %d", max)
+		}
+
+		if backoff == nil {
+			backoff = ExponentialBackoff(100*time.Millisecond, 10*time.Second)
+		}
+
+		if retryIf == nil {
+			retryIf = DefaultRetryIf
+		}
+
+		st.retry = &retryPolicy{max: max, backoff: backoff, retryIf: retryIf}
+	}
+}
+
+// WithMaxRetryAfter caps how long a response's Retry-After header makes
+// WithRetry wait before the next attempt, defaulting to a minute. A server
+// asking for longer is retried after d.
+func WithMaxRetryAfter(d time.Duration) Option {
+	return func(st *SlogTripper) {
+		if d <= 0 {
+			st.invalid("non-positive max retry after %v", d)
+			return
+		}
+
+		st.maxRetryAfter = d
+	}
+}
+
+func isIdempotent(req *http.Request) bool {
+	return idempotentMethod(req.Method) || req.Header.Get("Idempotency-Key") != ""
+}
+
+func idempotentMethod(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+func (st *SlogTripper) roundTripWithRetries(req *http.Request) (*http.Response, error) {
+	if req == nil || !isIdempotent(req) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return st.roundTrip(req)
+	}
+
+	ctx, done := st.TrackRetries(req.Context())
+	defer done()
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req.WithContext(WithAttempt(ctx, attempt))
+
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			attemptReq.Body = body
+		}
+
+		res, err := st.roundTrip(attemptReq)
+
+		if attempt > st.retry.max || !st.retry.retryIf(res, err) {
+			return res, err
+		}
+
+		delay := st.retry.backoff(attempt)
+		if res != nil {
+			limit := st.maxRetryAfter
+			if limit == 0 {
+				limit = defaultMaxRetryAfter
+			}
+
+			if after := min(retryAfter(res, st.clock.Now()), limit); after > delay {
+				delay = after
+			}
+		}
+
+		attrs := []any{
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+		}
+
+		if err != nil {
+			attrs = append(attrs, errorAttr(err))
+		} else {
+			attrs = append(attrs, slog.Int("status_code", res.StatusCode))
+		}
+
+		st.log(ctx, "HTTP Retry", slog.Group("retry", attrs...))
+
+		// Drain what's left of the body so the connection can be reused
+		if res != nil && res.Body != nil {
+			io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryAfter returns how long res's Retry-After header asks to wait, as of
+// now.
+func retryAfter(res *http.Response, now time.Time) time.Duration {
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+
+	if at, err := http.ParseTime(v); err == nil {
+		return at.Sub(now)
+	}
+
+	return 0
+}
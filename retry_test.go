@@ -0,0 +1,235 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrackRetries(t *testing.T) {
+	var output bytes.Buffer
+
+	calls := 0
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				calls++
+				if calls < 3 {
+					return &http.Response{StatusCode: http.StatusServiceUnavailable}, nil
+				}
+
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+	)
+
+	ctx, done := st.TrackRetries(context.Background())
+
+	// A stand in for a retry library, reusing the context for every attempt
+	for i := 0; i < 3; i++ {
+		if _, err := st.RoundTrip(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil))); err != nil {
+			t.Fatalf("Error in roundtrip: %v", err)
+		}
+	}
+
+	done()
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 records, got %d: %s", len(lines), output.String())
+	}
+
+	for i, line := range lines[:3] {
+		if !strings.Contains(line, fmt.Sprintf(`"attempt":%d`, i+1)) {
+			t.Errorf("Record %d missing attempt: %s", i, line)
+		}
+	}
+
+	if !strings.Contains(lines[3], `"msg":"HTTP Retry Summary"`) || !strings.Contains(lines[3], `"attempts":3`) || !strings.Contains(lines[3], `"status_code":200`) {
+		t.Errorf("Unexpected summary: %s", lines[3])
+	}
+}
+
+func TestSetAttempt(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+	)
+
+	req := Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))
+	SetAttempt(req, 4)
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"attempt":4`) {
+		t.Errorf("Log does not contain attempt: %s", output.String())
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	var output bytes.Buffer
+
+	var bodies []string
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(b))
+
+				if len(bodies) < 3 {
+					return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader(""))}, nil
+				}
+
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithRetry(3, func(n int) time.Duration { return time.Millisecond }, nil),
+	)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodPut, "http://localhost/", strings.NewReader("payload"))))
+	if err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status code: %d", res.StatusCode)
+	}
+
+	if !reflect.DeepEqual(bodies, []string{"payload", "payload", "payload"}) {
+		t.Errorf("Body not rewound between attempts: %q", bodies)
+	}
+
+	out := output.String()
+	if strings.Count(out, `"msg":"HTTP Request"`) != 3 || strings.Count(out, `"msg":"HTTP Retry"`) != 2 {
+		t.Errorf("Expected 3 attempts and 2 retries logged: %s", out)
+	}
+
+	if !strings.Contains(out, `"msg":"HTTP Retry Summary"`) || !strings.Contains(out, `"attempts":3`) {
+		t.Errorf("Summary not logged: %s", out)
+	}
+}
+
+func TestWithRetryGivesUp(t *testing.T) {
+	calls := 0
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				calls++
+				return nil, errors.New("connection refused")
+			},
+		}),
+		WithRetry(2, func(n int) time.Duration { return 0 }, nil),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err == nil {
+		t.Error("Error should have been returned")
+	}
+
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+
+	calls = 0
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodPost, "http://localhost/", strings.NewReader("payload")))); err == nil {
+		t.Error("Error should have been returned")
+	}
+
+	if calls != 1 {
+		t.Errorf("POST without an Idempotency-Key should not be retried, got %d attempts", calls)
+	}
+}
+
+func TestWithRetryContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				cancel()
+				return &http.Response{StatusCode: http.StatusServiceUnavailable}, nil
+			},
+		}),
+		WithRetry(5, func(n int) time.Duration { return time.Hour }, nil),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil))); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(100*time.Millisecond, time.Second)
+
+	for n, expected := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 10: time.Second} {
+		if d := backoff(n); d != expected {
+			t.Errorf("Retry %d: expected %v, got %v", n, expected, d)
+		}
+	}
+}
+
+func TestWithRetryMaxRetryAfter(t *testing.T) {
+	var output bytes.Buffer
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	calls := 0
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				calls++
+
+				retryAfter := "86400"
+				if calls == 2 {
+					// A date an hour ahead by the tripper's clock, long past by the wall's
+					retryAfter = now.Add(time.Hour).Format(http.TimeFormat)
+				}
+
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": {retryAfter}}, Body: http.NoBody}, nil
+			},
+		}),
+		WithClock(ClockFunc(func() time.Time { return now })),
+		WithRetry(2, func(n int) time.Duration { return time.Millisecond }, nil),
+		WithMaxRetryAfter(10*time.Millisecond),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := st.RoundTrip(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatalf("Expected the retries to wait no longer than the cap: %v", err)
+	}
+
+	out := output.String()
+	if !strings.Contains(out, `"attempt":1,"delay":10000000`) {
+		t.Errorf("Expected a day's Retry-After capped at 10ms: %s", out)
+	}
+
+	if !strings.Contains(out, `"attempt":2,"delay":10000000`) {
+		t.Errorf("Expected the date's hour capped at 10ms: %s", out)
+	}
+
+	if _, err := NewSlogTripperE(WithMaxRetryAfter(0)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption, got %v", err)
+	}
+}
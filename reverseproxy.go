@@ -0,0 +1,71 @@
+package slogtripper
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+)
+
+// InstrumentReverseProxy logs p's calls to its origins through a SlogTripper
+// configured by opts, wrapping its existing Transport or http.DefaultTransport.
+// Errors the proxy hands its ErrorHandler, including those from ModifyResponse,
+// are logged as an "HTTP Proxy Error" record before the existing ErrorHandler
+// runs, or the default response of 502 Bad Gateway is sent. The returned
+// tripper can be used to Close it.
+func InstrumentReverseProxy(p *httputil.ReverseProxy, opts ...Option) *SlogTripper {
+	transport := p.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	st := NewSlogTripper(append([]Option{WithRoundTripper(transport)}, opts...)...)
+	p.Transport = st
+
+	if modify := p.ModifyResponse; modify != nil {
+		p.ModifyResponse = func(res *http.Response) error {
+			if err := modify(res); err != nil {
+				return fmt.Errorf("modify response: %w", err)
+			}
+
+			return nil
+		}
+	}
+
+	handle := p.ErrorHandler
+	p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		st.resolve(r).logProxyError(r, err)
+
+		if handle != nil {
+			handle(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	return st
+}
+
+// logProxyError logs an error a reverse proxy hit handling r.
+func (st *SlogTripper) logProxyError(r *http.Request, err error) {
+	if st.disabled.Load() || st.summaryOnly {
+		return
+	}
+
+	attrs := []any{
+		slog.String("method", r.Method),
+		slog.String("host", r.Host),
+		slog.String("remote_addr", r.RemoteAddr),
+	}
+
+	if r.URL != nil {
+		attrs = append(attrs, slog.String("url", r.URL.String()))
+	}
+
+	if id, ok := RequestIDFromContext(r.Context()); ok {
+		attrs = append(attrs, slog.String("id", id))
+	}
+
+	st.log(r.Context(), "HTTP Proxy Error", slog.Group(st.requestKey, attrs...), errorAttr(err))
+}
@@ -0,0 +1,55 @@
+package slogtripper
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestInstrumentReverseProxy(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Reject", r.URL.Query().Get("reject"))
+	}))
+	defer origin.Close()
+
+	var output bytes.Buffer
+
+	p := httputil.NewSingleHostReverseProxy(Must(url.Parse(origin.URL)))
+	p.ModifyResponse = func(res *http.Response) error {
+		if res.Header.Get("X-Reject") != "" {
+			return errors.New("rejected")
+		}
+
+		return nil
+	}
+
+	st := InstrumentReverseProxy(p, WithLogger(slog.New(slog.NewJSONHandler(&output, nil))))
+	defer st.Close()
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK || !strings.Contains(output.String(), `"msg":"HTTP Request"`) {
+		t.Errorf("Expected the origin call logged: %d %s", rec.Code, output.String())
+	}
+
+	output.Reset()
+
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?reject=1", nil))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("Expected a bad gateway, got %d", rec.Code)
+	}
+
+	if !strings.Contains(output.String(), `"msg":"HTTP Proxy Error"`) ||
+		!strings.Contains(output.String(), `"message":"modify response: rejected"`) {
+		t.Errorf("Expected the proxy error logged: %s", output.String())
+	}
+}
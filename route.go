@@ -0,0 +1,83 @@
+package slogtripper
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// RouteResolver returns the route template a request was for, such as
+// /users/{id}, or "" if it doesn't know.
+type RouteResolver func(req *http.Request) string
+
+// WithRouteResolver logs the route f resolves each request to as route,
+// alongside its full URL, giving logs something of low cardinality to
+// aggregate on. The route is also added to WithProfilerLabels as http_route.
+func WithRouteResolver(f RouteResolver) Option {
+	return func(st *SlogTripper) {
+		if f == nil {
+			st.invalid("nil route resolver")
+			return
+		}
+
+		st.routeResolver = f
+	}
+}
+
+var (
+	uuidSegment = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+	hexSegment  = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+)
+
+// MaskIDSegments resolves a request's route by replacing the segments of its
+// path which look like IDs, those made of digits, UUIDs or long hex strings,
+// with {id}.
+func MaskIDSegments() RouteResolver {
+	return func(req *http.Request) string {
+		if req.URL == nil {
+			return ""
+		}
+
+		segments := strings.Split(req.URL.Path, "/")
+		for i, segment := range segments {
+			if isIDSegment(segment) {
+				segments[i] = "{id}"
+			}
+		}
+
+		return strings.Join(segments, "/")
+	}
+}
+
+func isIDSegment(segment string) bool {
+	if segment == "" {
+		return false
+	}
+
+	if strings.Trim(segment, "0123456789") == "" {
+		return true
+	}
+
+	return uuidSegment.MatchString(segment) || hexSegment.MatchString(segment)
+}
+
+// MaskPathRegexp resolves a request's route by replacing the matches of re
+// in its path with replacement, as regexp.ReplaceAllString does.
+func MaskPathRegexp(re *regexp.Regexp, replacement string) RouteResolver {
+	return func(req *http.Request) string {
+		if req.URL == nil {
+			return ""
+		}
+
+		return re.ReplaceAllString(req.URL.Path, replacement)
+	}
+}
+
+// route returns the route req resolves to, if the tripper has a resolver.
+func (st *SlogTripper) route(req *http.Request) string {
+	if st.routeResolver == nil || req == nil {
+		return ""
+	}
+
+	return st.routeResolver(req)
+}
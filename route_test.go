@@ -0,0 +1,51 @@
+package slogtripper
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMaskIDSegments(t *testing.T) {
+	tests := map[string]string{
+		"/users/123": "/users/{id}",
+		"/users/123/orders/0b8a7f9e-1c2d-4e5f-8a9b-0c1d2e3f4a5b": "/users/{id}/orders/{id}",
+		"/commits/5f3c2a9b8d7e6f1a0b9c":                          "/commits/{id}",
+		"/users/me/v2":                                           "/users/me/v2",
+		"/":                                                      "/",
+	}
+
+	resolve := MaskIDSegments()
+
+	for path, expected := range tests {
+		req := Must(http.NewRequest(http.MethodGet, "http://localhost"+path, nil))
+		if route := resolve(req); route != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, route)
+		}
+	}
+}
+
+func TestWithRouteResolver(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithRouteResolver(MaskPathRegexp(regexp.MustCompile(`/repos/[^/]+/[^/]+`), "/repos/{owner}/{repo}")),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/repos/golang/go/issues", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"url":"http://localhost/repos/golang/go/issues","route":"/repos/{owner}/{repo}/issues"`) {
+		t.Errorf("Expected the route logged alongside the URL: %s", output.String())
+	}
+}
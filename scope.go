@@ -0,0 +1,178 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// scope is a set of options applied on top of the parent configuration for
+// requests matching match. The resulting tripper is built once, after all of
+// the parent's options have been applied.
+type scope struct {
+	match func(*http.Request) bool
+	opts  []Option
+	st    *SlogTripper
+}
+
+// WithHostConfig applies opts on top of the tripper's configuration for
+// requests to host. host is compared against the request URL's host name, or
+// against host:port when it contains a port. A leading "*." matches any
+// subdomain. When several host or method configs match a request the first
+// one declared wins.
+func WithHostConfig(host string, opts ...Option) Option {
+	return func(st *SlogTripper) {
+		if host == "" {
+			st.invalid("empty host in host config")
+		}
+
+		st.scopes = append(st.scopes, &scope{
+			match: func(req *http.Request) bool {
+				return req.URL != nil && matchHost(host, req.URL)
+			},
+			opts: opts,
+		})
+	}
+}
+
+// ForMethods returns a function that applies opts on top of the tripper's
+// configuration for requests using one of methods, i.e.
+//
+//	ForMethods(http.MethodPost, http.MethodPut)(CaptureRequestBody())
+func ForMethods(methods ...string) func(opts ...Option) Option {
+	return func(opts ...Option) Option {
+		return func(st *SlogTripper) {
+			if len(methods) == 0 {
+				st.invalid("no methods in method config")
+			}
+
+			st.scopes = append(st.scopes, &scope{
+				match: func(req *http.Request) bool {
+					method := req.Method
+					if method == "" {
+						method = http.MethodGet
+					}
+
+					for _, m := range methods {
+						if strings.EqualFold(m, method) {
+							return true
+						}
+					}
+
+					return false
+				},
+				opts: opts,
+			})
+		}
+	}
+}
+
+// ForRequests returns a function that applies opts on top of the tripper's
+// configuration for requests match reports true for, i.e. a single endpoint.
+func ForRequests(match func(req *http.Request) bool) func(opts ...Option) Option {
+	return func(opts ...Option) Option {
+		return func(st *SlogTripper) {
+			if match == nil {
+				st.invalid("nil request matcher")
+				return
+			}
+
+			st.scopes = append(st.scopes, &scope{match: match, opts: opts})
+		}
+	}
+}
+
+// DisableLogging turns off logging, requests are passed straight through to
+// the proxied transport. Mostly useful in combination with WithHostConfig.
+func DisableLogging() Option {
+	return func(st *SlogTripper) {
+		st.disabled = newBool(true)
+	}
+}
+
+func matchHost(pattern string, u *url.URL) bool {
+	host := u.Hostname()
+	if strings.Contains(pattern, ":") {
+		host = host + ":" + u.Port()
+	}
+
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(suffix))
+	}
+
+	return strings.EqualFold(pattern, host)
+}
+
+func (st *SlogTripper) clone() *SlogTripper {
+	c := *st
+	return &c
+}
+
+// With returns a copy of st with opts applied on top of its configuration.
+// The copy shares st's transport, but its runtime settings are its own.
+func (st *SlogTripper) With(opts ...Option) *SlogTripper {
+	c := st.clone()
+	c.errs = nil
+	c.detach()
+
+	c.scopes = make([]*scope, 0, len(st.scopes))
+	for _, sc := range st.scopes {
+		c.scopes = append(c.scopes, &scope{match: sc.match, opts: sc.opts})
+	}
+
+	for _, f := range opts {
+		f(c)
+	}
+
+	c.buildScopes()
+	c.start(st)
+
+	return c
+}
+
+// detach gives st its own copy of the settings which can be changed at
+// runtime, so setters no longer affect the tripper it was cloned from.
+func (st *SlogTripper) detach() {
+	level := st.logAtLevel.Level()
+	st.logAtLevel = new(slog.LevelVar)
+	st.logAtLevel.Set(level)
+
+	st.captureRequestBody = newBool(st.captureRequestBody.Load())
+	st.captureResponseBody = newBool(st.captureResponseBody.Load())
+	st.captureRequestHeaders = newBool(st.captureRequestHeaders.Load())
+	st.captureResponseHeaders = newBool(st.captureResponseHeaders.Load())
+	st.disabled = newBool(st.disabled.Load())
+}
+
+func (st *SlogTripper) buildScopes() {
+	for _, sc := range st.scopes {
+		child := st.clone()
+		child.scopes = nil
+		child.errs = nil
+
+		for _, f := range sc.opts {
+			f(child)
+		}
+
+		child.buildScopes()
+		sc.st = child
+
+		st.errs = append(st.errs, child.errs...)
+	}
+}
+
+// resolve returns the tripper whose configuration applies to req.
+func (st *SlogTripper) resolve(req *http.Request) *SlogTripper {
+	if req == nil {
+		return st
+	}
+
+	for _, sc := range st.scopes {
+		if sc.match(req) {
+			return sc.st.resolve(req)
+		}
+	}
+
+	return st
+}
@@ -0,0 +1,162 @@
+package slogtripper
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHostConfig(t *testing.T) {
+	mrt := &MockRoundTripper{
+		MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"ping": "pong"}`)),
+			}, nil
+		},
+	}
+
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(mrt),
+		WithHostConfig("payments.example.com", CaptureResponseBody()),
+		WithHostConfig("*.metrics.example.com", DisableLogging()),
+	)
+
+	tests := []struct {
+		url      string
+		logged   bool
+		withBody bool
+	}{
+		{url: "http://payments.example.com/charge", logged: true, withBody: true},
+		{url: "http://PAYMENTS.example.com:8080/charge", logged: true, withBody: true},
+		{url: "http://other.example.com/", logged: true, withBody: false},
+		{url: "http://eu.metrics.example.com/push", logged: false},
+	}
+
+	for _, test := range tests {
+		output.Reset()
+
+		if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, test.url, nil))); err != nil {
+			t.Fatalf("Error in roundtrip: %v", err)
+		}
+
+		if logged := output.Len() != 0; logged != test.logged {
+			t.Errorf("%s: expected logged=%v, got output %q", test.url, test.logged, output.String())
+		}
+
+		if withBody := strings.Contains(output.String(), "body_content"); withBody != test.withBody {
+			t.Errorf("%s: expected body captured=%v, got output %q", test.url, test.withBody, output.String())
+		}
+	}
+}
+
+func TestHostConfigInheritsLaterOptions(t *testing.T) {
+	var output bytes.Buffer
+
+	// The logger is set after the host config, the host config should still use it
+	st := NewSlogTripper(
+		WithHostConfig("localhost", CaptureRequestHeaders()),
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+	)
+
+	req := Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))
+	req.Header.Set("X-Example", "value")
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"X-Example":"value"`) {
+		t.Errorf("Log does not contain request header: %s", output.String())
+	}
+}
+
+func TestForMethods(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		ForMethods(http.MethodPost, http.MethodPut)(
+			CaptureRequestBody(),
+			WithLoggingLevel(slog.LevelDebug),
+		),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodPost, "http://localhost/", strings.NewReader(`{"hello": "world"}`)))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), "body_content") || !strings.Contains(output.String(), `"level":"DEBUG"`) {
+		t.Errorf("POST should be logged at debug with its body: %s", output.String())
+	}
+
+	output.Reset()
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if strings.Contains(output.String(), "body_content") || !strings.Contains(output.String(), `"level":"INFO"`) {
+		t.Errorf("GET should be logged at info without a body: %s", output.String())
+	}
+}
+
+func TestWith(t *testing.T) {
+	mrt := &MockRoundTripper{
+		MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		},
+	}
+
+	var output bytes.Buffer
+
+	base := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(mrt),
+		WithHostConfig("localhost", CaptureRequestHeaders()),
+	)
+
+	derived := base.With(CaptureRequestBody())
+
+	if derived.proxyTransport != base.proxyTransport {
+		t.Error("Derived tripper should share the transport")
+	}
+
+	if base.captureRequestBody.Load() {
+		t.Error("Deriving modified the base tripper")
+	}
+
+	req := Must(http.NewRequest(http.MethodPost, "http://localhost/", strings.NewReader("payload")))
+	req.Header.Set("X-Example", "value")
+
+	if _, err := derived.RoundTrip(req); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	// Host config applies on top of the derived configuration
+	if !strings.Contains(output.String(), `"body_content":"payload"`) || !strings.Contains(output.String(), `"X-Example":"value"`) {
+		t.Errorf("Derived tripper did not apply its options: %s", output.String())
+	}
+
+	derived.SetEnabled(false)
+
+	if base.disabled.Load() {
+		t.Error("Runtime settings on the derived tripper should not affect the base")
+	}
+}
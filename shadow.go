@@ -0,0 +1,231 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShadowConfig configures WithShadow. At least one of BaseURL and Transport
+// is set.
+type ShadowConfig struct {
+	// BaseURL is where shadow requests go: its scheme and host replace the
+	// request's, and its path prefixes the request's path
+	BaseURL string
+	// Transport sends shadow requests, defaulting to http.DefaultTransport
+	Transport http.RoundTripper
+	// Match picks the requests to shadow, defaulting to all of them
+	Match func(*http.Request) bool
+	// CompareBodies compares SHA-256 digests of the response bodies too
+	CompareBodies bool
+	// Timeout bounds each shadow request, defaulting to 30s
+	Timeout time.Duration
+}
+
+// WithShadow sends a copy of every request it matches to a canary alongside
+// the real one, for validating a migration against live traffic. The caller
+// only ever sees the real response. Where the canary's status code differs,
+// or it fails, or CompareBodies is set and the bodies differ, an "HTTP Shadow
+// Mismatch" record is logged with both outcomes.
+//
+// Request bodies are read into memory to be sent twice. With CompareBodies,
+// the comparison waits for the caller to read the real body to the end, and
+// bodies it doesn't are compared by status code alone. Close waits for
+// outstanding comparisons, so real bodies must be closed.
+func WithShadow(c ShadowConfig) Option {
+	return func(st *SlogTripper) {
+		var base *url.URL
+		if c.BaseURL != "" {
+			u, err := url.Parse(c.BaseURL)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				st.invalid("shadow base URL %q isn't absolute", c.BaseURL)
+				return
+			}
+
+			base = u
+		} else if c.Transport == nil {
+			st.invalid("shadow without a base URL or transport")
+			return
+		}
+
+		if c.Timeout < 0 {
+			st.invalid("negative shadow timeout")
+			return
+		}
+
+		if c.Transport == nil {
+			c.Transport = http.DefaultTransport
+		}
+
+		if c.Timeout == 0 {
+			c.Timeout = 30 * time.Second
+		}
+
+		st.shadow = &shadow{config: c, base: base}
+	}
+}
+
+type shadow struct {
+	config ShadowConfig
+	base   *url.URL
+	wg     sync.WaitGroup
+}
+
+// shadowOutcome is how one side of a shadowed round trip went.
+type shadowOutcome struct {
+	status int
+	sum    string
+	err    error
+}
+
+func (o shadowOutcome) attrs() []any {
+	attrs := []any{}
+	if o.err != nil {
+		attrs = append(attrs, errorAttr(o.err))
+	} else {
+		attrs = append(attrs, slog.Int("status_code", o.status))
+	}
+
+	if o.sum != "" {
+		attrs = append(attrs, slog.String("body_sha256", o.sum))
+	}
+
+	return attrs
+}
+
+// sendShadowed sends req through send, and a copy of it to the canary.
+func (st *SlogTripper) sendShadowed(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	sh := st.shadow
+	if sh.config.Match != nil && !sh.config.Match(req) {
+		return send(req)
+	}
+
+	var content []byte
+	if hasBody(req.Body) {
+		var err error
+		content, err = io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.WithContext(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(content))
+	}
+
+	// The canary outlives the caller's interest in the real response
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), sh.config.Timeout)
+	canary := make(chan shadowOutcome, 1)
+
+	sh.wg.Add(1)
+	go func() {
+		defer cancel()
+		canary <- sh.send(ctx, req, content)
+	}()
+
+	res, err := send(req)
+
+	compare := func(primary shadowOutcome) {
+		defer sh.wg.Done()
+		st.compareShadow(ctx, req, primary, <-canary)
+	}
+
+	switch {
+	case err != nil:
+		go compare(shadowOutcome{err: err})
+	case sh.config.CompareBodies && wrappableBody(res):
+		body := newCountingBody(res.Body)
+		body.hash = newBodyHash()
+		res.Body = body
+
+		status := res.StatusCode
+		body.then(func() {
+			_, _, sum := body.result()
+			go compare(shadowOutcome{status: status, sum: sum})
+		})
+	default:
+		go compare(shadowOutcome{status: res.StatusCode})
+	}
+
+	return res, err
+}
+
+// send sends a copy of req, with content as its body, to the canary.
+func (sh *shadow) send(ctx context.Context, req *http.Request, content []byte) shadowOutcome {
+	sreq := req.Clone(ctx)
+	if sh.base != nil {
+		u := *sreq.URL
+		u.Scheme = sh.base.Scheme
+		u.Host = sh.base.Host
+		u.Path = strings.TrimSuffix(sh.base.Path, "/") + u.Path
+		if u.RawPath != "" {
+			u.RawPath = strings.TrimSuffix(sh.base.EscapedPath(), "/") + u.RawPath
+		}
+
+		sreq.URL = &u
+		sreq.Host = ""
+	}
+
+	if content != nil {
+		sreq.Body = io.NopCloser(bytes.NewReader(content))
+	}
+
+	res, err := sh.config.Transport.RoundTrip(sreq)
+	if err != nil {
+		return shadowOutcome{err: err}
+	}
+	defer res.Body.Close()
+
+	outcome := shadowOutcome{status: res.StatusCode}
+
+	if sh.config.CompareBodies {
+		h := newBodyHash()
+		if _, err := io.Copy(h, res.Body); err != nil {
+			outcome.err = err
+			return outcome
+		}
+
+		outcome.sum = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return outcome
+}
+
+// compareShadow logs primary and canary if they differ.
+func (st *SlogTripper) compareShadow(ctx context.Context, req *http.Request, primary, canary shadowOutcome) {
+	if st.disabled.Load() {
+		return
+	}
+
+	if primary.err != nil && canary.err != nil {
+		return
+	}
+
+	if primary.err == nil && canary.err == nil && primary.status == canary.status &&
+		(primary.sum == "" || canary.sum == "" || primary.sum == canary.sum) {
+		return
+	}
+
+	attrs := []any{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+	}
+
+	if id, ok := RequestIDFromContext(req.Context()); ok {
+		attrs = append(attrs, slog.String("id", id))
+	}
+
+	st.log(ctx, "HTTP Shadow Mismatch",
+		slog.Group(st.requestKey, attrs...),
+		slog.Group("primary", primary.attrs()...),
+		slog.Group("shadow", canary.attrs()...),
+	)
+}
@@ -0,0 +1,115 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithShadow(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer primary.Close()
+
+	var canaryPaths []string
+	canary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		canaryPaths = append(canaryPaths, r.URL.Path)
+
+		switch r.URL.Path {
+		case "/v2/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/v2/different":
+			w.Write([]byte("something else"))
+		default:
+			body, _ := io.ReadAll(r.Body)
+			w.Write(body)
+		}
+	}))
+	defer canary.Close()
+
+	var buf bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithShadow(ShadowConfig{BaseURL: canary.URL + "/v2", CompareBodies: true}),
+	)
+
+	for _, path := range []string{"/same", "/missing", "/different"} {
+		req := Must(http.NewRequest(http.MethodPost, primary.URL+path, strings.NewReader("hello")))
+
+		res, err := st.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if string(body) != "hello" {
+			t.Errorf("Expected the primary response, got %q", body)
+		}
+	}
+
+	st.Close()
+
+	if strings.Join(canaryPaths, ",") != "/v2/same,/v2/missing,/v2/different" {
+		t.Errorf("Unexpected shadow requests: %v", canaryPaths)
+	}
+
+	type outcome struct {
+		StatusCode int    `json:"status_code"`
+		BodySHA256 string `json:"body_sha256"`
+	}
+
+	mismatches := map[string][2]outcome{}
+
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var record struct {
+			Msg     string `json:"msg"`
+			Request struct {
+				URL string `json:"url"`
+			} `json:"request"`
+			Primary outcome `json:"primary"`
+			Shadow  outcome `json:"shadow"`
+		}
+
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+
+		if record.Msg != "HTTP Shadow Mismatch" {
+			continue
+		}
+
+		mismatches[strings.TrimPrefix(record.Request.URL, primary.URL)] = [2]outcome{record.Primary, record.Shadow}
+	}
+
+	if len(mismatches) != 2 {
+		t.Fatalf("Expected two mismatches, got %v", mismatches)
+	}
+
+	if m := mismatches["/missing"]; m[0].StatusCode != http.StatusOK || m[1].StatusCode != http.StatusNotFound {
+		t.Errorf("Unexpected status mismatch: %+v", m)
+	}
+
+	if m := mismatches["/different"]; m[0].BodySHA256 == m[1].BodySHA256 || m[1].BodySHA256 == "" {
+		t.Errorf("Unexpected body mismatch: %+v", m)
+	}
+}
+
+func TestWithShadowInvalid(t *testing.T) {
+	for _, c := range []ShadowConfig{{}, {BaseURL: "/relative"}} {
+		if _, err := NewSlogTripperE(WithShadow(c)); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("Expected %+v rejected, got %v", c, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package slogtripper
+
+import (
+	"context"
+	"net/http"
+)
+
+type silenceKey struct{}
+
+// Silence returns a context whose requests aren't logged, such as those of
+// a client shipping the logs themselves, which would otherwise log its own
+// requests forever. Hooks and aggregates still see them, and audit mode logs
+// them regardless.
+func Silence(ctx context.Context) context.Context {
+	return context.WithValue(ctx, silenceKey{}, true)
+}
+
+// WithSilenceHeader silences requests carrying the header name, as Silence
+// does, for code which can set a header but not the context. The header is
+// removed before the request is sent.
+func WithSilenceHeader(name string) Option {
+	return func(st *SlogTripper) {
+		if name == "" {
+			st.invalid("empty silence header")
+			return
+		}
+
+		st.silenceHeader = http.CanonicalHeaderKey(name)
+	}
+}
+
+// silenced reports whether records made with ctx are suppressed.
+func silenced(ctx context.Context) bool {
+	v, _ := ctx.Value(silenceKey{}).(bool)
+	return v
+}
+
+// silencedRequest reports whether req isn't to be logged.
+func silencedRequest(req *http.Request) bool {
+	return req != nil && silenced(req.Context())
+}
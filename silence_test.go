@@ -0,0 +1,94 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSilence(t *testing.T) {
+	var output bytes.Buffer
+	var sent http.Header
+	var hooked int
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				sent = r.Header
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			},
+		}),
+		WithSilenceHeader("x-internal-no-log"),
+		OnResponse(func(*http.Request, *http.Response, error, time.Duration) {
+			hooked++
+		}),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequestWithContext(Silence(context.Background()), http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	req := Must(http.NewRequest(http.MethodPost, "http://localhost/logs", nil))
+	req.Header.Set("X-Internal-No-Log", "1")
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := sent["X-Internal-No-Log"]; ok {
+		t.Error("Expected the silence header kept from the upstream")
+	}
+
+	if req.Header.Get("X-Internal-No-Log") == "" {
+		t.Error("Expected the caller's request left alone")
+	}
+
+	if output.Len() != 0 {
+		t.Errorf("Expected silenced requests not logged: %s", output.Bytes())
+	}
+
+	if hooked != 2 {
+		t.Errorf("Expected hooks to see silenced requests, got %d calls", hooked)
+	}
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.Len() == 0 {
+		t.Error("Expected other requests logged")
+	}
+}
+
+func TestSilenceAudit(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			},
+		}),
+		AuditMode(nil),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequestWithContext(Silence(context.Background()), http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.Len() == 0 {
+		t.Error("Expected audit mode to log silenced requests")
+	}
+}
+
+func TestWithSilenceHeaderInvalid(t *testing.T) {
+	if _, err := NewSlogTripperE(WithSilenceHeader("")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected an invalid option, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// singleFlightHeaders are the request headers responses commonly vary on,
+// which requests must share to be coalesced.
+var singleFlightHeaders = []string{"Accept", "Accept-Encoding", "Accept-Language", "Authorization", "Cookie", "Range"}
+
+// WithSingleFlight coalesces concurrent identical GET requests into one
+// upstream call, whose response each caller gets a copy of. Requests are
+// identical when they have the same URL and the same Accept,
+// Accept-Encoding, Accept-Language, Authorization, Cookie and Range headers,
+// plus any others named by headers. The shared response body is read in
+// full before it's handed out, so this doesn't suit streamed responses.
+//
+// The upstream call carries on while any caller is still waiting for it.
+// Every coalesced request is logged with a singleflight group giving the
+// number of callers which shared the call and whether it was the leader,
+// the one whose request was sent.
+func WithSingleFlight(headers ...string) Option {
+	return func(st *SlogTripper) {
+		keyHeaders := append([]string(nil), singleFlightHeaders...)
+		for _, name := range headers {
+			keyHeaders = append(keyHeaders, http.CanonicalHeaderKey(name))
+		}
+
+		st.singleFlight = &singleFlight{headers: keyHeaders, flights: make(map[string]*flight)}
+	}
+}
+
+type singleFlight struct {
+	headers []string
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is an upstream call shared by callers.
+type flight struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	callers int
+	waiting int
+
+	res  *http.Response
+	body []byte
+	err  error
+}
+
+// key identifies the requests which can share a call with req.
+func (g *singleFlight) key(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.URL.String())
+
+	for _, name := range g.headers {
+		b.WriteByte('\n')
+		b.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+
+	return b.String()
+}
+
+// sendSingleFlight sends req through send, sharing the call with any
+// identical request already in flight.
+func (st *SlogTripper) sendSingleFlight(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != "" || hasBody(req.Body) || req.Header.Get("Upgrade") != "" {
+		return send(req)
+	}
+
+	g := st.singleFlight
+	key := g.key(req)
+
+	g.mu.Lock()
+	f, inFlight := g.flights[key]
+	if !inFlight {
+		ctx, cancel := context.WithCancel(context.WithoutCancel(req.Context()))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+
+		go g.fly(key, f, req.WithContext(ctx), send)
+	}
+	f.callers++
+	f.waiting++
+	g.mu.Unlock()
+
+	ctx := req.Context()
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiting--
+		if f.waiting == 0 {
+			// Callers from now on start a flight of their own rather than
+			// join one that's been cancelled
+			f.cancel()
+			g.land(key, f)
+		}
+		g.mu.Unlock()
+
+		return nil, ctx.Err()
+	}
+
+	if n := notesFrom(ctx); n != nil {
+		n.flightCallers, n.flightLeader = f.callers, !inFlight
+	}
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	res := *f.res
+	res.Header = f.res.Header.Clone()
+	res.Body = io.NopCloser(bytes.NewReader(f.body))
+	res.Request = req
+
+	return &res, nil
+}
+
+// fly makes the call for f, reading the body to share.
+func (g *singleFlight) fly(key string, f *flight, req *http.Request, send func(*http.Request) (*http.Response, error)) {
+	defer f.cancel()
+
+	res, err := send(req)
+	if err == nil && res.Body != nil {
+		f.body, err = io.ReadAll(res.Body)
+		res.Body.Close()
+	}
+
+	f.res, f.err = res, err
+
+	g.mu.Lock()
+	g.land(key, f)
+	g.mu.Unlock()
+
+	close(f.done)
+}
+
+// land stops f taking callers, unless a later flight has already replaced
+// it. g.mu is held.
+func (g *singleFlight) land(key string, f *flight) {
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
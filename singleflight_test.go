@@ -0,0 +1,251 @@
+package slogtripper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForCallers waits until n callers share a flight.
+func waitForCallers(t *testing.T, st *SlogTripper, n int) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		st.singleFlight.mu.Lock()
+		callers := 0
+		for _, f := range st.singleFlight.flights {
+			callers += f.callers
+		}
+		st.singleFlight.mu.Unlock()
+
+		if callers == n {
+			return
+		}
+	}
+
+	t.Fatalf("Timed out waiting for %d callers", n)
+}
+
+func TestWithSingleFlight(t *testing.T) {
+	var output syncBuffer
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				calls.Add(1)
+				<-release
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {"text/plain"}},
+					Body:       io.NopCloser(strings.NewReader("shared")),
+				}, nil
+			},
+		}),
+		WithSingleFlight(),
+	)
+
+	const n = 5
+
+	var wg sync.WaitGroup
+	bodies := make([]string, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/config", nil)))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			body, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+			bodies[i] = string(body)
+		}(i)
+	}
+
+	waitForCallers(t, st, n)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected one upstream call, got %d", calls.Load())
+	}
+
+	for i, body := range bodies {
+		if body != "shared" {
+			t.Errorf("Caller %d: unexpected body %q", i, body)
+		}
+	}
+
+	leaders := 0
+	for _, line := range output.Lines() {
+		var record struct {
+			Request struct {
+				SingleFlight struct {
+					Callers int  `json:"callers"`
+					Leader  bool `json:"leader"`
+				} `json:"singleflight"`
+			} `json:"request"`
+		}
+
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+
+		if record.Request.SingleFlight.Callers != n {
+			t.Errorf("Expected %d callers logged: %s", n, line)
+		}
+
+		if record.Request.SingleFlight.Leader {
+			leaders++
+		}
+	}
+
+	if leaders != 1 {
+		t.Errorf("Expected one leader, got %d", leaders)
+	}
+}
+
+func TestSingleFlightKey(t *testing.T) {
+	g := &singleFlight{headers: append([]string(nil), singleFlightHeaders...)}
+
+	req := func(accept string) *http.Request {
+		r := Must(http.NewRequest(http.MethodGet, "http://localhost/config", nil))
+		r.Header.Set("Accept", accept)
+		r.Header.Set("X-Trace", accept)
+
+		return r
+	}
+
+	if g.key(req("application/json")) == g.key(req("text/html")) {
+		t.Error("Expected requests accepting different types kept apart")
+	}
+
+	g.headers = append(g.headers, "X-Trace")
+	if g.key(req("a")) == g.key(req("b")) {
+		t.Error("Expected the extra header in the key")
+	}
+}
+
+func TestSingleFlightCancel(t *testing.T) {
+	release := make(chan struct{})
+	upstream := make(chan context.Context, 1)
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				upstream <- r.Context()
+				<-release
+
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+			},
+		}),
+		WithSingleFlight(),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := st.RoundTrip(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil)))
+		leaderErr <- err
+	}()
+
+	sent := <-upstream
+
+	follower := make(chan *http.Response, 1)
+	go func() {
+		res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil)))
+		if err != nil {
+			t.Error(err)
+		}
+		follower <- res
+	}()
+
+	waitForCallers(t, st, 2)
+
+	// The leader giving up doesn't cancel the call the follower is waiting on
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the leader cancelled, got %v", err)
+	}
+
+	if sent.Err() != nil {
+		t.Error("Expected the upstream call to carry on")
+	}
+
+	close(release)
+
+	if res := <-follower; res == nil || res.StatusCode != http.StatusOK {
+		t.Errorf("Expected the follower to get the response, got %v", res)
+	}
+}
+
+func TestSingleFlightJoinAfterCancel(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if calls.Add(1) == 1 {
+					// The cancelled call is slow to give up
+					<-r.Context().Done()
+					<-release
+
+					return nil, r.Context().Err()
+				}
+
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+			},
+		}),
+		WithSingleFlight(),
+	)
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := st.RoundTrip(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil)))
+		done <- err
+	}()
+
+	waitForCallers(t, st, 1)
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the first caller cancelled, got %v", err)
+	}
+
+	// The cancelled flight hasn't landed yet, but mustn't be joined
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := st.RoundTrip(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil)))
+	if err != nil {
+		t.Fatalf("Expected a fresh flight, got %v", err)
+	}
+	res.Body.Close()
+
+	if calls.Load() != 2 {
+		t.Errorf("Expected a second upstream call, got %d", calls.Load())
+	}
+}
@@ -1,12 +1,14 @@
 package slogtripper
 
 import (
-	"bytes"
 	"context"
-	"io"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/http/httptrace"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,10 +17,6 @@ var m sync.Once
 func Init() {
 	m.Do(func() {
 		http.DefaultTransport = NewSlogTripper()
-
-		http.DefaultTransport = &SlogTripper{
-			proxyTransport: http.DefaultTransport,
-		}
 	})
 }
 
@@ -27,6 +25,7 @@ type Option func(*SlogTripper)
 func WithLogger(l *slog.Logger) Option {
 	return func(st *SlogTripper) {
 		if l == nil {
+			st.invalid("nil logger")
 			l = slog.Default()
 		}
 
@@ -34,15 +33,29 @@ func WithLogger(l *slog.Logger) Option {
 	}
 }
 
+// WithContextLogger pulls a request scoped logger out of the request's
+// context. When f returns nil the logger from WithLogger is used instead.
+func WithContextLogger(f func(ctx context.Context) *slog.Logger) Option {
+	return func(st *SlogTripper) {
+		if f == nil {
+			st.invalid("nil context logger func")
+		}
+
+		st.contextLogger = f
+	}
+}
+
 func WithLoggingLevel(level slog.Level) Option {
 	return func(st *SlogTripper) {
-		st.logAtLevel = level
+		st.logAtLevel = new(slog.LevelVar)
+		st.logAtLevel.Set(level)
 	}
 }
 
 func WithRoundTripper(t http.RoundTripper) Option {
 	return func(st *SlogTripper) {
 		if t == nil {
+			st.invalid("nil round tripper")
 			t = http.DefaultTransport
 		}
 
@@ -50,163 +63,1090 @@ func WithRoundTripper(t http.RoundTripper) Option {
 	}
 }
 
+// WithAttrs adds attrs to every record logged by the tripper, i.e. the
+// service or dependency name. Repeated calls add to the existing attrs.
+func WithAttrs(attrs ...slog.Attr) Option {
+	return func(st *SlogTripper) {
+		// Clip so a derived tripper never writes into its parent's backing array
+		st.attrs = append(st.attrs[:len(st.attrs):len(st.attrs)], attrs...)
+	}
+}
+
+// MessageFunc builds the log message for a round trip. res is nil when err
+// isn't.
+type MessageFunc func(req *http.Request, res *http.Response, err error) string
+
+// WithMessage replaces the default "HTTP Request" log message.
+func WithMessage(msg string) Option {
+	return func(st *SlogTripper) {
+		st.message = msg
+		st.messageFunc = nil
+	}
+}
+
+// WithMessageFunc builds the log message per round trip, i.e.
+// "GET api.stripe.com 200".
+func WithMessageFunc(f MessageFunc) Option {
+	return func(st *SlogTripper) {
+		if f == nil {
+			st.invalid("nil message func")
+		}
+
+		st.messageFunc = f
+	}
+}
+
+// WithGroupName nests everything the tripper logs under a group called name.
+func WithGroupName(name string) Option {
+	return func(st *SlogTripper) {
+		st.groupName = name
+	}
+}
+
+// WithGroupKeys renames the request and response groups. An empty key leaves
+// that group's name unchanged.
+func WithGroupKeys(request, response string) Option {
+	return func(st *SlogTripper) {
+		if request != "" {
+			st.requestKey = request
+		}
+
+		if response != "" {
+			st.responseKey = response
+		}
+	}
+}
+
+// WithContextAttrs adds the attrs f extracts from the request's context to
+// every record, i.e. a tenant or correlation ID set by middleware. Repeated
+// calls add further extractors.
+func WithContextAttrs(f func(ctx context.Context) []slog.Attr) Option {
+	return func(st *SlogTripper) {
+		if f == nil {
+			st.invalid("nil context attrs func")
+			return
+		}
+
+		st.contextAttrs = append(st.contextAttrs[:len(st.contextAttrs):len(st.contextAttrs)], f)
+	}
+}
+
 func CaptureRequestBody() Option {
 	return func(st *SlogTripper) {
-		st.captureRequestBody = true
+		st.captureRequestBody = newBool(true)
 	}
 }
 
+// CaptureResponseBody logs the body of each response. An event stream, with
+// a Content-Type of text/event-stream, is never buffered: the response is
+// marked event_stream=true and each event is logged as an "HTTP SSE Event"
+// record as the caller reads it, with its name, id and data, up to 1KiB.
 func CaptureResponseBody() Option {
 	return func(st *SlogTripper) {
-		st.captureResponseBody = true
+		st.captureResponseBody = newBool(true)
 	}
 }
 
 func CaptureRequestHeaders() Option {
 	return func(st *SlogTripper) {
-		st.captureRequestHeaders = true
+		st.captureRequestHeaders = newBool(true)
 	}
 }
 
 func CaptureResponseHeaders() Option {
 	return func(st *SlogTripper) {
-		st.captureResponseHeaders = true
+		st.captureResponseHeaders = newBool(true)
 	}
 }
 
 type SlogTripper struct {
-	logger     *slog.Logger
-	logAtLevel slog.Level
+	logger        *slog.Logger
+	contextLogger func(ctx context.Context) *slog.Logger
+	logAtLevel    *slog.LevelVar
 
 	proxyTransport http.RoundTripper
 
-	captureRequestBody  bool
-	captureResponseBody bool
+	// Settings which can be changed at runtime are held behind pointers, a
+	// host or method config shares them with its parent until it overrides them
+	captureRequestBody  *atomic.Bool
+	captureResponseBody *atomic.Bool
+
+	captureRequestHeaders  *atomic.Bool
+	captureResponseHeaders *atomic.Bool
+
+	bodyDumpDir  string
+	maxBodySize  int64
+	decodeBodies bool
+	decoders     map[string]BodyDecoder
+	redactXML    map[string]struct{}
+	redactForm   map[string]struct{}
+
+	attrs        []slog.Attr
+	contextAttrs []func(ctx context.Context) []slog.Attr
+
+	message     string
+	messageFunc MessageFunc
+
+	groupName   string
+	requestKey  string
+	responseKey string
+
+	clientTimeout time.Duration
+
+	disabled *atomic.Bool
+	scopes   []*scope
+
+	capturers     []Capturer
+	redactHeaders map[string]struct{}
 
-	captureRequestHeaders  bool
-	captureResponseHeaders bool
+	retry         *retryPolicy
+	maxRetryAfter time.Duration
+	breakers      *circuitBreakers
+	inFlight      *inFlight
+
+	async       *asyncLogger
+	logLimit    *logLimiter
+	dedup       *errorDedup
+	stats       *stats
+	otel        *otelMetrics
+	tracer      OTelTracer
+	statsd      *statsd
+	emf         *emf
+	recent      *recentRoundTrips
+	webhook     *webhook
+	summary     *summary
+	summaryOnly bool
+
+	transferSizes        bool
+	phaseTimings         bool
+	captureConnection    bool
+	captureProxy         bool
+	captureDNS           bool
+	captureProtocol      bool
+	captureInformational bool
+	captureTrailers      bool
+	profilerLabels       bool
+	checkContentLength   bool
+
+	stop     chan struct{}
+	stopOnce *sync.Once
+	// drain is closed once the work stopped by stop has logged its last
+	// records, for the async queue and webhook to write what's left
+	drain chan struct{}
+
+	onRequest  []func(*http.Request)
+	onResponse []ResponseHook
+
+	errorReporter ErrorReporter
+	audit         bool
+	auditIdentity func(ctx context.Context) []slog.Attr
+	routeResolver RouteResolver
+	clock         Clock
+	caller        bool
+	callerSkip    int
+	errorStacks   bool
+	logOnSend     bool
+
+	dryRun     bool
+	faults     []*FaultConfig
+	throttle   int64
+	shadow     *shadow
+	validators []ResponseValidator
+
+	detectLeaks bool
+	leakTimeout time.Duration
+	heartbeat   time.Duration
+
+	upgradeHooks []UpgradeHook
+
+	userAgent       string
+	outgoingHeaders []func(ctx context.Context) http.Header
+	traceContext    bool
+	logBaggage      bool
+	baggageKeys     []string
+	forwardBaggage  bool
+	idempotencyKeys bool
+	silenceHeader   string
+	logSinkHosts    []string
+	logSinkLevel    *slog.Level
+	cache           *responseCache
+	conditional     *validatorStore
+	singleFlight    *singleFlight
+
+	maskers         []Masker
+	maskReplacement string
+
+	maxAttrValueLength int
+	flatAttributes     bool
+
+	datadog         DatadogSpanFunc
+	datadogTraceKey string
+	datadogSpanKey  string
+
+	errs []error
 }
 
 func NewSlogTripper(opts ...Option) *SlogTripper {
 	st := &SlogTripper{
-		logger:         nil,
-		logAtLevel:     slog.LevelInfo,
-		proxyTransport: http.DefaultTransport,
+		logger:                 nil,
+		logAtLevel:             new(slog.LevelVar),
+		proxyTransport:         http.DefaultTransport,
+		captureRequestBody:     newBool(false),
+		captureResponseBody:    newBool(false),
+		captureRequestHeaders:  newBool(false),
+		captureResponseHeaders: newBool(false),
+		disabled:               newBool(false),
+		redactForm:             defaultRedactedFormFields,
+		inFlight:               newInFlight(),
+		stop:                   make(chan struct{}),
+		stopOnce:               new(sync.Once),
+		drain:                  make(chan struct{}),
+		message:                "HTTP Request",
+		requestKey:             "request",
+		responseKey:            "response",
+		clock:                  systemClock{},
+		datadogTraceKey:        "dd.trace_id",
+		datadogSpanKey:         "dd.span_id",
 	}
 
 	for _, f := range opts {
 		f(st)
 	}
 
+	st.buildScopes()
+	st.start(nil)
+
 	return st
 }
 
+// start runs the tripper's background work, which is shared with its host
+// and method configs and any tripper derived from it with With. from is the
+// tripper st was cloned from, whose work is already running.
+func (st *SlogTripper) start(from *SlogTripper) {
+	if st.summary != nil && (from == nil || st.summary != from.summary) {
+		go st.summary.run(st, st.stop)
+	}
+
+	if st.logLimit != nil && (from == nil || st.logLimit != from.logLimit) {
+		go st.logLimit.run(st, st.stop)
+	}
+
+	if st.dedup != nil && (from == nil || st.dedup != from.dedup) {
+		go st.dedup.run(st, st.stop)
+	}
+
+	if st.async != nil && (from == nil || st.async != from.async) {
+		go st.async.run(st.drain)
+	}
+
+	if st.webhook != nil && (from == nil || st.webhook != from.webhook) {
+		go st.webhook.run(st.drain)
+	}
+
+	for _, sc := range st.scopes {
+		sc.st.start(st)
+	}
+}
+
+// Close stops the tripper's background work, i.e. periodic summaries, logging
+// what they have left, waits for records queued by WithAsyncLogging to be written and events queued by
+// WithWebhook to be posted, and closes WithStatsd's socket. Trippers derived
+// from it with With share that work and are stopped too. The proxied
+// transport is left alone.
+func (st *SlogTripper) Close() error {
+	st.stopOnce.Do(func() {
+		close(st.stop)
+
+		if st.summary != nil {
+			<-st.summary.done
+		}
+
+		if st.logLimit != nil {
+			<-st.logLimit.done
+		}
+
+		if st.dedup != nil {
+			<-st.dedup.done
+		}
+
+		close(st.drain)
+	})
+
+	if st.shadow != nil {
+		st.shadow.wg.Wait()
+	}
+
+	// Wait for queued records to be written
+	if st.async != nil {
+		<-st.async.done
+	}
+
+	if st.webhook != nil {
+		<-st.webhook.done
+	}
+
+	if st.statsd != nil {
+		st.statsd.conn.Close()
+	}
+
+	return nil
+}
+
+// ErrInvalidOption is wrapped by the errors NewSlogTripperE returns.
+var ErrInvalidOption = errors.New("invalid option")
+
+// NewSlogTripperE is NewSlogTripper, but reports invalid options as an error
+// instead of silently falling back to a default. The tripper is closed
+// before the error is returned.
+func NewSlogTripperE(opts ...Option) (*SlogTripper, error) {
+	st := NewSlogTripper(opts...)
+	if err := errors.Join(st.errs...); err != nil {
+		st.Close()
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// invalid records an invalid option for NewSlogTripperE.
+func (st *SlogTripper) invalid(format string, args ...any) {
+	st.errs = append(st.errs, fmt.Errorf("slogtripper: %w: %s", ErrInvalidOption, fmt.Sprintf(format, args...)))
+}
+
+// SetLevel changes the level requests are logged at. It is safe to call while
+// the tripper is in use, and applies to host and method configs which don't
+// set their own level.
+func (st *SlogTripper) SetLevel(level slog.Level) {
+	st.logAtLevel.Set(level)
+}
+
+// SetEnabled turns logging on or off at runtime.
+func (st *SlogTripper) SetEnabled(enabled bool) {
+	st.disabled.Store(!enabled)
+}
+
+// SetCaptureRequestBody turns request body capture on or off at runtime.
+func (st *SlogTripper) SetCaptureRequestBody(capture bool) {
+	st.captureRequestBody.Store(capture)
+}
+
+// SetCaptureResponseBody turns response body capture on or off at runtime.
+func (st *SlogTripper) SetCaptureResponseBody(capture bool) {
+	st.captureResponseBody.Store(capture)
+}
+
+// SetCaptureRequestHeaders turns request header capture on or off at runtime.
+func (st *SlogTripper) SetCaptureRequestHeaders(capture bool) {
+	st.captureRequestHeaders.Store(capture)
+}
+
+// SetCaptureResponseHeaders turns response header capture on or off at runtime.
+func (st *SlogTripper) SetCaptureResponseHeaders(capture bool) {
+	st.captureResponseHeaders.Store(capture)
+}
+
+func newBool(v bool) *atomic.Bool {
+	b := new(atomic.Bool)
+	b.Store(v)
+
+	return b
+}
+
 func (st *SlogTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	// A local instance of slog for this rountrip
-	start := time.Now()
+	st = st.resolve(req)
+	req = st.outgoing(req)
 
-	requestGroup := []any{
-		slog.Time("started_at", start),
+	if st.retry != nil {
+		return st.roundTripWithRetries(req)
+	}
+
+	return st.roundTrip(req)
+}
+
+func (st *SlogTripper) roundTrip(req *http.Request) (*http.Response, error) {
+	if st.tracer != nil && req != nil {
+		return st.roundTripInSpan(req, st.roundTripUntraced)
+	}
+
+	return st.roundTripUntraced(req)
+}
+
+func (st *SlogTripper) roundTripUntraced(req *http.Request) (*http.Response, error) {
+	st.runOnRequest(req)
+
+	// Don't buffer bodies or build attributes for a record nobody will see
+	if ((st.disabled.Load() || silencedRequest(req)) && !st.audit) || st.skipLogSink(req) || !st.wantsRecord(req) {
+		return st.passThrough(req)
 	}
 
+	// A local instance of slog for this rountrip
+	start := st.clock.Now()
+	id := newRequestID()
+
+	requestGroup := st.requestAttrs(req, id, start)
+
+	var tracker *retryTracker
+	var trace *roundTripTrace
+	var requestBody, responseBody slog.Value
+	var budget time.Duration
+	var attempt int
+	var proxy *proxyChoice
+	var notes *sendNotes
+
 	if req != nil {
-		requestGroup = append(requestGroup,
-			slog.String("method", req.Method),
-			slog.Int64("content_length", req.ContentLength),
-			slog.String("proto", req.Proto),
-		)
+		if st.userAgent != "" {
+			requestGroup = append(requestGroup, slog.String("user_agent", req.Header.Get("User-Agent")))
+		}
 
-		if u := req.URL; u != nil {
-			requestGroup = append(requestGroup, slog.String("url", u.String()))
+		if injected := injectedHeaders(req.Context()); injected != nil {
+			requestGroup = append(requestGroup, slog.Group("injected_headers", st.headerAttrs(injected)...))
 		}
 
-		if st.captureRequestBody && req.Body != nil {
-			b := new(bytes.Buffer)
-			_, err := b.ReadFrom(req.Body)
+		if st.idempotencyKeys {
+			if key := req.Header.Get("Idempotency-Key"); key != "" {
+				requestGroup = append(requestGroup, slog.String("idempotency_key", key))
+			}
+		}
 
-			if err != nil {
+		if deadline, ok := req.Context().Deadline(); ok {
+			budget = deadline.Sub(start)
+			requestGroup = append(requestGroup, slog.Duration("deadline_remaining", budget))
+		}
+
+		if st.caller {
+			if attr, ok := st.callerAttr(); ok {
+				requestGroup = append(requestGroup, attr)
+			}
+		}
+
+		if st.captureProxy {
+			proxy, _ = st.pickedProxy(req)
+		}
+
+		if attempt, tracker = startAttempt(req.Context()); attempt != 0 {
+			requestGroup = append(requestGroup, slog.Int("attempt", attempt))
+		}
+
+		if st.captureRequestBody.Load() && req.Body != nil {
+			var attrs []slog.Attr
+			var err error
+			if attrs, requestBody, err = st.requestBodyAttrs(req, id); err != nil {
 				return nil, err
 			}
-			req.Body.Close()
 
-			requestGroup = append(requestGroup, slog.Any("body_content", b.String()))
+			requestGroup = append(requestGroup, attrs...)
+		}
+
+		requestGroup = append(requestGroup, st.requestCaptures(req)...)
 
-			req.Body = io.NopCloser(b)
+		ids, prev := redirectIDs(req, id)
+		if prev != nil {
+			requestGroup = append(requestGroup, redirectAttr(ids, prev))
 		}
 
-		if st.captureRequestHeaders && req.Header != nil {
-			headers := []any{}
+		// The proxied transport gets a copy carrying our IDs, so the next hop
+		// of a redirect can find them on its req.Response.Request
+		ctx := context.WithValue(req.Context(), requestIDKey{}, ids)
+		if st.cache != nil || st.conditional != nil || st.singleFlight != nil {
+			notes = new(sendNotes)
+			ctx = context.WithValue(ctx, sendNotesKey{}, notes)
+		}
 
-			for name := range req.Header {
-				// We don't use value here as value would be a []string and I can't be bothered to check len, pick the one .Get would use and use it
-				headers = append(headers, slog.String(name, req.Header.Get(name)))
-			}
+		if st.wantsTrace(req) {
+			trace = newRoundTripTrace(time.Now())
+			ctx = httptrace.WithClientTrace(ctx, trace.clientTrace())
+		}
 
-			if len(headers) != 0 {
-				requestGroup = append(requestGroup, slog.Group("headers", headers...))
-			}
+		req = req.WithContext(ctx)
+	}
+
+	var sent *countingReader
+	if (st.transferSizes || st.audit || expectsContinue(req)) && req != nil && hasBody(req.Body) {
+		sent = &countingReader{ReadCloser: req.Body}
+		if st.audit {
+			sent.hash = newBodyHash()
 		}
+
+		req.Body = sent
+	}
+
+	host := requestHost(req)
+	if st.stats != nil {
+		req = st.stats.countRequest(host, req)
+	}
+
+	total, perHost := st.inFlight.start(host)
+	if st.otel != nil {
+		st.otel.started(req)
+	}
+	requestGroup = append(requestGroup, slog.Int64("in_flight", total), slog.Int64("in_flight_host", perHost))
+
+	if st.logOnSend && req != nil {
+		st.logDispatch(req, id, attempt)
+	}
+
+	fault := st.pickFault(req)
+	res, err := st.sendWithFault(req, fault)
+	finished := st.clock.Now()
+	elapsed := finished.Sub(start)
+	st.inFlight.done(host)
+	st.observeRoundTrip(host, req, res, err, elapsed)
+
+	if st.stats != nil {
+		st.stats.countResponse(host, res)
+	}
+
+	if st.throttle > 0 {
+		requestGroup = append(requestGroup, slog.Int64("throttle_bytes_per_second", st.throttle))
 	}
 
-	res, err := st.proxyTransport.RoundTrip(req)
+	if proxy != nil {
+		requestGroup = append(requestGroup, proxy.attr(trace, err))
+	}
 
-	responseGroup := []any{}
+	if notes != nil && notes.cache != "" {
+		requestGroup = append(requestGroup, slog.String("cache", notes.cache))
+	}
+
+	if notes != nil && notes.conditional {
+		requestGroup = append(requestGroup, slog.Bool("conditional", true))
+	}
+
+	if notes != nil && notes.flightCallers > 1 {
+		requestGroup = append(requestGroup, slog.Group("singleflight",
+			slog.Int("callers", notes.flightCallers),
+			slog.Bool("leader", notes.flightLeader),
+		))
+	}
+
+	if sent != nil && st.transferSizes {
+		n := sent.n.Load()
+		requestGroup = append(requestGroup,
+			slog.Int64("bytes_sent", n),
+			slog.Float64("upload_throughput_bps", throughput(n, elapsed)),
+		)
+	}
+
+	if sent != nil && st.audit {
+		if sum, ok := sent.sum(); ok {
+			requestGroup = append(requestGroup, slog.String("body_sha256", sum))
+		}
+	}
+
+	if req != nil {
+		if attr, ok := st.trailersAttr(req.Trailer); ok {
+			requestGroup = append(requestGroup, attr)
+		}
+	}
+
+	if trace != nil && expectsContinue(req) {
+		requestGroup = append(requestGroup, trace.expectContinue(sent))
+	}
+
+	if tracker != nil {
+		tracker.record(elapsed, res, err)
+	}
+
+	var received *countingBody
+
+	responseGroup := make([]slog.Attr, 0, 16)
 	if err != nil {
-		responseGroup = append(responseGroup, slog.Any("error", err))
+		responseGroup = append(responseGroup, errorAttr(err), slog.Time("finished_at", finished))
+
+		if st.errorStacks {
+			responseGroup = append(responseGroup, stackAttr())
+		}
+	}
+
+	if budget > 0 {
+		responseGroup = append(responseGroup, slog.Float64("budget_used_pct", float64(elapsed)/float64(budget)*100))
+	}
+
+	if st.captureConnection && trace != nil {
+		if attr, ok := trace.connection(); ok {
+			responseGroup = append(responseGroup, attr)
+		}
+	}
+
+	if st.captureDNS && trace != nil {
+		if attr, ok := trace.dns(); ok {
+			responseGroup = append(responseGroup, attr)
+		}
+	}
+
+	if st.dryRun {
+		responseGroup = append(responseGroup, slog.Bool("dry_run", true))
+	}
+
+	if fault != nil {
+		responseGroup = append(responseGroup, faultAttrs(fault)...)
 	}
 
 	if res != nil {
-		responseGroup = append(responseGroup,
-			slog.String("status", http.StatusText(res.StatusCode)),
-			slog.Int("status_code", res.StatusCode),
-			slog.Int64("content_length", res.ContentLength),
-			slog.Duration("time_taken", time.Since(start)),
-			slog.String("content_type", res.Header.Get("Content-Type")),
-		)
+		responseGroup = append(responseGroup, responseAttrs(res, elapsed, finished)...)
+
+		if st.captureProtocol && trace != nil {
+			responseGroup = append(responseGroup, trace.protocol(res))
+		}
+
+		if st.captureInformational && trace != nil {
+			if attr, ok := trace.informational(); ok {
+				responseGroup = append(responseGroup, attr)
+			}
+		}
+
+		if switchedProtocols(res) {
+			responseGroup = append(responseGroup, upgradeAttr(res))
+		}
+
+		if (st.transferSizes || st.phaseTimings || st.captureTrailers || st.checkContentLength || st.audit) && wrappableBody(res) {
+			received = newCountingBody(res.Body)
+			if st.audit {
+				received.hash = newBodyHash()
+			}
+
+			res.Body = received
+		}
 
-		if st.captureResponseBody && res.Body != nil {
-			b := new(bytes.Buffer)
-			_, err := b.ReadFrom(res.Body)
+		if st.captureResponseBody.Load() && wrappableBody(res) && sseStream(res) {
+			res.Body = st.newSSEBody(req, res.Body, id)
+			responseGroup = append(responseGroup, slog.Bool("event_stream", true))
+		} else if st.captureResponseBody.Load() && res.Body != nil && !switchedProtocols(res) {
+			content, truncated, body, err := captureBody(res.Body, st.maxBodySize, true)
 			if err != nil {
 				return nil, err
 			}
 
-			res.Body.Close()
+			attrs, value := st.bodyAttrs(id+"-response", res.Header.Get("Content-Type"), content, truncated)
+			responseBody = value
+			responseGroup = append(responseGroup, attrs...)
 
-			responseGroup = append(responseGroup, slog.Any("body_content", b.String()))
-
-			res.Body = io.NopCloser(b)
+			res.Body = body
 		}
 
-		if st.captureResponseHeaders && res.Header != nil {
-			headers := []any{}
+		responseGroup = append(responseGroup, st.responseCaptures(res)...)
+	}
 
-			for name := range res.Header {
-				// We don't use value here as value would be a []string and I can't be bothered to check len, pick the one .Get would use and use it
-				headers = append(headers, slog.String(name, req.Header.Get(name)))
-			}
+	st.runOnResponse(req, res, err, elapsed)
+	msg := st.messageFor(req, res, err)
 
-			if len(headers) != 0 {
-				responseGroup = append(responseGroup, slog.Group("headers", headers...))
+	if received != nil && st.audit {
+		st.auditAfterBody(received, func(unfinished bool) {
+			attrs := st.bodyDoneAttrs(res, trace, received)
+			if unfinished {
+				attrs = append(attrs, slog.Bool("body_unfinished", true))
 			}
+
+			st.write(req, res, host, msg, err, requestGroup, append(responseGroup, attrs...))
+		})
+	} else if received != nil {
+		received.then(func() {
+			st.write(req, res, host, msg, err, requestGroup, append(responseGroup, st.bodyDoneAttrs(res, trace, received)...))
+		})
+	} else {
+		st.write(req, res, host, msg, err, requestGroup, append(responseGroup, st.bodyDoneAttrs(res, trace, nil)...))
+	}
+
+	if st.recent != nil {
+		st.recent.add(req, res, err, id, start, elapsed, requestBody, responseBody)
+	}
+
+	if st.webhook != nil {
+		st.webhook.add(req, res, err, id, start, elapsed)
+	}
+
+	return res, err
+}
+
+// requestAttrs starts the request group of the record for req, logged under
+// id, with what the client and server sides have in common. Groups are built
+// as attrs, as boxing each into an any costs an allocation.
+func (st *SlogTripper) requestAttrs(req *http.Request, id string, start time.Time) []slog.Attr {
+	attrs := make([]slog.Attr, 0, 16)
+	attrs = append(attrs,
+		slog.String("id", id),
+		slog.Time("started_at", start),
+	)
+
+	if req == nil {
+		return attrs
+	}
+
+	attrs = append(attrs,
+		slog.String("method", req.Method),
+		slog.Int64("content_length", req.ContentLength),
+		slog.String("proto", req.Proto),
+	)
+
+	if u := req.URL; u != nil {
+		attrs = append(attrs, slog.String("url", u.String()))
+	}
+
+	if route := st.route(req); route != "" {
+		attrs = append(attrs, slog.String("route", route))
+	}
+
+	if st.logBaggage {
+		if attr, ok := st.baggageAttr(req); ok {
+			attrs = append(attrs, attr)
 		}
 	}
 
-	st.log(req.Context(), "HTTP Request", slog.Group("request", requestGroup...), slog.Group("response", responseGroup...))
+	return attrs
+}
+
+// requestBodyAttrs returns the attributes and logged value of req's body,
+// leaving req with a body which reads in full.
+func (st *SlogTripper) requestBodyAttrs(req *http.Request, id string) ([]slog.Attr, slog.Value, error) {
+	content, truncated, body, err := captureBody(req.Body, st.maxBodySize, false)
+	if err != nil {
+		return nil, slog.Value{}, err
+	}
+
+	req.Body = body
+	attrs, value := st.bodyAttrs(id+"-request", req.Header.Get("Content-Type"), content, truncated)
+
+	return attrs, value, nil
+}
+
+// requestCaptures returns req's captured headers and the attributes of the
+// tripper's capturers.
+func (st *SlogTripper) requestCaptures(req *http.Request) []slog.Attr {
+	var attrs []slog.Attr
+	if st.captureRequestHeaders.Load() {
+		if headers := st.headerAttrs(req.Header); len(headers) != 0 {
+			attrs = append(attrs, slog.Group("headers", headers...))
+		}
+	}
+
+	for _, c := range st.capturers {
+		attrs = append(attrs, c.CaptureRequest(req)...)
+	}
+
+	return attrs
+}
+
+// responseAttrs returns the attributes every response is logged with.
+func responseAttrs(res *http.Response, elapsed time.Duration, finished time.Time) []slog.Attr {
+	return []slog.Attr{
+		slog.String("status", http.StatusText(res.StatusCode)),
+		slog.Int("status_code", res.StatusCode),
+		slog.Int64("content_length", res.ContentLength),
+		slog.Duration("time_taken", elapsed),
+		slog.Time("finished_at", finished),
+		slog.String("content_type", res.Header.Get("Content-Type")),
+	}
+}
+
+// responseCaptures returns res's captured headers and the attributes of the
+// tripper's capturers.
+func (st *SlogTripper) responseCaptures(res *http.Response) []slog.Attr {
+	var attrs []slog.Attr
+	if st.captureResponseHeaders.Load() {
+		if headers := st.headerAttrs(res.Header); len(headers) != 0 {
+			attrs = append(attrs, slog.Group("headers", headers...))
+		}
+	}
+
+	for _, c := range st.capturers {
+		attrs = append(attrs, c.CaptureResponse(res)...)
+	}
+
+	return attrs
+}
+
+// messageFor returns the message of the record for a round trip.
+func (st *SlogTripper) messageFor(req *http.Request, res *http.Response, err error) string {
+	if st.messageFunc != nil {
+		return st.messageFunc(req, res, err)
+	}
+
+	return st.message
+}
+
+// write logs the record for req, unless it's only summarised, a repeat of
+// the last error or over the host's log rate limit, and reports it if it
+// failed.
+func (st *SlogTripper) write(req *http.Request, res *http.Response, host, msg string, err error, requestGroup, responseGroup []slog.Attr) {
+	args := make([]any, 0, len(st.attrs)+5)
+	for _, attr := range st.attrs {
+		args = append(args, attr)
+	}
+
+	if st.audit {
+		args = append(args, st.auditAttrs(req.Context())...)
+	}
+
+	for _, f := range st.contextAttrs {
+		for _, attr := range f(req.Context()) {
+			args = append(args, attr)
+		}
+	}
+	args = append(args, outcomeAttrs(res, err)...)
+	args = append(args,
+		slog.Attr{Key: st.requestKey, Value: slog.GroupValue(requestGroup...)},
+		slog.Attr{Key: st.responseKey, Value: slog.GroupValue(responseGroup...)},
+	)
+
+	if st.groupName != "" {
+		args = []any{slog.Group(st.groupName, args...)}
+	}
+
+	args = append(args, st.traceAttrs(req)...)
+
+	if st.errorReporter != nil && failed(res, err) {
+		st.errorReporter(req, res, err, toAttrs(args))
+	}
+
+	if st.audit || !st.summaryOnly &&
+		(st.dedup == nil || st.dedup.observe(st, req, err)) &&
+		(st.logLimit == nil || st.logLimit.allow(host)) {
+		st.logAt(req.Context(), st.recordLevel(req), msg, args...)
+	}
+}
+
+// outcomeAttrs sums up how a round trip went, for alerting on without
+// enumerating status codes: its status_class, such as 2xx, whether it was a
+// success, with a response below 400, and error=true if it failed without a
+// response.
+func outcomeAttrs(res *http.Response, err error) []any {
+	if err != nil || res == nil {
+		return []any{slog.Bool("success", false), slog.Bool("error", true)}
+	}
+
+	return []any{
+		slog.String("status_class", statusClass(res.StatusCode)),
+		slog.Bool("success", res.StatusCode < http.StatusBadRequest),
+	}
+}
+
+// wantsRecord reports whether the record for req would be written anywhere.
+func (st *SlogTripper) wantsRecord(req *http.Request) bool {
+	if st.recent != nil || st.webhook != nil || st.errorReporter != nil {
+		return true
+	}
+
+	if (st.summaryOnly && !st.audit) || req == nil {
+		return false
+	}
+
+	ctx := req.Context()
+
+	return st.loggerFor(ctx).Enabled(ctx, st.recordLevel(req))
+}
+
+// passThrough sends req without logging it, keeping the tripper's
+// aggregates, hooks and retry tracking up to date.
+func (st *SlogTripper) passThrough(req *http.Request) (*http.Response, error) {
+	var tracker *retryTracker
+	if req != nil {
+		_, tracker = startAttempt(req.Context())
+	}
+
+	host := requestHost(req)
+	if st.stats != nil {
+		req = st.stats.countRequest(host, req)
+	}
+
+	st.inFlight.start(host)
+	if st.otel != nil {
+		st.otel.started(req)
+	}
+
+	start := st.clock.Now()
+	// Faults are only injected into requests with a record to show them
+	res, err := st.send(req)
+	elapsed := st.clock.Now().Sub(start)
+	st.inFlight.done(host)
+	st.observeRoundTrip(host, req, res, err, elapsed)
+
+	if st.stats != nil {
+		st.stats.countResponse(host, res)
+	}
+
+	if tracker != nil {
+		tracker.record(elapsed, res, err)
+	}
+
+	st.runOnResponse(req, res, err, elapsed)
 
 	return res, err
 }
 
-func (st *SlogTripper) log(ctx context.Context, msg string, args ...any) {
+// send hands req to the proxied transport, through whichever of the
+// tripper's transport level features are enabled.
+func (st *SlogTripper) send(req *http.Request) (*http.Response, error) {
+	if st.cache != nil && req != nil && req.URL != nil {
+		return st.sendCached(req, st.sendUncached)
+	}
+
+	return st.sendUncached(req)
+}
+
+func (st *SlogTripper) sendUncached(req *http.Request) (*http.Response, error) {
+	if st.singleFlight != nil && req != nil && req.URL != nil {
+		return st.sendSingleFlight(req, st.sendAlone)
+	}
+
+	return st.sendAlone(req)
+}
+
+func (st *SlogTripper) sendAlone(req *http.Request) (*http.Response, error) {
+	if st.conditional != nil && req != nil && req.URL != nil {
+		return st.sendConditional(req, st.sendUnconditional)
+	}
+
+	return st.sendUnconditional(req)
+}
+
+func (st *SlogTripper) sendUnconditional(req *http.Request) (*http.Response, error) {
+	if len(st.upgradeHooks) != 0 && req != nil {
+		return st.sendWithUpgradeHooks(req, st.sendWithoutUpgradeHooks)
+	}
+
+	return st.sendWithoutUpgradeHooks(req)
+}
+
+// sendNotes is what the tripper's transport level features note about a
+// round trip, for roundTrip to log.
+type sendNotes struct {
+	cache         string
+	conditional   bool
+	flightCallers int
+	flightLeader  bool
+}
+
+type sendNotesKey struct{}
+
+// notesFrom returns the notes for the round trip made with ctx, nil when
+// nothing's logging them.
+func notesFrom(ctx context.Context) *sendNotes {
+	n, _ := ctx.Value(sendNotesKey{}).(*sendNotes)
+	return n
+}
+
+func (st *SlogTripper) sendWithoutUpgradeHooks(req *http.Request) (*http.Response, error) {
+	if st.heartbeat > 0 && req != nil {
+		return st.sendWithHeartbeat(req, st.sendWithoutHeartbeat)
+	}
+
+	return st.sendWithoutHeartbeat(req)
+}
+
+func (st *SlogTripper) sendWithoutHeartbeat(req *http.Request) (*http.Response, error) {
+	if st.detectLeaks && req != nil {
+		return st.sendLeakChecked(req, st.sendUnchecked)
+	}
+
+	return st.sendUnchecked(req)
+}
+
+func (st *SlogTripper) sendUnchecked(req *http.Request) (*http.Response, error) {
+	if len(st.validators) != 0 && req != nil {
+		return st.sendValidated(req, st.sendUnvalidated)
+	}
+
+	return st.sendUnvalidated(req)
+}
+
+func (st *SlogTripper) sendUnvalidated(req *http.Request) (*http.Response, error) {
+	if st.shadow != nil && req != nil {
+		return st.sendShadowed(req, st.sendUnshadowed)
+	}
+
+	return st.sendUnshadowed(req)
+}
+
+func (st *SlogTripper) sendUnshadowed(req *http.Request) (*http.Response, error) {
+	if st.throttle > 0 && req != nil {
+		return st.sendThrottled(req, st.sendUnthrottled)
+	}
+
+	return st.sendUnthrottled(req)
+}
+
+func (st *SlogTripper) sendUnthrottled(req *http.Request) (*http.Response, error) {
+	if st.profilerLabels && req != nil {
+		return st.sendLabelled(req, st.sendUnlabelled)
+	}
+
+	return st.sendUnlabelled(req)
+}
+
+func (st *SlogTripper) sendUnlabelled(req *http.Request) (*http.Response, error) {
+	if st.breakers != nil && req != nil {
+		return st.sendWithBreaker(req)
+	}
+
+	return st.proxyTransport.RoundTrip(req)
+}
+
+// observe records the outcome of a round trip in the tripper's aggregates,
+// whether or not it's logged.
+func (st *SlogTripper) observe(host string, req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	if st.stats != nil {
+		st.stats.record(host, res, err, elapsed)
+	}
+
+	if st.summary != nil {
+		st.summary.record(host, res, err, elapsed)
+	}
+}
+
+// observeRoundTrip is observe for the client side, also recording the
+// outcome with the tripper's client metrics.
+func (st *SlogTripper) observeRoundTrip(host string, req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	st.observe(host, req, res, err, elapsed)
+
+	if st.otel != nil {
+		st.otel.record(req, res, err, elapsed)
+	}
+
+	if st.statsd != nil {
+		st.statsd.record(host, req, res, err, elapsed)
+	}
+
+	if st.emf != nil {
+		st.emf.emit(st, host, req, res, err, elapsed)
+	}
+}
+
+// loggerFor returns the logger records for ctx are written to.
+func (st *SlogTripper) loggerFor(ctx context.Context) *slog.Logger {
 	logger := st.logger
+	if st.contextLogger != nil {
+		if l := st.contextLogger(ctx); l != nil {
+			logger = l
+		}
+	}
+
 	if logger == nil {
 		logger = slog.Default()
 	}
 
-	switch st.logAtLevel {
-	case slog.LevelDebug:
-		logger.DebugContext(ctx, msg, args...)
-	case slog.LevelInfo:
-		logger.InfoContext(ctx, msg, args...)
+	return logger
+}
+
+func (st *SlogTripper) log(ctx context.Context, msg string, args ...any) {
+	st.logAt(ctx, st.logAtLevel.Level(), msg, args...)
+}
+
+// logAt is log at a level of its own, for records that need to stand out.
+func (st *SlogTripper) logAt(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if (silenced(ctx) && !st.audit) || logging(ctx) {
+		return
 	}
+
+	// Requests made while the record is handled are the logging pipeline's own
+	ctx = context.WithValue(ctx, loggingKey{}, true)
+
+	logger := st.loggerFor(ctx)
+	args = append(st.flattenArgs(st.truncateArgs(args)), st.datadogAttrs(ctx)...)
+
+	if st.async != nil && !st.audit {
+		st.async.log(ctx, logger, level, msg, args...)
+		return
+	}
+
+	logger.Log(ctx, level, msg, args...)
 }
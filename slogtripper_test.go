@@ -2,16 +2,20 @@ package slogtripper
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 type MockRoundTripper struct {
@@ -306,3 +310,471 @@ func TestFaultyResponseBody(t *testing.T) {
 		t.Error("Error should have been returned")
 	}
 }
+
+func TestRuntimeSetters(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"ping": "pong"}`)),
+				}, nil
+			},
+		}),
+		WithHostConfig("other.example.com", CaptureRequestHeaders()),
+	)
+
+	run := func(url string) string {
+		output.Reset()
+		if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, url, nil))); err != nil {
+			t.Fatalf("Error in roundtrip: %v", err)
+		}
+
+		return output.String()
+	}
+
+	if out := run("http://localhost/"); strings.Contains(out, "body_content") {
+		t.Errorf("Body captured before being enabled: %s", out)
+	}
+
+	st.SetCaptureResponseBody(true)
+	st.SetLevel(slog.LevelWarn)
+
+	if out := run("http://localhost/"); !strings.Contains(out, "body_content") || !strings.Contains(out, `"level":"WARN"`) {
+		t.Errorf("Runtime settings not applied: %s", out)
+	}
+
+	// Host configs which don't override a setting share it with their parent
+	if out := run("http://other.example.com/"); !strings.Contains(out, "body_content") {
+		t.Errorf("Runtime settings not applied to host config: %s", out)
+	}
+
+	st.SetEnabled(false)
+
+	if out := run("http://localhost/"); out != "" {
+		t.Errorf("Logged while disabled: %s", out)
+	}
+}
+
+func TestRuntimeSettersConcurrent(t *testing.T) {
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+	)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			st.SetCaptureRequestHeaders(i%2 == 0)
+			st.SetLevel(slog.Level(i % 8))
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+			t.Fatalf("Error in roundtrip: %v", err)
+		}
+	}
+
+	<-done
+}
+
+func TestNewSlogTripperE(t *testing.T) {
+	st, err := NewSlogTripperE(
+		WithLogger(slog.Default()),
+		WithMaxBodySize(1024),
+		WithHostConfig("localhost", CaptureRequestBody()),
+	)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if st == nil {
+		t.Error("Slog Tripper created as nil")
+	}
+
+	st, err = NewSlogTripperE(
+		WithLogger(nil),
+		WithRoundTripper(nil),
+		WithHostConfig("localhost", WithMaxBodySize(-1)),
+	)
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption, got %v", err)
+	}
+
+	for _, msg := range []string{"nil logger", "nil round tripper", "negative max body size"} {
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("Error should mention %q: %v", msg, err)
+		}
+	}
+
+	if st != nil {
+		t.Error("Slog Tripper should be nil on error")
+	}
+}
+
+func TestNewSlogTripperEClosed(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	_, err := NewSlogTripperE(
+		WithSummaryInterval(time.Hour),
+		WithErrorDedup(time.Hour),
+		WithAsyncLogging(10),
+		WithStatsd(listenStatsd(t).LocalAddr().String(), "app"),
+		WithMaxBodySize(-1),
+	)
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Expected ErrInvalidOption, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("Expected the invalid tripper's goroutines stopped, %d left running", n-before)
+	}
+}
+
+func TestWithAttrs(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithAttrs(slog.String("service", "billing")),
+		WithAttrs(slog.String("dependency", "stripe")),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"service":"billing","dependency":"stripe"`) {
+		t.Errorf("Log does not contain static attrs: %s", output.String())
+	}
+}
+
+func TestWithGroupName(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithAttrs(slog.String("service", "billing")),
+		WithGroupName("outbound_http"),
+		WithGroupKeys("req", "res"),
+	)
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	var record struct {
+		OutboundHTTP struct {
+			Service string         `json:"service"`
+			Req     map[string]any `json:"req"`
+			Res     map[string]any `json:"res"`
+		} `json:"outbound_http"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("Error unmarshalling log: %v", err)
+	}
+
+	if record.OutboundHTTP.Service != "billing" || record.OutboundHTTP.Req["method"] != http.MethodGet || record.OutboundHTTP.Res["status_code"] != float64(http.StatusOK) {
+		t.Errorf("Record not nested as expected: %s", output.String())
+	}
+}
+
+func TestWithMessage(t *testing.T) {
+	var output bytes.Buffer
+
+	mrt := &MockRoundTripper{
+		MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		},
+	}
+
+	logger := slog.New(slog.NewJSONHandler(&output, nil))
+	req := Must(http.NewRequest(http.MethodGet, "http://api.example.com/", nil))
+
+	if _, err := NewSlogTripper(WithLogger(logger), WithRoundTripper(mrt), WithMessage("outbound call")).RoundTrip(req); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"msg":"outbound call"`) {
+		t.Errorf("Log does not contain custom message: %s", output.String())
+	}
+
+	output.Reset()
+
+	st := NewSlogTripper(
+		WithLogger(logger),
+		WithRoundTripper(mrt),
+		WithMessageFunc(func(req *http.Request, res *http.Response, err error) string {
+			return fmt.Sprintf("%s %s %d", req.Method, req.URL.Host, res.StatusCode)
+		}),
+	)
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"msg":"GET api.example.com 200"`) {
+		t.Errorf("Log does not contain message from func: %s", output.String())
+	}
+}
+
+func TestWithContextLogger(t *testing.T) {
+	type loggerKey struct{}
+
+	var defaultOutput, contextOutput bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&defaultOutput, nil))),
+		WithContextLogger(func(ctx context.Context) *slog.Logger {
+			l, _ := ctx.Value(loggerKey{}).(*slog.Logger)
+			return l
+		}),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+	)
+
+	contextLogger := slog.New(slog.NewJSONHandler(&contextOutput, nil)).With("tenant", "acme")
+	ctx := context.WithValue(context.Background(), loggerKey{}, contextLogger)
+
+	if _, err := st.RoundTrip(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(contextOutput.String(), `"tenant":"acme"`) || defaultOutput.Len() != 0 {
+		t.Errorf("Context logger not used, context: %q default: %q", contextOutput.String(), defaultOutput.String())
+	}
+
+	contextOutput.Reset()
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if contextOutput.Len() != 0 || defaultOutput.Len() == 0 {
+		t.Errorf("Should fall back to the configured logger, context: %q default: %q", contextOutput.String(), defaultOutput.String())
+	}
+}
+
+func TestWithContextAttrs(t *testing.T) {
+	type tenantKey struct{}
+
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithContextAttrs(func(ctx context.Context) []slog.Attr {
+			if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+				return []slog.Attr{slog.String("tenant", tenant)}
+			}
+
+			return nil
+		}),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+	)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+
+	if _, err := st.RoundTrip(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"tenant":"acme"`) {
+		t.Errorf("Log does not contain context attrs: %s", output.String())
+	}
+}
+
+func TestDisabledLevelSkipsCapture(t *testing.T) {
+	body := strings.NewReader(`{"hello": "world"}`)
+	var sentBody io.Reader
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelInfo}))),
+		WithLoggingLevel(slog.LevelDebug),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				sentBody = r.Body
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		CaptureRequestBody(),
+	)
+
+	req := Must(http.NewRequest(http.MethodPost, "http://localhost/", body))
+	original := req.Body
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if sentBody != original || body.Len() == 0 {
+		t.Error("Body should not be buffered when the record would be discarded")
+	}
+}
+
+func TestDeadlineBudget(t *testing.T) {
+	var buf bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				time.Sleep(20 * time.Millisecond)
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	for _, ctx := range []context.Context{ctx, context.Background()} {
+		buf.Reset()
+
+		if _, err := st.RoundTrip(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost", nil))); err != nil {
+			t.Fatal(err)
+		}
+
+		var record struct {
+			Request struct {
+				DeadlineRemaining *time.Duration `json:"deadline_remaining"`
+			} `json:"request"`
+			Response struct {
+				BudgetUsedPct *float64 `json:"budget_used_pct"`
+			} `json:"response"`
+		}
+
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+
+		remaining, used := record.Request.DeadlineRemaining, record.Response.BudgetUsedPct
+
+		if _, ok := ctx.Deadline(); !ok {
+			if remaining != nil || used != nil {
+				t.Errorf("Expected no budget without a deadline: %s", buf.String())
+			}
+
+			continue
+		}
+
+		if remaining == nil || *remaining <= 0 || *remaining > 100*time.Millisecond {
+			t.Errorf("Unexpected deadline_remaining: %s", buf.String())
+		}
+
+		if used == nil || *used < 20 || *used > 100 {
+			t.Errorf("Unexpected budget_used_pct: %s", buf.String())
+		}
+	}
+}
+
+func TestDeadlineBudgetClock(t *testing.T) {
+	var buf bytes.Buffer
+
+	// The tripper's clock runs a second ahead of the wall's
+	wall := time.Now()
+	now := wall.Add(time.Second)
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				now = now.Add(500 * time.Millisecond)
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithClock(ClockFunc(func() time.Time { return now })),
+	)
+
+	ctx, cancel := context.WithDeadline(context.Background(), wall.Add(2*time.Second))
+	defer cancel()
+
+	if _, err := st.RoundTrip(Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost", nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), `"deadline_remaining":1000000000`) || !strings.Contains(buf.String(), `"budget_used_pct":50`) {
+		t.Errorf("Expected the budget measured by the tripper's clock: %s", buf.String())
+	}
+}
+
+func TestOutcomeAttrs(t *testing.T) {
+	var buf bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				switch r.URL.Path {
+				case "/error":
+					return nil, errors.New("connection refused")
+				case "/missing":
+					return &http.Response{StatusCode: http.StatusNotFound}, nil
+				case "/moved":
+					return &http.Response{StatusCode: http.StatusMovedPermanently}, nil
+				}
+
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+	)
+
+	for _, tt := range []struct {
+		path        string
+		statusClass string
+		success     bool
+		error       bool
+	}{
+		{path: "/ok", statusClass: "2xx", success: true},
+		{path: "/moved", statusClass: "3xx", success: true},
+		{path: "/missing", statusClass: "4xx"},
+		{path: "/error", error: true},
+	} {
+		buf.Reset()
+		_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost"+tt.path, nil)))
+
+		var record struct {
+			StatusClass string `json:"status_class"`
+			Success     bool   `json:"success"`
+			Error       bool   `json:"error"`
+		}
+
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+
+		if record.StatusClass != tt.statusClass || record.Success != tt.success || record.Error != tt.error {
+			t.Errorf("%s: unexpected record %s", tt.path, buf.String())
+		}
+	}
+}
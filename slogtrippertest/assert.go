@@ -0,0 +1,165 @@
+package slogtrippertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Matcher checks an entry, returning why it doesn't match.
+type Matcher func(e Entry) error
+
+// AssertLogged fails t unless a request record was logged for method and
+// url with status, and satisfying every matcher, returning the first such
+// entry. It assumes the default request and response group names.
+func (r *Recorder) AssertLogged(t testing.TB, method, url string, status int, matchers ...Matcher) Entry {
+	t.Helper()
+
+	var reasons []string
+	for _, e := range r.Entries() {
+		if !matchRequest(e, method, url) {
+			continue
+		}
+
+		if got, _ := e.Get("response.status_code"); fmt.Sprint(got) != fmt.Sprint(status) {
+			reasons = append(reasons, fmt.Sprintf("status code %v", got))
+			continue
+		}
+
+		var failed bool
+		for _, m := range matchers {
+			if err := m(e); err != nil {
+				reasons = append(reasons, err.Error())
+				failed = true
+
+				break
+			}
+		}
+
+		if !failed {
+			return e
+		}
+	}
+
+	if len(reasons) == 0 {
+		t.Fatalf("No request logged for %s %s", method, url)
+	} else {
+		t.Fatalf("No request logged for %s %s with status %d matching: %s", method, url, status, strings.Join(reasons, "; "))
+	}
+
+	return Entry{}
+}
+
+// AssertNotLogged fails t if a request record was logged for method and url.
+func (r *Recorder) AssertNotLogged(t testing.TB, method, url string) {
+	t.Helper()
+
+	for _, e := range r.Entries() {
+		if matchRequest(e, method, url) {
+			t.Fatalf("Unexpected request logged for %s %s", method, url)
+		}
+	}
+}
+
+func matchRequest(e Entry, method, url string) bool {
+	m, _ := e.Get("request.method")
+	u, _ := e.Get("request.url")
+
+	return m == method && u == url
+}
+
+// Attr matches entries with want at path, compared by their printed form so
+// numbers of any type match.
+func Attr(path string, want any) Matcher {
+	return func(e Entry) error {
+		got, ok := e.Get(path)
+		if !ok {
+			return fmt.Errorf("%s missing", path)
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			return fmt.Errorf("%s is %v, not %v", path, got, want)
+		}
+
+		return nil
+	}
+}
+
+// RequestBodyEquals matches entries whose request body was logged as want.
+func RequestBodyEquals(want string) Matcher {
+	return bodyMatcher("request", "equal to", func(body string) bool { return body == want }, want)
+}
+
+// ResponseBodyEquals matches entries whose response body was logged as want.
+func ResponseBodyEquals(want string) Matcher {
+	return bodyMatcher("response", "equal to", func(body string) bool { return body == want }, want)
+}
+
+// RequestBodyContains matches entries whose logged request body contains s.
+func RequestBodyContains(s string) Matcher {
+	return bodyMatcher("request", "containing", func(body string) bool { return strings.Contains(body, s) }, s)
+}
+
+// ResponseBodyContains matches entries whose logged response body contains s.
+func ResponseBodyContains(s string) Matcher {
+	return bodyMatcher("response", "containing", func(body string) bool { return strings.Contains(body, s) }, s)
+}
+
+// RequestBodyJSON matches entries whose logged request body is the same JSON
+// as want, whatever its formatting.
+func RequestBodyJSON(want string) Matcher {
+	return bodyMatcher("request", "JSON equal to", jsonEqual(want), want)
+}
+
+// ResponseBodyJSON matches entries whose logged response body is the same
+// JSON as want, whatever its formatting.
+func ResponseBodyJSON(want string) Matcher {
+	return bodyMatcher("response", "JSON equal to", jsonEqual(want), want)
+}
+
+func bodyMatcher(group, relation string, match func(body string) bool, want string) Matcher {
+	return func(e Entry) error {
+		v, ok := e.Get(group + ".body_content")
+		if !ok {
+			return fmt.Errorf("%s body not logged", group)
+		}
+
+		if body := bodyString(v); !match(body) {
+			return fmt.Errorf("%s body %q isn't %s %q", group, body, relation, want)
+		}
+
+		return nil
+	}
+}
+
+// bodyString returns a logged body as text, re-encoding decoded bodies.
+func bodyString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case json.RawMessage:
+		return string(v)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
+
+func jsonEqual(want string) func(body string) bool {
+	return func(body string) bool {
+		var a, b any
+		if json.Unmarshal([]byte(body), &a) != nil || json.Unmarshal([]byte(want), &b) != nil {
+			return false
+		}
+
+		return reflect.DeepEqual(a, b)
+	}
+}
@@ -0,0 +1,130 @@
+package slogtrippertest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/b1scuit/slogtripper"
+)
+
+// Server is an httptest.Server with a client wired to log through a
+// SlogTripper into a Recorder, for end to end tests of HTTP integrations.
+type Server struct {
+	*httptest.Server
+
+	// Client sends requests to the server, logging them to Recorder
+	Client   *http.Client
+	Recorder *Recorder
+	Tripper  *slogtripper.SlogTripper
+
+	// calls serializes Do, so the server requests it sees are its own
+	calls sync.Mutex
+
+	mu   sync.Mutex
+	seen []*http.Request
+}
+
+// Call is one request made with Server.Do, seen from both ends.
+type Call struct {
+	// ServerRequest is the request as the handler received it, with its
+	// body read in full so it can be read again
+	ServerRequest *http.Request
+	// Response is the response the client received, its body already read
+	// into Body
+	Response *http.Response
+	Body     []byte
+	// Entry is the client's record of the request
+	Entry Entry
+}
+
+// NewServer starts a server for h, and a client logging to it configured by
+// opts. Both are closed when the test ends.
+func NewServer(t testing.TB, h http.Handler, opts ...slogtripper.Option) *Server {
+	t.Helper()
+
+	s := &Server{Recorder: NewRecorder()}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen := r.Clone(r.Context())
+		if r.Body != nil {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			seen.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		s.mu.Lock()
+		s.seen = append(s.seen, seen)
+		s.mu.Unlock()
+
+		h.ServeHTTP(w, r)
+	}))
+
+	s.Tripper = slogtripper.NewSlogTripper(append([]slogtripper.Option{
+		slogtripper.WithLogger(s.Recorder.Logger()),
+		slogtripper.WithRoundTripper(s.Server.Client().Transport),
+	}, opts...)...)
+	s.Client = &http.Client{Transport: s.Tripper}
+
+	t.Cleanup(func() {
+		s.Server.Close()
+		s.Tripper.Close()
+	})
+
+	return s
+}
+
+// ServerRequests returns every request the handler has received.
+func (s *Server) ServerRequests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*http.Request(nil), s.seen...)
+}
+
+// Do sends req with the server's client, returning the request the handler
+// received and the client's record of it. Following a redirect, they're
+// those of the last hop.
+func (s *Server) Do(req *http.Request) (*Call, error) {
+	s.calls.Lock()
+	defer s.calls.Unlock()
+
+	seen := len(s.ServerRequests())
+	logged := len(s.Recorder.Entries())
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The record can wait for the body to be finished with
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	call := &Call{Response: res, Body: body}
+
+	if requests := s.ServerRequests(); len(requests) > seen {
+		call.ServerRequest = requests[len(requests)-1]
+	}
+
+	entries := s.Recorder.Entries()
+	for i := len(entries) - 1; i >= logged; i-- {
+		if _, ok := entries[i].Get("request.method"); ok {
+			call.Entry = entries[i]
+			break
+		}
+	}
+
+	return call, nil
+}
@@ -0,0 +1,70 @@
+package slogtrippertest
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/b1scuit/slogtripper"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}), slogtripper.CaptureRequestBody(), slogtripper.WithTransferSizes())
+
+	req, err := http.NewRequest(http.MethodPut, s.URL+"/users/1", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call, err := s.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(call.Body) != "hello" || call.Response.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected response %d %q", call.Response.StatusCode, call.Body)
+	}
+
+	if body, _ := io.ReadAll(call.ServerRequest.Body); call.ServerRequest.Method != http.MethodPut || string(body) != "hello" {
+		t.Errorf("Unexpected server request %s %q", call.ServerRequest.Method, body)
+	}
+
+	if err := Attr("response.bytes_received", 5)(call.Entry); err != nil {
+		t.Error(err)
+	}
+
+	if err := RequestBodyEquals("hello")(call.Entry); err != nil {
+		t.Error(err)
+	}
+
+	req, err = http.NewRequest(http.MethodGet, s.URL+"/old", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call, err = s.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if call.ServerRequest.URL.Path != "/new" {
+		t.Errorf("Expected the last hop's request, got %s", call.ServerRequest.URL)
+	}
+
+	if u, _ := call.Entry.Get("request.url"); u != s.URL+"/new" {
+		t.Errorf("Expected the last hop's entry, got %v", u)
+	}
+
+	if got := len(s.ServerRequests()); got != 3 {
+		t.Errorf("Expected three server requests, got %d", got)
+	}
+}
@@ -0,0 +1,166 @@
+// Package slogtrippertest helps test that code logs its HTTP calls through
+// slogtripper, recording what's logged in memory and asserting on it. For end
+// to end tests, NewServer wires a logged client to an httptest.Server.
+//
+//	rec := slogtrippertest.NewRecorder()
+//	client := &http.Client{Transport: slogtripper.NewSlogTripper(
+//		slogtripper.WithLogger(rec.Logger()),
+//		slogtripper.WithRoundTripper(slogtrippertest.NewTransport(handler)),
+//		slogtripper.CaptureResponseBody(),
+//	)}
+//
+//	// ... exercise the code using client ...
+//
+//	rec.AssertLogged(t, http.MethodGet, "http://api.example.com/users/1", http.StatusOK,
+//		slogtrippertest.ResponseBodyContains(`"name":"gopher"`))
+package slogtrippertest
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a record as it was logged, with its attributes resolved and its
+// groups as nested maps.
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   map[string]any
+}
+
+// Get returns the attribute at path, with groups separated by dots, such as
+// "response.status_code".
+func (e Entry) Get(path string) (any, bool) {
+	var v any = e.Attrs
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+// Recorder is a slog.Handler keeping every record in memory. It's safe for
+// concurrent use.
+type Recorder struct {
+	attrs []slog.Attr
+	group []string
+
+	mu      *sync.Mutex
+	entries *[]Entry
+}
+
+// NewRecorder returns a Recorder for records of every level.
+func NewRecorder() *Recorder {
+	return &Recorder{mu: new(sync.Mutex), entries: new([]Entry)}
+}
+
+// Logger returns a logger writing to r.
+func (r *Recorder) Logger() *slog.Logger {
+	return slog.New(r)
+}
+
+// Entries returns the records logged so far.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Entry(nil), *r.entries...)
+}
+
+// Reset forgets the records logged so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	*r.entries = nil
+}
+
+func (r *Recorder) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (r *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *r
+	c.attrs = append(c.attrs[:len(c.attrs):len(c.attrs)], nest(r.group, attrs)...)
+
+	return &c
+}
+
+func (r *Recorder) WithGroup(name string) slog.Handler {
+	c := *r
+	c.group = append(c.group[:len(c.group):len(c.group)], name)
+
+	return &c
+}
+
+func (r *Recorder) Handle(_ context.Context, record slog.Record) error {
+	var attrs []slog.Attr
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+
+	m := map[string]any{}
+	for _, attr := range append(r.attrs, nest(r.group, attrs)...) {
+		put(m, attr)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	*r.entries = append(*r.entries, Entry{Time: record.Time, Level: record.Level, Message: record.Message, Attrs: m})
+
+	return nil
+}
+
+// nest puts attrs inside groups.
+func nest(groups []string, attrs []slog.Attr) []slog.Attr {
+	for i := len(groups) - 1; i >= 0; i-- {
+		args := make([]any, len(attrs))
+		for j, attr := range attrs {
+			args[j] = attr
+		}
+
+		attrs = []slog.Attr{slog.Group(groups[i], args...)}
+	}
+
+	return attrs
+}
+
+// put resolves attr into m, merging groups of the same name.
+func put(m map[string]any, attr slog.Attr) {
+	v := attr.Value.Resolve()
+	if v.Kind() != slog.KindGroup {
+		if attr.Key != "" {
+			m[attr.Key] = v.Any()
+		}
+
+		return
+	}
+
+	group := m
+	if attr.Key != "" {
+		g, ok := m[attr.Key].(map[string]any)
+		if !ok {
+			g = map[string]any{}
+			m[attr.Key] = g
+		}
+
+		group = g
+	}
+
+	for _, a := range v.Group() {
+		put(group, a)
+	}
+}
@@ -0,0 +1,123 @@
+package slogtrippertest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/b1scuit/slogtripper"
+)
+
+// fakeTB records whether an assertion failed, rather than failing the test.
+type fakeTB struct {
+	testing.TB
+	failed string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.failed = fmt.Sprintf(format, args...)
+}
+
+func TestRecorder(t *testing.T) {
+	transport := NewTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, `{"echo": %s, "path": %q}`, body, r.URL.Path)
+	}))
+
+	rec := NewRecorder()
+	client := &http.Client{Transport: slogtripper.NewSlogTripper(
+		slogtripper.WithLogger(rec.Logger()),
+		slogtripper.WithRoundTripper(transport),
+		slogtripper.CaptureRequestBody(),
+		slogtripper.CaptureResponseBody(),
+	)}
+
+	res, err := client.Post("http://api.example.com/users", "application/json", strings.NewReader(`{"name":"gopher"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	res, err = client.Get("http://api.example.com/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if got := len(transport.Requests()); got != 2 {
+		t.Errorf("Expected two requests sent, got %d", got)
+	}
+
+	if got := len(rec.Entries()); got != 2 {
+		t.Fatalf("Expected two entries, got %d", got)
+	}
+
+	e := rec.AssertLogged(t, http.MethodPost, "http://api.example.com/users", http.StatusOK,
+		RequestBodyEquals(`{"name":"gopher"}`),
+		ResponseBodyContains(`"path": "/users"`),
+		ResponseBodyJSON(`{"path":"/users","echo":{"name":"gopher"}}`),
+		Attr("response.content_type", "application/json"),
+	)
+
+	if e.Message != "HTTP Request" {
+		t.Errorf("Unexpected entry: %+v", e)
+	}
+
+	rec.AssertLogged(t, http.MethodGet, "http://api.example.com/missing", http.StatusNotFound)
+	rec.AssertNotLogged(t, http.MethodDelete, "http://api.example.com/users")
+
+	for _, tt := range []struct {
+		name   string
+		assert func(tb testing.TB)
+		reason string
+	}{
+		{
+			name: "unlogged",
+			assert: func(tb testing.TB) {
+				rec.AssertLogged(tb, http.MethodGet, "http://api.example.com/other", http.StatusOK)
+			},
+			reason: "No request logged for GET http://api.example.com/other",
+		},
+		{
+			name: "status",
+			assert: func(tb testing.TB) {
+				rec.AssertLogged(tb, http.MethodGet, "http://api.example.com/missing", http.StatusOK)
+			},
+			reason: "status code 404",
+		},
+		{
+			name: "body",
+			assert: func(tb testing.TB) {
+				rec.AssertLogged(tb, http.MethodPost, "http://api.example.com/users", http.StatusOK, RequestBodyJSON(`{"name":"other"}`))
+			},
+			reason: "isn't JSON equal to",
+		},
+		{
+			name:   "logged",
+			assert: func(tb testing.TB) { rec.AssertNotLogged(tb, http.MethodPost, "http://api.example.com/users") },
+			reason: "Unexpected request logged",
+		},
+	} {
+		tb := &fakeTB{}
+		tt.assert(tb)
+
+		if !strings.Contains(tb.failed, tt.reason) {
+			t.Errorf("%s: expected a failure mentioning %q, got %q", tt.name, tt.reason, tb.failed)
+		}
+	}
+
+	rec.Reset()
+	if len(rec.Entries()) != 0 {
+		t.Error("Expected no entries after a reset")
+	}
+}
@@ -0,0 +1,61 @@
+package slogtrippertest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Transport is an http.RoundTripper answering requests from a handler in
+// memory, without a network, keeping the requests it's sent. It's safe for
+// concurrent use.
+type Transport struct {
+	handler http.Handler
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+// NewTransport returns a Transport answering with h.
+func NewTransport(h http.Handler) *Transport {
+	return &Transport{handler: h}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The handler sees a server request, with its body read in full
+	r := req.Clone(req.Context())
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	r.RequestURI = req.URL.RequestURI()
+
+	t.mu.Lock()
+	t.requests = append(t.requests, r)
+	t.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, r)
+
+	res := rec.Result()
+	res.Request = req
+
+	return res, nil
+}
+
+// Requests returns the requests sent so far.
+func (t *Transport) Requests() []*http.Request {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]*http.Request(nil), t.requests...)
+}
@@ -0,0 +1,157 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+// maxSSEData is how much of an event's data is logged.
+const maxSSEData = 1024
+
+// isEventStream reports whether contentType is that of Server-Sent Events,
+// a stream which may never end, so can't be captured whole.
+func isEventStream(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "text/event-stream"
+}
+
+// sseBody logs the Server-Sent Events read through it, one record each, in
+// place of capturing a response body which may never end.
+type sseBody struct {
+	io.ReadCloser
+	st  *SlogTripper
+	ctx context.Context
+	id  string
+	url string
+
+	mu      sync.Mutex
+	partial []byte
+	event   sseEvent
+	seq     int
+}
+
+type sseEvent struct {
+	name    string
+	id      string
+	data    []byte
+	hasData bool
+}
+
+// newSSEBody wraps the body of the response to req, logged under id.
+func (st *SlogTripper) newSSEBody(req *http.Request, body io.ReadCloser, id string) *sseBody {
+	b := &sseBody{ReadCloser: body, st: st, ctx: context.Background(), id: id}
+	if req != nil {
+		b.ctx = context.WithoutCancel(req.Context())
+		if req.URL != nil {
+			b.url = req.URL.String()
+		}
+	}
+
+	return b
+}
+
+func (b *sseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.partial = append(b.partial, p[:n]...)
+	for {
+		i := bytes.IndexByte(b.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		b.line(bytes.TrimSuffix(b.partial[:i], []byte("\r")))
+		b.partial = b.partial[i+1:]
+	}
+
+	// A line longer than any event worth logging is cut short
+	if len(b.partial) > 2*maxSSEData {
+		b.partial = b.partial[:2*maxSSEData]
+	}
+
+	return n, err
+}
+
+// line handles one line of the stream, as the spec for EventSource does.
+func (b *sseBody) line(line []byte) {
+	if len(line) == 0 {
+		b.dispatch()
+		return
+	}
+
+	if line[0] == ':' {
+		return
+	}
+
+	field, value, _ := bytes.Cut(line, []byte(":"))
+	value = bytes.TrimPrefix(value, []byte(" "))
+
+	switch string(field) {
+	case "event":
+		b.event.name = string(value)
+	case "id":
+		b.event.id = string(value)
+	case "data":
+		if b.event.hasData {
+			b.event.data = append(b.event.data, '\n')
+		}
+
+		if room := maxSSEData + 1 - len(b.event.data); room > 0 {
+			b.event.data = append(b.event.data, value[:min(len(value), room)]...)
+		}
+
+		b.event.hasData = true
+	}
+}
+
+// dispatch logs the event built up so far, if it has any data.
+func (b *sseBody) dispatch() {
+	event := b.event
+	b.event = sseEvent{}
+
+	if !event.hasData || b.st.disabled.Load() {
+		return
+	}
+
+	b.seq++
+
+	name := event.name
+	if name == "" {
+		name = "message"
+	}
+
+	attrs := []any{
+		slog.Int("sequence", b.seq),
+		slog.String("name", name),
+	}
+
+	if event.id != "" {
+		attrs = append(attrs, slog.String("id", event.id))
+	}
+
+	data := event.data
+	if len(data) > maxSSEData {
+		data = trimPartialRune(data[:maxSSEData])
+		attrs = append(attrs, slog.String("data", string(data)), slog.Bool("data_truncated", true))
+	} else {
+		attrs = append(attrs, slog.String("data", string(data)))
+	}
+
+	b.st.log(b.ctx, "HTTP SSE Event",
+		slog.Group(b.st.requestKey, slog.String("id", b.id), slog.String("url", b.url)),
+		slog.Group("event", attrs...),
+	)
+}
+
+// sseStream reports whether res is an event stream.
+func sseStream(res *http.Response) bool {
+	return res.Header != nil && isEventStream(res.Header.Get("Content-Type"))
+}
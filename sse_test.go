@@ -0,0 +1,127 @@
+package slogtripper
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSSEEvents(t *testing.T) {
+	var output syncBuffer
+
+	stream := strings.Join([]string{
+		": a comment",
+		"event: update",
+		"id: 1",
+		"data: {\"price\": 1}",
+		"",
+		"data: first line",
+		"data: second line",
+		"",
+		"retry: 1000",
+		"",
+		"data: " + strings.Repeat("x", maxSSEData+10),
+		"",
+		"",
+	}, "\r\n")
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {"text/event-stream; charset=utf-8"}},
+					// Events arriving a byte at a time are put back together
+					Body: io.NopCloser(iotest.OneByteReader(strings.NewReader(stream))),
+				}, nil
+			},
+		}),
+		CaptureResponseBody(),
+	)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/events", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The request is logged straight away, not once the stream ends
+	if n := len(output.Lines()); n != 1 {
+		t.Fatalf("Expected the request logged, got %d records", n)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if string(body) != stream {
+		t.Error("Expected the stream passed through untouched")
+	}
+
+	type event struct {
+		Sequence      int    `json:"sequence"`
+		Name          string `json:"name"`
+		ID            string `json:"id"`
+		Data          string `json:"data"`
+		DataTruncated bool   `json:"data_truncated"`
+	}
+
+	var requestID string
+	var events []event
+
+	for _, line := range output.Lines() {
+		var record struct {
+			Msg     string `json:"msg"`
+			Request struct {
+				ID  string `json:"id"`
+				URL string `json:"url"`
+			} `json:"request"`
+			Response struct {
+				EventStream bool    `json:"event_stream"`
+				BodyContent *string `json:"body_content"`
+			} `json:"response"`
+			Event event `json:"event"`
+		}
+
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+
+		if record.Msg == "HTTP Request" {
+			requestID = record.Request.ID
+			if !record.Response.EventStream || record.Response.BodyContent != nil {
+				t.Errorf("Expected the stream noted rather than captured: %s", line)
+			}
+
+			continue
+		}
+
+		if record.Request.ID != requestID || record.Request.URL != "http://localhost/events" {
+			t.Errorf("Expected the event tied to its request: %s", line)
+		}
+
+		events = append(events, record.Event)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected three events, got %+v", events)
+	}
+
+	if e := events[0]; e.Sequence != 1 || e.Name != "update" || e.ID != "1" || e.Data != `{"price": 1}` {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+
+	if e := events[1]; e.Name != "message" || e.Data != "first line\nsecond line" {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+
+	if e := events[2]; !e.DataTruncated || len(e.Data) != maxSSEData {
+		t.Errorf("Expected the data truncated, got %d bytes", len(e.Data))
+	}
+}
@@ -0,0 +1,185 @@
+package slogtripper
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultStatsBuckets are the duration histogram bounds used by WithStats when
+// none are given.
+var DefaultStatsBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Stats is a snapshot of the counters kept by WithStats, since the tripper
+// was created.
+type Stats struct {
+	Hosts map[string]HostStats `json:"hosts"`
+}
+
+// HostStats are the counters for a single host.
+type HostStats struct {
+	Requests int64 `json:"requests"`
+	// Errors counts transport errors, responses are counted by class
+	Errors        int64            `json:"errors"`
+	StatusClasses map[string]int64 `json:"status_classes"`
+	// BytesSent and BytesReceived count the bytes of request and response
+	// bodies as they're read, so chunked bodies are counted too. For
+	// Middleware, that's the request body the handler reads and the response
+	// body it writes
+	BytesSent     int64     `json:"bytes_sent"`
+	BytesReceived int64     `json:"bytes_received"`
+	Durations     Histogram `json:"durations"`
+}
+
+// Histogram counts durations into buckets. Counts[i] is how many were at most
+// Bounds[i], the extra last count is everything longer.
+type Histogram struct {
+	Bounds []time.Duration `json:"bounds"`
+	Counts []int64         `json:"counts"`
+}
+
+// WithStats keeps per host counters, read with Stats. buckets are the upper
+// bounds of the duration histogram, defaulting to DefaultStatsBuckets.
+func WithStats(buckets ...time.Duration) Option {
+	return func(st *SlogTripper) {
+		if len(buckets) == 0 {
+			buckets = DefaultStatsBuckets
+		}
+
+		bounds := append([]time.Duration(nil), buckets...)
+		sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+		st.stats = &stats{bounds: bounds, hosts: map[string]*HostStats{}}
+	}
+}
+
+type stats struct {
+	bounds []time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*HostStats
+}
+
+// host returns the counters for host. s.mu is held.
+func (s *stats) host(host string) *HostStats {
+	h, ok := s.hosts[host]
+	if !ok {
+		h = &HostStats{
+			StatusClasses: map[string]int64{},
+			Durations: Histogram{
+				Bounds: s.bounds,
+				Counts: make([]int64, len(s.bounds)+1),
+			},
+		}
+		s.hosts[host] = h
+	}
+
+	return h
+}
+
+func (s *stats) record(host string, res *http.Response, err error, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := s.host(host)
+	h.Requests++
+
+	if err != nil {
+		h.Errors++
+	} else if res != nil {
+		h.StatusClasses[statusClass(res.StatusCode)]++
+	}
+
+	h.Durations.Counts[sort.Search(len(s.bounds), func(i int) bool { return elapsed <= s.bounds[i] })]++
+}
+
+// addBytes adds n bytes of a request body, or a response body when response
+// is set, to host's counters.
+func (s *stats) addBytes(host string, n int64, response bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if h := s.host(host); response {
+		h.BytesReceived += n
+	} else {
+		h.BytesSent += n
+	}
+}
+
+// countRequest returns req with its body counted into host's bytes sent.
+func (s *stats) countRequest(host string, req *http.Request) *http.Request {
+	if req == nil || !hasBody(req.Body) {
+		return req
+	}
+
+	r := req.WithContext(req.Context())
+	r.Body = &statsBody{ReadCloser: req.Body, s: s, host: host}
+
+	return r
+}
+
+// countResponse counts res's body into host's bytes received.
+func (s *stats) countResponse(host string, res *http.Response) {
+	if wrappableBody(res) {
+		res.Body = &statsBody{ReadCloser: res.Body, s: s, host: host, response: true}
+	}
+}
+
+// statsBody adds the bytes read through a body to a host's counters as
+// they're read.
+type statsBody struct {
+	io.ReadCloser
+	s        *stats
+	host     string
+	response bool
+}
+
+func (b *statsBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.s.addBytes(b.host, int64(n), b.response)
+	}
+
+	return n, err
+}
+
+// Stats returns a snapshot of the counters kept since the tripper was
+// created, empty unless WithStats is used.
+func (st *SlogTripper) Stats() Stats {
+	snapshot := Stats{Hosts: map[string]HostStats{}}
+	if st.stats == nil {
+		return snapshot
+	}
+
+	st.stats.mu.Lock()
+	defer st.stats.mu.Unlock()
+
+	for host, h := range st.stats.hosts {
+		c := *h
+
+		c.StatusClasses = make(map[string]int64, len(h.StatusClasses))
+		for class, n := range h.StatusClasses {
+			c.StatusClasses[class] = n
+		}
+
+		c.Durations.Counts = append([]int64(nil), h.Durations.Counts...)
+
+		snapshot.Hosts[host] = c
+	}
+
+	return snapshot
+}
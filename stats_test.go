@@ -0,0 +1,91 @@
+package slogtripper
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path == "/error" {
+					return nil, errors.New("connection refused")
+				}
+
+				io.Copy(io.Discard, r.Body)
+
+				// Of unknown length, as when chunked
+				return &http.Response{StatusCode: http.StatusOK, ContentLength: -1, Body: io.NopCloser(strings.NewReader("0123456789"))}, nil
+			},
+		}),
+		WithStats(time.Hour, time.Minute),
+	)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodPost, "http://api/", strings.NewReader("payload"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://api/error", nil)))
+
+	stats := st.Stats()
+
+	expected := HostStats{
+		Requests:      2,
+		Errors:        1,
+		StatusClasses: map[string]int64{"2xx": 1},
+		BytesSent:     7,
+		BytesReceived: 10,
+		Durations: Histogram{
+			Bounds: []time.Duration{time.Minute, time.Hour},
+			Counts: []int64{2, 0, 0},
+		},
+	}
+
+	if !reflect.DeepEqual(stats.Hosts["api"], expected) {
+		t.Errorf("Unexpected stats:\n%+v\nexpected:\n%+v", stats.Hosts["api"], expected)
+	}
+
+	// Snapshots are copies
+	stats.Hosts["api"].StatusClasses["2xx"] = 100
+	if st.Stats().Hosts["api"].StatusClasses["2xx"] != 1 {
+		t.Error("Modifying a snapshot changed the tripper's stats")
+	}
+}
+
+func TestStatsDisabled(t *testing.T) {
+	if stats := NewSlogTripper().Stats(); len(stats.Hosts) != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestStatsMiddleware(t *testing.T) {
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithStats(),
+	)
+
+	handler := st.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte("created"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload"))
+	req.Host = "example.com"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if h := st.Stats().Hosts["example.com"]; h.BytesSent != 7 || h.BytesReceived != 7 {
+		t.Errorf("Unexpected stats: %+v", h)
+	}
+}
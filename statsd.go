@@ -0,0 +1,93 @@
+package slogtripper
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithStatsd sends a count and a timing for every round trip, whether or not
+// it's logged, to the statsd server at addr over UDP:
+//
+//	<prefix>.requests.<host>.<method>.<status_class>:1|c
+//	<prefix>.duration.<host>.<method>.<status_class>:<ms>|ms
+//
+// where host has its dots and colons replaced with underscores and
+// status_class is 2xx, 5xx and so on, or error for transport errors. Metrics
+// are fire and forget: ones the server doesn't get are lost. Requests
+// Middleware handles aren't sent, as these are client metrics. Close closes
+// the socket.
+func WithStatsd(addr, prefix string) Option {
+	return func(st *SlogTripper) {
+		st.statsd = newStatsd(st, addr, prefix, false)
+	}
+}
+
+// WithDogstatsd is WithStatsd for DogStatsD, with the host, method and status
+// class as the tags http_host, http_method and status_class rather than in
+// the metric names:
+//
+//	<prefix>.requests:1|c|#http_host:<host>,http_method:<method>,status_class:<status_class>
+func WithDogstatsd(addr, prefix string) Option {
+	return func(st *SlogTripper) {
+		st.statsd = newStatsd(st, addr, prefix, true)
+	}
+}
+
+type statsd struct {
+	conn   net.Conn
+	prefix string
+	tags   bool
+}
+
+// newStatsd returns a client sending to addr, or nil if it can't.
+func newStatsd(st *SlogTripper, addr, prefix string, tags bool) *statsd {
+	if addr == "" {
+		st.invalid("empty statsd address")
+		return nil
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		st.invalid("statsd address %q: %v", addr, err)
+		return nil
+	}
+
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+
+	return &statsd{conn: conn, prefix: prefix, tags: tags}
+}
+
+// record sends the metrics for a round trip in one packet.
+func (s *statsd) record(host string, req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	method := http.MethodGet
+	if req != nil && req.Method != "" {
+		method = req.Method
+	}
+
+	class := "error"
+	if err == nil && res != nil {
+		class = statusClass(res.StatusCode)
+	}
+
+	ms := strconv.FormatFloat(float64(elapsed)/float64(time.Millisecond), 'f', -1, 64)
+
+	var b strings.Builder
+	if s.tags {
+		tags := "|#http_host:" + host + ",http_method:" + method + ",status_class:" + class
+
+		b.WriteString(s.prefix + "requests:1|c" + tags + "\n")
+		b.WriteString(s.prefix + "duration:" + ms + "|ms" + tags)
+	} else {
+		name := "." + strings.NewReplacer(".", "_", ":", "_").Replace(host) + "." + method + "." + class
+
+		b.WriteString(s.prefix + "requests" + name + ":1|c\n")
+		b.WriteString(s.prefix + "duration" + name + ":" + ms + "|ms")
+	}
+
+	_, _ = s.conn.Write([]byte(b.String()))
+}
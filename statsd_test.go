@@ -0,0 +1,132 @@
+package slogtripper
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// listenStatsd returns a UDP socket standing in for a statsd server.
+func listenStatsd(t *testing.T) net.PacketConn {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+
+	buf := make([]byte, 1500)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(buf[:n])
+}
+
+func TestWithStatsd(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		option func(addr, prefix string) Option
+		want   *regexp.Regexp
+	}{
+		{
+			name:   "statsd",
+			option: WithStatsd,
+			want: regexp.MustCompile(`^myapp\.http\.requests\.api_example_8080\.POST\.5xx:1\|c\n` +
+				`myapp\.http\.duration\.api_example_8080\.POST\.5xx:[0-9.]+\|ms$`),
+		},
+		{
+			name:   "dogstatsd",
+			option: WithDogstatsd,
+			want: regexp.MustCompile(`^myapp\.http\.requests:1\|c\|#http_host:api\.example:8080,http_method:POST,status_class:5xx\n` +
+				`myapp\.http\.duration:[0-9.]+\|ms\|#http_host:api\.example:8080,http_method:POST,status_class:5xx$`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := listenStatsd(t)
+
+			st := NewSlogTripper(
+				WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+				WithRoundTripper(&MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						return &http.Response{StatusCode: http.StatusBadGateway, Body: http.NoBody}, nil
+					},
+				}),
+				tc.option(server.LocalAddr().String(), "myapp.http"),
+				DisableLogging(),
+			)
+			defer st.Close()
+
+			if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodPost, "http://api.example:8080/orders", nil))); err != nil {
+				t.Fatal(err)
+			}
+
+			if packet := readPacket(t, server); !tc.want.MatchString(packet) {
+				t.Errorf("Unexpected packet %q", packet)
+			}
+		})
+	}
+}
+
+func TestWithStatsdError(t *testing.T) {
+	server := listenStatsd(t)
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			},
+		}),
+		WithStatsd(server.LocalAddr().String(), ""),
+	)
+	defer st.Close()
+
+	st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil)))
+
+	if packet, want := readPacket(t, server), regexp.MustCompile(`^requests\.localhost\.GET\.error:1\|c\n`); !want.MatchString(packet) {
+		t.Errorf("Unexpected packet %q", packet)
+	}
+}
+
+func TestWithStatsdInvalid(t *testing.T) {
+	for _, addr := range []string{"", "no port"} {
+		if _, err := NewSlogTripperE(WithStatsd(addr, "app")); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%q: expected an invalid option, got %v", addr, err)
+		}
+	}
+}
+
+func TestWithStatsdMiddleware(t *testing.T) {
+	server := listenStatsd(t)
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithStatsd(server.LocalAddr().String(), "app"),
+	)
+	defer st.Close()
+
+	st.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	server.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if n, _, err := server.ReadFrom(make([]byte, 1500)); err == nil {
+		t.Errorf("Expected nothing sent for a server request, got %d bytes", n)
+	}
+}
@@ -0,0 +1,181 @@
+package slogtripper
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxSummarySamples caps the latencies kept per host per interval, so a busy
+// host can't grow the summary without bound. Past it, percentiles are of a
+// uniform sample of the interval's latencies.
+const maxSummarySamples = 10_000
+
+// WithSummaryInterval logs an "HTTP Summary" record every d, with per host
+// request counts, counts per status class, the error rate (transport errors
+// and 5xx responses) and latency percentiles for the interval. Hosts without
+// requests in an interval are left out, as is the whole record when there
+// were none. Call Close to stop the summaries, logging one for the interval
+// so far.
+func WithSummaryInterval(d time.Duration) Option {
+	return func(st *SlogTripper) {
+		if d <= 0 {
+			st.invalid("non-positive summary interval %v", d)
+			return
+		}
+
+		st.summary = &summary{interval: d, hosts: map[string]*hostSummary{}, done: make(chan struct{})}
+	}
+}
+
+// SummaryOnly stops the per request records, leaving the periodic summaries
+// from WithSummaryInterval.
+func SummaryOnly() Option {
+	return func(st *SlogTripper) {
+		st.summaryOnly = true
+	}
+}
+
+type summary struct {
+	interval time.Duration
+	done     chan struct{}
+
+	mu    sync.Mutex
+	hosts map[string]*hostSummary
+}
+
+type hostSummary struct {
+	requests int
+	errors   int
+	classes  map[string]int
+	max      time.Duration
+	// latencies is a reservoir sample of the interval's latencies
+	latencies []time.Duration
+}
+
+func (s *summary) record(host string, res *http.Response, err error, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.hosts[host]
+	if !ok {
+		h = &hostSummary{classes: map[string]int{}}
+		s.hosts[host] = h
+	}
+
+	h.requests++
+
+	if err != nil {
+		h.errors++
+	} else if res != nil {
+		h.classes[statusClass(res.StatusCode)]++
+	}
+
+	h.max = max(h.max, elapsed)
+
+	if len(h.latencies) < maxSummarySamples {
+		h.latencies = append(h.latencies, elapsed)
+	} else if i := rand.Intn(h.requests); i < maxSummarySamples {
+		h.latencies[i] = elapsed
+	}
+}
+
+// flush returns the summary attrs for the interval so far and starts a new one.
+func (s *summary) flush() []any {
+	s.mu.Lock()
+	hosts := s.hosts
+	s.hosts = map[string]*hostSummary{}
+	s.mu.Unlock()
+
+	names := make([]string, 0, len(hosts))
+	for name := range hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attrs := make([]any, 0, len(names))
+	for _, name := range names {
+		h := hosts[name]
+
+		classes := make([]string, 0, len(h.classes))
+		for class := range h.classes {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+
+		status := make([]any, 0, len(classes))
+		for _, class := range classes {
+			status = append(status, slog.Int(class, h.classes[class]))
+		}
+
+		sort.Slice(h.latencies, func(i, j int) bool { return h.latencies[i] < h.latencies[j] })
+
+		attrs = append(attrs, slog.Group(name,
+			slog.Int("requests", h.requests),
+			slog.Int("errors", h.errors),
+			slog.Float64("error_rate", float64(h.errors+h.classes["5xx"])/float64(h.requests)),
+			slog.Group("status", status...),
+			slog.Group("latency",
+				slog.Duration("p50", percentile(h.latencies, 50)),
+				slog.Duration("p90", percentile(h.latencies, 90)),
+				slog.Duration("p99", percentile(h.latencies, 99)),
+				slog.Duration("max", h.max),
+			),
+		))
+	}
+
+	return attrs
+}
+
+// run logs a summary every interval until stop is closed, then one for the
+// interval so far.
+func (s *summary) run(st *SlogTripper, stop <-chan struct{}) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			s.log(st)
+			return
+		case <-ticker.C:
+			s.log(st)
+		}
+	}
+}
+
+// log logs the summary of the interval so far, if there were requests.
+func (s *summary) log(st *SlogTripper) {
+	if hosts := s.flush(); len(hosts) != 0 {
+		st.log(context.Background(), "HTTP Summary",
+			slog.Duration("interval", s.interval),
+			slog.Group("hosts", hosts...),
+		)
+	}
+}
+
+// percentile returns the p-th percentile of sorted, using nearest rank.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// statusClass returns the class of a status code, i.e. "2xx".
+func statusClass(code int) string {
+	if code < 100 || code > 999 {
+		return "unknown"
+	}
+
+	return strconv.Itoa(code/100) + "xx"
+}
@@ -0,0 +1,164 @@
+package slogtripper
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe to write from background goroutines
+type syncBuffer struct {
+	mu    sync.Mutex
+	lines [][]byte
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lines = append(b.lines, append([]byte(nil), p...))
+
+	return len(p), nil
+}
+
+func (b *syncBuffer) Lines() [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.lines
+}
+
+func TestSummaryInterval(t *testing.T) {
+	var output syncBuffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				switch r.URL.Path {
+				case "/error":
+					return nil, errors.New("connection refused")
+				case "/fail":
+					return &http.Response{StatusCode: http.StatusServiceUnavailable}, nil
+				}
+
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithSummaryInterval(20*time.Millisecond),
+		SummaryOnly(),
+	)
+	defer st.Close()
+
+	for _, path := range []string{"/", "/", "/fail", "/error"} {
+		_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://api"+path, nil)))
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(output.Lines()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	lines := output.Lines()
+	if len(lines) != 1 {
+		t.Fatalf("Expected only the summary to be logged, got %d records", len(lines))
+	}
+
+	var record struct {
+		Msg   string `json:"msg"`
+		Hosts map[string]struct {
+			Requests  int            `json:"requests"`
+			Errors    int            `json:"errors"`
+			ErrorRate float64        `json:"error_rate"`
+			Status    map[string]int `json:"status"`
+			Latency   map[string]any `json:"latency"`
+		} `json:"hosts"`
+	}
+	if err := json.Unmarshal(lines[0], &record); err != nil {
+		t.Fatalf("Error unmarshalling log: %v", err)
+	}
+
+	api := record.Hosts["api"]
+	if record.Msg != "HTTP Summary" || api.Requests != 4 || api.Errors != 1 || api.ErrorRate != 0.5 {
+		t.Errorf("Unexpected summary: %s", lines[0])
+	}
+
+	if api.Status["2xx"] != 2 || api.Status["5xx"] != 1 {
+		t.Errorf("Unexpected status classes: %v", api.Status)
+	}
+
+	for _, p := range []string{"p50", "p90", "p99", "max"} {
+		if _, ok := api.Latency[p]; !ok {
+			t.Errorf("Latency %s missing: %s", p, lines[0])
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	for p, expected := range map[int]time.Duration{50: 5, 90: 9, 99: 10, 0: 1} {
+		if d := percentile(sorted, p); d != expected {
+			t.Errorf("p%d: expected %v, got %v", p, expected, d)
+		}
+	}
+}
+
+func TestSummarySampled(t *testing.T) {
+	s := &summary{hosts: map[string]*hostSummary{}}
+
+	// Latencies grow through the interval, so only a sample of all of it has
+	// its median in the middle
+	const n = 3 * maxSummarySamples
+	for i := 1; i <= n; i++ {
+		s.record("api", &http.Response{StatusCode: http.StatusOK}, nil, time.Duration(i)*time.Millisecond)
+	}
+
+	api := s.flush()[0].(slog.Attr)
+
+	latency := map[string]time.Duration{}
+	for _, attr := range api.Value.Group() {
+		if attr.Key == "latency" {
+			for _, p := range attr.Value.Group() {
+				latency[p.Key] = p.Value.Duration()
+			}
+		}
+	}
+
+	if latency["max"] != n*time.Millisecond {
+		t.Errorf("Expected the max of every request, got %v", latency["max"])
+	}
+
+	if p50 := latency["p50"]; p50 < 12*time.Second || p50 > 18*time.Second {
+		t.Errorf("Expected the median of the whole interval, about 15s, got %v", p50)
+	}
+}
+
+func TestSummaryClose(t *testing.T) {
+	var output syncBuffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		}),
+		WithSummaryInterval(time.Hour),
+		WithAsyncLogging(10),
+		SummaryOnly(),
+	)
+
+	_, _ = st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://api/", nil)))
+	st.Close()
+
+	lines := output.Lines()
+	if len(lines) != 1 || !strings.Contains(string(lines[0]), `"msg":"HTTP Summary"`) || !strings.Contains(string(lines[0]), `"requests":1`) {
+		t.Errorf("Expected the partial interval summarised on Close, got %q", lines)
+	}
+}
@@ -0,0 +1,85 @@
+package slogtripper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithThrottle limits the request and response bodies of every round trip to
+// bytesPerSecond each, to reproduce a slow network locally. The limit is
+// logged as throttle_bytes_per_second, and the transfer sizes and effective
+// throughputs WithTransferSizes describes are logged with it.
+func WithThrottle(bytesPerSecond int64) Option {
+	return func(st *SlogTripper) {
+		if bytesPerSecond <= 0 {
+			st.invalid("throttle of %d bytes per second", bytesPerSecond)
+			return
+		}
+
+		st.throttle = bytesPerSecond
+		st.transferSizes = true
+	}
+}
+
+// sendThrottled sends req through send with its bodies throttled.
+func (st *SlogTripper) sendThrottled(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	ctx := req.Context()
+
+	if hasBody(req.Body) {
+		req = req.WithContext(ctx)
+		req.Body = newThrottledBody(ctx, req.Body, st.throttle)
+	}
+
+	res, err := send(req)
+	if wrappableBody(res) {
+		res.Body = newThrottledBody(ctx, res.Body, st.throttle)
+	}
+
+	return res, err
+}
+
+// throttledBody reads no faster than rate bytes per second, on average since
+// its first read.
+type throttledBody struct {
+	io.ReadCloser
+	ctx   context.Context
+	rate  int64
+	chunk int
+
+	start time.Time
+	n     int64
+}
+
+func newThrottledBody(ctx context.Context, body io.ReadCloser, rate int64) *throttledBody {
+	// Reads are kept to a tenth of a second's worth, so the pace is even
+	return &throttledBody{ReadCloser: body, ctx: ctx, rate: rate, chunk: int(max(rate/10, 1))}
+}
+
+func (t *throttledBody) Read(p []byte) (int, error) {
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+
+	if len(p) > t.chunk {
+		p = p[:t.chunk]
+	}
+
+	n, err := t.ReadCloser.Read(p)
+	t.n += int64(n)
+
+	due := time.Duration(float64(t.n) / float64(t.rate) * float64(time.Second))
+	if wait := due - time.Since(t.start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-t.ctx.Done():
+			return n, t.ctx.Err()
+		}
+	}
+
+	return n, err
+}
@@ -0,0 +1,82 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithThrottle(t *testing.T) {
+	var buf bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if _, err := io.Copy(io.Discard, r.Body); err != nil {
+					return nil, err
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(strings.Repeat("b", 1000))),
+				}, nil
+			},
+		}),
+		WithThrottle(10_000),
+	)
+
+	req := Must(http.NewRequest(http.MethodPost, "http://localhost", strings.NewReader(strings.Repeat("a", 1000))))
+
+	start := time.Now()
+
+	res, err := st.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	// A kilobyte each way at ten kilobytes a second
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("Expected the bodies throttled, took %s", elapsed)
+	}
+
+	var record struct {
+		Request struct {
+			Throttle  int64 `json:"throttle_bytes_per_second"`
+			BytesSent int64 `json:"bytes_sent"`
+		} `json:"request"`
+		Response struct {
+			BytesReceived int64   `json:"bytes_received"`
+			Throughput    float64 `json:"download_throughput_bps"`
+		} `json:"response"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Request.Throttle != 10_000 || record.Request.BytesSent != 1000 || record.Response.BytesReceived != 1000 {
+		t.Errorf("Unexpected record: %s", buf.String())
+	}
+
+	if record.Response.Throughput > 10_000*8*1.1 {
+		t.Errorf("Expected throughput within the throttle, got %v", record.Response.Throughput)
+	}
+}
+
+func TestWithThrottleInvalid(t *testing.T) {
+	if _, err := NewSlogTripperE(WithThrottle(0)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected a zero throttle rejected, got %v", err)
+	}
+}
@@ -0,0 +1,284 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithPhaseTimings breaks time_taken down into a phases group on the
+// response: connect (getting a connection, new or pooled), write_request,
+// time_to_first_byte (from the request being written to the first byte of
+// the response) and read_body (from the response headers to the caller
+// finishing with the body). Phases the transport never reached are left out.
+//
+// As with WithTransferSizes, the record for a response with a body is held
+// back until that body has been read to the end or closed.
+func WithPhaseTimings() Option {
+	return func(st *SlogTripper) {
+		st.phaseTimings = true
+	}
+}
+
+// CaptureConnection logs the connection a request went out on as a
+// connection group on the response: the network and address actually
+// dialed, such as a unix socket's path or whatever a custom dialer connected
+// to, the local address, whether it was reused from the pool and, if it had
+// been idle there, for how long.
+func CaptureConnection() Option {
+	return func(st *SlogTripper) {
+		st.captureConnection = true
+	}
+}
+
+// CaptureProtocol logs the protocol a response came back over as a protocol
+// group: the response's proto, the ALPN result on TLS connections and, for
+// HTTP/2, whether the request was coalesced onto an existing connection
+// rather than opening its own. HTTP/3 shows up through transports that
+// report it in the response.
+func CaptureProtocol() Option {
+	return func(st *SlogTripper) {
+		st.captureProtocol = true
+	}
+}
+
+// CaptureInformational logs the 1xx responses received ahead of the final
+// one, such as 100 Continue or 103 Early Hints, as an informational list on
+// the response, each with its status code and any Link headers.
+func CaptureInformational() Option {
+	return func(st *SlogTripper) {
+		st.captureInformational = true
+	}
+}
+
+// wantsTrace reports whether req needs a roundTripTrace. Requests sent with
+// Expect: 100-continue always get one, so the handshake can be logged.
+func (st *SlogTripper) wantsTrace(req *http.Request) bool {
+	return st.phaseTimings || st.captureConnection || st.captureProtocol ||
+		st.captureInformational || st.captureProxy || st.captureDNS || expectsContinue(req)
+}
+
+func expectsContinue(req *http.Request) bool {
+	return req != nil && strings.EqualFold(req.Header.Get("Expect"), "100-continue")
+}
+
+// roundTripTrace collects the httptrace events of a single round trip.
+type roundTripTrace struct {
+	start time.Time
+
+	mu           sync.Mutex
+	gotConn      time.Time
+	conn         httptrace.GotConnInfo
+	wroteRequest time.Time
+	firstByte    time.Time
+	interim      []map[string]any
+	waitContinue time.Time
+	gotContinue  time.Time
+
+	dnsHost  string
+	dnsStart time.Time
+	dnsDone  time.Time
+	dnsInfo  httptrace.DNSDoneInfo
+	connects []connectAttempt
+}
+
+func newRoundTripTrace(start time.Time) *roundTripTrace {
+	return &roundTripTrace{start: start}
+}
+
+func (t *roundTripTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			if t.dnsStart.IsZero() {
+				t.dnsHost, t.dnsStart = info.Host, time.Now()
+			}
+			t.mu.Unlock()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			if t.dnsDone.IsZero() {
+				t.dnsDone, t.dnsInfo = time.Now(), info
+			}
+			t.mu.Unlock()
+		},
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			t.connects = append(t.connects, connectAttempt{addr: addr})
+			t.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			for i := range t.connects {
+				if c := &t.connects[i]; c.addr == addr && !c.done {
+					c.done, c.err = true, err
+					break
+				}
+			}
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			if t.gotConn.IsZero() {
+				t.gotConn, t.conn = time.Now(), info
+			}
+			t.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.set(&t.wroteRequest)
+		},
+		GotFirstResponseByte: func() {
+			t.set(&t.firstByte)
+		},
+		Wait100Continue: func() {
+			t.set(&t.waitContinue)
+		},
+		Got100Continue: func() {
+			t.set(&t.gotContinue)
+		},
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			response := map[string]any{"status_code": code}
+			if links := header.Values("Link"); len(links) != 0 {
+				response["link"] = links
+			}
+
+			t.mu.Lock()
+			t.interim = append(t.interim, response)
+			t.mu.Unlock()
+
+			return nil
+		},
+	}
+}
+
+// set records the time of an event, keeping the first if it repeats.
+func (t *roundTripTrace) set(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if at.IsZero() {
+		*at = time.Now()
+	}
+}
+
+// phases returns the durations of the phases seen so far.
+func (t *roundTripTrace) phases() []any {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var attrs []any
+
+	from := t.start
+	for _, phase := range []struct {
+		key string
+		at  time.Time
+	}{
+		{"connect", t.gotConn},
+		{"write_request", t.wroteRequest},
+		{"time_to_first_byte", t.firstByte},
+	} {
+		if phase.at.IsZero() {
+			break
+		}
+
+		attrs = append(attrs, slog.Duration(phase.key, phase.at.Sub(from)))
+		from = phase.at
+	}
+
+	return attrs
+}
+
+// connection returns the connection group, if a connection was got.
+func (t *roundTripTrace) connection() (slog.Attr, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.gotConn.IsZero() || t.conn.Conn == nil {
+		return slog.Attr{}, false
+	}
+
+	// The remote address is what was actually dialed, which for a unix socket
+	// or a custom dialer has nothing to do with the URL's host
+	remote := t.conn.Conn.RemoteAddr()
+
+	attrs := make([]any, 0, 5)
+	if remote != nil {
+		attrs = append(attrs,
+			slog.String("network", remote.Network()),
+			slog.String("remote_addr", remote.String()),
+		)
+	}
+
+	if local := t.conn.Conn.LocalAddr(); local != nil && local.String() != "" {
+		attrs = append(attrs, slog.String("local_addr", local.String()))
+	}
+
+	attrs = append(attrs, slog.Bool("reused", t.conn.Reused))
+
+	if t.conn.WasIdle {
+		attrs = append(attrs, slog.Duration("idle_time", t.conn.IdleTime))
+	}
+
+	return slog.Group("connection", attrs...), true
+}
+
+// connected reports whether the transport got a connection.
+func (t *roundTripTrace) connected() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return !t.gotConn.IsZero()
+}
+
+// protocol returns the protocol group for res.
+func (t *roundTripTrace) protocol(res *http.Response) slog.Attr {
+	attrs := []any{slog.String("proto", res.Proto)}
+
+	if res.TLS != nil {
+		attrs = append(attrs, slog.String("alpn", res.TLS.NegotiatedProtocol))
+	}
+
+	if res.ProtoMajor == 2 {
+		t.mu.Lock()
+		reused := t.conn.Reused
+		t.mu.Unlock()
+
+		attrs = append(attrs, slog.Bool("coalesced", reused))
+	}
+
+	return slog.Group("protocol", attrs...)
+}
+
+// informational returns the 1xx responses received, if any.
+func (t *roundTripTrace) informational() (slog.Attr, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.interim) == 0 {
+		return slog.Attr{}, false
+	}
+
+	return slog.Any("informational", t.interim), true
+}
+
+// expectContinue returns the expect_continue group, for a request sent with
+// Expect: 100-continue: whether the server said to continue, how long the
+// transport waited for it and whether any of the body went out.
+func (t *roundTripTrace) expectContinue(sent *countingReader) slog.Attr {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	attrs := []any{slog.Bool("continue_received", !t.gotContinue.IsZero())}
+
+	if !t.waitContinue.IsZero() && !t.gotContinue.IsZero() {
+		attrs = append(attrs, slog.Duration("wait", t.gotContinue.Sub(t.waitContinue)))
+	}
+
+	attrs = append(attrs, slog.Bool("body_sent", sent != nil && sent.n.Load() > 0))
+
+	return slog.Group("expect_continue", attrs...)
+}
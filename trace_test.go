@@ -0,0 +1,301 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithPhaseTimings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, "hello")
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+
+	client := &http.Client{Transport: NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(server.Client().Transport),
+		WithPhaseTimings(),
+	)}
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Error in request: %v", err)
+	}
+
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	var record struct {
+		Response struct {
+			Phases map[string]time.Duration `json:"phases"`
+		} `json:"response"`
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("Error decoding record: %v: %s", err, output.String())
+	}
+
+	for _, phase := range []string{"connect", "write_request", "time_to_first_byte", "read_body"} {
+		if _, ok := record.Response.Phases[phase]; !ok {
+			t.Errorf("Missing phase %s: %s", phase, output.String())
+		}
+	}
+
+	if ttfb := record.Response.Phases["time_to_first_byte"]; ttfb < 10*time.Millisecond {
+		t.Errorf("Time to first byte should cover the handler, got %s", ttfb)
+	}
+}
+
+func TestCaptureConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+
+	client := &http.Client{Transport: NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(server.Client().Transport),
+		CaptureConnection(),
+	)}
+
+	for i, reused := range []bool{false, true} {
+		output.Reset()
+
+		res, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Error in request: %v", err)
+		}
+
+		// Finishing with the body puts the connection back in the pool
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+
+		var record struct {
+			Response struct {
+				Connection struct {
+					Network    string `json:"network"`
+					LocalAddr  string `json:"local_addr"`
+					RemoteAddr string `json:"remote_addr"`
+					Reused     bool   `json:"reused"`
+				} `json:"connection"`
+			} `json:"response"`
+		}
+
+		if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+			t.Fatalf("Error decoding record: %v: %s", err, output.String())
+		}
+
+		conn := record.Response.Connection
+		if conn.Network != "tcp" || conn.RemoteAddr != server.Listener.Addr().String() || conn.LocalAddr == "" {
+			t.Errorf("Request %d: unexpected addresses: %+v", i, conn)
+		}
+
+		if conn.Reused != reused {
+			t.Errorf("Request %d: expected reused %v: %s", i, reused, output.String())
+		}
+	}
+}
+
+func TestCaptureConnectionUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return new(net.Dialer).DialContext(ctx, "unix", socket)
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(transport),
+		CaptureConnection(),
+	)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://docker/v1.43/containers/json", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	var record struct {
+		Response struct {
+			Connection struct {
+				Network    string `json:"network"`
+				RemoteAddr string `json:"remote_addr"`
+			} `json:"connection"`
+		} `json:"response"`
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if conn := record.Response.Connection; conn.Network != "unix" || conn.RemoteAddr != socket {
+		t.Errorf("Expected the socket dialed logged, got %+v", conn)
+	}
+}
+
+func TestCaptureProtocol(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	var output bytes.Buffer
+
+	client := &http.Client{Transport: NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(server.Client().Transport),
+		CaptureProtocol(),
+	)}
+
+	for i, coalesced := range []bool{false, true} {
+		output.Reset()
+
+		res, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Error in request: %v", err)
+		}
+
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+
+		var record struct {
+			Response struct {
+				Protocol struct {
+					Proto     string `json:"proto"`
+					ALPN      string `json:"alpn"`
+					Coalesced bool   `json:"coalesced"`
+				} `json:"protocol"`
+			} `json:"response"`
+		}
+
+		if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+			t.Fatalf("Error decoding record: %v: %s", err, output.String())
+		}
+
+		got := record.Response.Protocol
+		if got.Proto != "HTTP/2.0" || got.ALPN != "h2" || got.Coalesced != coalesced {
+			t.Errorf("Request %d: unexpected protocol: %s", i, output.String())
+		}
+	}
+}
+
+func TestCaptureInformational(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+
+		w.Header().Del("Link")
+		fmt.Fprint(w, "hello")
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+
+	client := &http.Client{Transport: NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(server.Client().Transport),
+		CaptureInformational(),
+	)}
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Error in request: %v", err)
+	}
+	res.Body.Close()
+
+	if !strings.Contains(output.String(), `"informational":[{"link":["</style.css>; rel=preload; as=style"],"status_code":103}]`) {
+		t.Errorf("Expected the early hints logged: %s", output.String())
+	}
+}
+
+func TestExpectContinue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/reject" {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	transport := server.Client().Transport.(*http.Transport)
+	transport.ExpectContinueTimeout = 5 * time.Second
+
+	var output bytes.Buffer
+
+	client := &http.Client{Transport: NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(transport),
+	)}
+
+	tests := map[string]bool{
+		"/accept": true,
+		"/reject": false,
+	}
+
+	for path, accepted := range tests {
+		output.Reset()
+
+		req := Must(http.NewRequest(http.MethodPut, server.URL+path, strings.NewReader(strings.Repeat("x", 1024))))
+		req.Header.Set("Expect", "100-continue")
+
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s: error in request: %v", path, err)
+		}
+		res.Body.Close()
+
+		var record struct {
+			Request struct {
+				ExpectContinue struct {
+					ContinueReceived bool           `json:"continue_received"`
+					Wait             *time.Duration `json:"wait"`
+					BodySent         bool           `json:"body_sent"`
+				} `json:"expect_continue"`
+			} `json:"request"`
+		}
+
+		if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+			t.Fatalf("%s: error decoding record: %v: %s", path, err, output.String())
+		}
+
+		got := record.Request.ExpectContinue
+		if got.ContinueReceived != accepted || got.BodySent != accepted || (got.Wait != nil) != accepted {
+			t.Errorf("%s: unexpected handshake: %s", path, output.String())
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package slogtripper
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strings"
+)
+
+// TraceContext is a W3C Trace Context, the trace a request belongs to and
+// the span it was sent from, as carried by the traceparent and tracestate
+// headers.
+type TraceContext struct {
+	// TraceID is the trace's 32 lower case hex digits.
+	TraceID string
+	// SpanID is the 16 lower case hex digits of the span in the trace the
+	// request was sent from, traceparent's parent-id.
+	SpanID string
+	// Sampled is traceparent's sampled flag.
+	Sampled bool
+	// TraceState is the tracestate header, passed on as is.
+	TraceState string
+}
+
+// ParseTraceParent parses the values of the traceparent and tracestate
+// headers, reporting whether traceparent is valid.
+func ParseTraceParent(traceparent, tracestate string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || parts[0] == "ff" || !isHex(parts[0], 2) || parts[0] == "00" && len(parts) != 4 {
+		return TraceContext{}, false
+	}
+
+	traceID, spanID, flags := parts[1], parts[2], parts[3]
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) ||
+		strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return TraceContext{}, false
+	}
+
+	b, _ := hex.DecodeString(flags)
+
+	return TraceContext{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		Sampled:    b[0]&1 == 1,
+		TraceState: tracestate,
+	}, true
+}
+
+// isHex reports whether s is n lower case hex digits.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// TraceParent returns tc as a traceparent header value.
+func (tc TraceContext) TraceParent() string {
+	flags := "00"
+	if tc.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + tc.TraceID + "-" + tc.SpanID + "-" + flags
+}
+
+type traceContextKey struct{}
+
+// ContextWithTraceContext returns a context carrying tc, which requests made
+// with it continue when trace context is propagated.
+func ContextWithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFromContext returns the TraceContext in ctx, if there is one.
+// Middleware puts the trace context of requests it handles there when trace
+// context is propagated.
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// PropagateTraceContext sends a traceparent header, and tracestate if there
+// is one, with requests which don't already have one, for log correlation
+// across services without running an OpenTelemetry SDK. Requests continue
+// the trace in their context, from ContextWithTraceContext or Middleware,
+// or start a new unsampled one, getting a span ID of their own. The trace_id
+// and span_id of a request's traceparent are logged at the top level,
+// outside any WithGroupName group.
+//
+// Middleware reads the traceparent of the requests it handles into their
+// context, so the calls a handler makes continue its trace.
+func PropagateTraceContext() Option {
+	return func(st *SlogTripper) {
+		st.traceContext = true
+	}
+}
+
+// needsTraceParent reports whether a traceparent should be added to req.
+func (st *SlogTripper) needsTraceParent(req *http.Request) bool {
+	if !st.traceContext {
+		return false
+	}
+
+	_, ok := req.Header["Traceparent"]
+
+	return !ok
+}
+
+// childTraceContext returns the trace context for a request made with ctx,
+// continuing the trace in ctx if there is one, with a new span.
+func childTraceContext(ctx context.Context) TraceContext {
+	tc, ok := TraceContextFromContext(ctx)
+	if !ok {
+		tc = TraceContext{TraceID: randomHex(16)}
+	}
+
+	tc.SpanID = randomHex(8)
+
+	return tc
+}
+
+// randomHex returns n random bytes as hex, never all zeroes. n is a
+// multiple of 8.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	for {
+		for i := 0; i < n; i += 8 {
+			binary.BigEndian.PutUint64(b[i:], rand.Uint64())
+		}
+
+		for _, c := range b {
+			if c != 0 {
+				return hex.EncodeToString(b)
+			}
+		}
+	}
+}
+
+// traceAttrs returns the top level trace_id and span_id of req's traceparent.
+func (st *SlogTripper) traceAttrs(req *http.Request) []any {
+	if (!st.traceContext && st.tracer == nil) || req == nil {
+		return nil
+	}
+
+	tc, ok := ParseTraceParent(req.Header.Get("Traceparent"), "")
+	if !ok {
+		return nil
+	}
+
+	return []any{slog.String("trace_id", tc.TraceID), slog.String("span_id", tc.SpanID)}
+}
+
+// incomingTraceContext returns r with its traceparent in its context.
+func incomingTraceContext(r *http.Request) *http.Request {
+	tc, ok := ParseTraceParent(r.Header.Get("Traceparent"), r.Header.Get("Tracestate"))
+	if !ok {
+		return r
+	}
+
+	return r.WithContext(ContextWithTraceContext(r.Context(), tc))
+}
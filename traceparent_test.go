@@ -0,0 +1,161 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	for traceparent, want := range map[string]bool{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       true,
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": false,
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       false,
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01":       false,
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01":       false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01":       false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7":          false,
+		"": false,
+	} {
+		if _, ok := ParseTraceParent(traceparent, ""); ok != want {
+			t.Errorf("%q: expected valid %v", traceparent, want)
+		}
+	}
+
+	tc, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "vendor=x")
+	if !tc.Sampled || tc.TraceState != "vendor=x" || tc.TraceParent() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("Unexpected trace context: %+v", tc)
+	}
+}
+
+// tracedRoundTrip sends req through a tripper propagating trace context,
+// returning the headers sent and the record logged.
+func tracedRoundTrip(t *testing.T, req *http.Request) (http.Header, map[string]any) {
+	t.Helper()
+
+	var output bytes.Buffer
+	var sent http.Header
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				sent = r.Header
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			},
+		}),
+		WithGroupName("http"),
+		PropagateTraceContext(),
+	)
+
+	if _, err := st.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	return sent, record
+}
+
+func TestPropagateTraceContext(t *testing.T) {
+	t.Run("new trace", func(t *testing.T) {
+		sent, record := tracedRoundTrip(t, Must(http.NewRequest(http.MethodGet, "http://localhost/", nil)))
+
+		tc, ok := ParseTraceParent(sent.Get("Traceparent"), "")
+		if !ok || tc.Sampled {
+			t.Fatalf("Expected a new unsampled trace, got %q", sent.Get("Traceparent"))
+		}
+
+		if record["trace_id"] != tc.TraceID || record["span_id"] != tc.SpanID {
+			t.Errorf("Expected the trace logged at the top level: %v", record)
+		}
+	})
+
+	t.Run("continued", func(t *testing.T) {
+		parent := TraceContext{
+			TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+			SpanID:     "00f067aa0ba902b7",
+			Sampled:    true,
+			TraceState: "vendor=x",
+		}
+
+		ctx := ContextWithTraceContext(context.Background(), parent)
+		sent, record := tracedRoundTrip(t, Must(http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil)))
+
+		tc, _ := ParseTraceParent(sent.Get("Traceparent"), sent.Get("Tracestate"))
+		if tc.TraceID != parent.TraceID || tc.SpanID == parent.SpanID || !tc.Sampled || tc.TraceState != "vendor=x" {
+			t.Errorf("Expected the trace continued with a span of its own, got %+v", tc)
+		}
+
+		if record["trace_id"] != parent.TraceID {
+			t.Errorf("Expected the trace logged: %v", record)
+		}
+	})
+
+	t.Run("already set", func(t *testing.T) {
+		const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"
+
+		req := Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))
+		req.Header.Set("Traceparent", traceparent)
+
+		sent, record := tracedRoundTrip(t, req)
+		if sent.Get("Traceparent") != traceparent || record["span_id"] != "00f067aa0ba902b7" {
+			t.Errorf("Expected the request's traceparent kept, got %q", sent.Get("Traceparent"))
+		}
+	})
+}
+
+func TestMiddlewareTraceContext(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	var output bytes.Buffer
+	var sent http.Header
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				sent = r.Header
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			},
+		}),
+		PropagateTraceContext(),
+	)
+
+	handler := st.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := Must(http.NewRequestWithContext(r.Context(), http.MethodGet, "http://upstream/", nil))
+		if _, err := st.RoundTrip(req); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if tc, _ := ParseTraceParent(sent.Get("Traceparent"), ""); tc.TraceID != traceID {
+		t.Errorf("Expected the upstream call to continue the trace, got %q", sent.Get("Traceparent"))
+	}
+
+	for _, line := range bytes.Split(bytes.TrimSpace(output.Bytes()), []byte("\n")) {
+		var record struct {
+			TraceID string `json:"trace_id"`
+		}
+
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+
+		if record.TraceID != traceID {
+			t.Errorf("Expected both records in the trace: %s", line)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package slogtripper
+
+import (
+	"encoding/hex"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithTransferSizes logs the bytes actually sent and received, counted as the
+// bodies are read rather than trusted from Content-Length, which is -1 for
+// chunked bodies. Throughputs are logged in bits per second: the upload over
+// the whole round trip, the download from the response headers arriving to
+// the end of the body.
+//
+// The record for a response with a body is held back until that body has
+// been read to the end or closed, so it must be closed as net/http requires.
+func WithTransferSizes() Option {
+	return func(st *SlogTripper) {
+		st.transferSizes = true
+	}
+}
+
+// countingReader counts the bytes read through a request body, hashing them
+// too when hash is set. The transport may read it from another goroutine.
+type countingReader struct {
+	io.ReadCloser
+	n atomic.Int64
+
+	mu   sync.Mutex
+	hash hash.Hash
+	eof  bool
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n.Add(int64(n))
+
+	if c.hash != nil {
+		c.mu.Lock()
+		c.hash.Write(p[:n])
+		c.eof = c.eof || err == io.EOF
+		c.mu.Unlock()
+	}
+
+	return n, err
+}
+
+// sum returns the hex hash of the body, once it's been read to the end.
+func (c *countingReader) sum() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hash == nil || !c.eof {
+		return "", false
+	}
+
+	return hex.EncodeToString(c.hash.Sum(nil)), true
+}
+
+// countingBody counts the bytes read through a response body, hashing them
+// too when hash is set, and calls the function passed to then once the body
+// is exhausted or closed.
+type countingBody struct {
+	io.ReadCloser
+	start time.Time
+
+	mu       sync.Mutex
+	n        int64
+	hash     hash.Hash
+	eof      bool
+	err      error
+	finished bool
+	elapsed  time.Duration
+	onDone   func()
+}
+
+func newCountingBody(body io.ReadCloser) *countingBody {
+	return &countingBody{ReadCloser: body, start: time.Now()}
+}
+
+func (c *countingBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+
+	c.mu.Lock()
+	c.n += int64(n)
+	if c.hash != nil && !c.finished {
+		c.hash.Write(p[:n])
+	}
+	c.eof = c.eof || err == io.EOF
+	if err != nil && err != io.EOF && c.err == nil && !c.finished {
+		c.err = err
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		c.finish()
+	}
+
+	return n, err
+}
+
+func (c *countingBody) Close() error {
+	err := c.ReadCloser.Close()
+	c.finish()
+
+	return err
+}
+
+func (c *countingBody) finish() {
+	c.mu.Lock()
+	if c.finished {
+		c.mu.Unlock()
+		return
+	}
+
+	c.finished = true
+	c.elapsed = time.Since(c.start)
+	f := c.onDone
+	c.mu.Unlock()
+
+	if f != nil {
+		f()
+	}
+}
+
+// then calls f once the body is finished, straight away if it already is.
+func (c *countingBody) then(f func()) {
+	c.mu.Lock()
+	if !c.finished {
+		c.onDone = f
+		c.mu.Unlock()
+
+		return
+	}
+	c.mu.Unlock()
+
+	f()
+}
+
+// result returns the bytes read, how long the body took and, if it was read
+// to the end, its hex hash.
+func (c *countingBody) result() (n int64, elapsed time.Duration, sum string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hash != nil && c.eof {
+		sum = hex.EncodeToString(c.hash.Sum(nil))
+	}
+
+	return c.n, c.elapsed, sum
+}
+
+// readErr returns the error reading the body failed with, other than EOF,
+// and whether it was read to the end.
+func (c *countingBody) readErr() (error, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err, c.eof
+}
+
+// throughput returns n bytes over d in bits per second.
+func throughput(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+
+	return float64(n) * 8 / d.Seconds()
+}
+
+// hasBody reports whether body holds anything to count.
+func hasBody(body io.ReadCloser) bool {
+	return body != nil && body != http.NoBody
+}
+
+// bodyDoneAttrs returns the response attributes only known once the caller
+// has finished with its body, which is nil for a response without one.
+func (st *SlogTripper) bodyDoneAttrs(res *http.Response, trace *roundTripTrace, body *countingBody) []slog.Attr {
+	var attrs []slog.Attr
+
+	var n int64
+	var sum string
+	d := time.Duration(-1)
+	if body != nil {
+		n, d, sum = body.result()
+	}
+
+	if st.transferSizes && res != nil {
+		attrs = append(attrs, slog.Int64("bytes_received", n))
+		if d >= 0 {
+			attrs = append(attrs, slog.Float64("download_throughput_bps", throughput(n, d)))
+		}
+	}
+
+	if sum != "" {
+		attrs = append(attrs, slog.String("body_sha256", sum))
+	}
+
+	if st.checkContentLength && body != nil && res != nil {
+		attrs = append(attrs, st.contentLengthAttrs(res, body)...)
+	}
+
+	if res != nil {
+		if attr, ok := st.trailersAttr(res.Trailer); ok {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	if st.phaseTimings && trace != nil {
+		phases := trace.phases()
+		if d >= 0 {
+			phases = append(phases, slog.Duration("read_body", d))
+		}
+
+		if len(phases) != 0 {
+			attrs = append(attrs, slog.Group("phases", phases...))
+		}
+	}
+
+	return attrs
+}
@@ -0,0 +1,100 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithTransferSizes(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				io.Copy(io.Discard, r.Body)
+
+				// A chunked response, with no Content-Length to go on
+				return &http.Response{
+					StatusCode:    http.StatusOK,
+					ContentLength: -1,
+					Body:          io.NopCloser(strings.NewReader("0123456789")),
+				}, nil
+			},
+		}),
+		WithTransferSizes(),
+	)
+
+	req := Must(http.NewRequest(http.MethodPost, "http://localhost", io.NopCloser(strings.NewReader("hello"))))
+
+	res, err := st.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if output.Len() != 0 {
+		t.Fatalf("Record written before the body was read: %s", output.String())
+	}
+
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	var record struct {
+		Request struct {
+			BytesSent  int64   `json:"bytes_sent"`
+			Throughput float64 `json:"upload_throughput_bps"`
+		} `json:"request"`
+		Response struct {
+			BytesReceived int64   `json:"bytes_received"`
+			Throughput    float64 `json:"download_throughput_bps"`
+		} `json:"response"`
+	}
+
+	if n := strings.Count(output.String(), "\n"); n != 1 {
+		t.Fatalf("Expected one record, got %d: %s", n, output.String())
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("Error decoding record: %v", err)
+	}
+
+	if record.Request.BytesSent != 5 || record.Response.BytesReceived != 10 {
+		t.Errorf("Unexpected sizes: %+v", record)
+	}
+
+	if record.Request.Throughput <= 0 || record.Response.Throughput <= 0 {
+		t.Errorf("Unexpected throughputs: %+v", record)
+	}
+}
+
+func TestWithTransferSizesCapturedBody(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("0123456789")),
+				}, nil
+			},
+		}),
+		WithTransferSizes(),
+		CaptureResponseBody(),
+	)
+
+	// The capture has already read the body, so the record can't wait on it
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost", nil))); err != nil {
+		t.Fatalf("Error in roundtrip: %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"bytes_received":10`) {
+		t.Errorf("Expected the received size logged: %s", output.String())
+	}
+}
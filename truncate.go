@@ -0,0 +1,90 @@
+package slogtripper
+
+import (
+	"log/slog"
+	"unicode/utf8"
+)
+
+// truncatedMarker ends a value cut short by WithMaxAttrValueLength.
+const truncatedMarker = "…[truncated]"
+
+// WithMaxAttrValueLength cuts every string the tripper logs, including
+// headers, URLs, bodies and errors, to at most n bytes, ending those it cuts
+// with "…[truncated]". It stops a single pathological value producing a
+// log line of megabytes. 0, the default, leaves values whole.
+func WithMaxAttrValueLength(n int) Option {
+	return func(st *SlogTripper) {
+		if n < 0 {
+			st.invalid("negative max attr value length %d", n)
+			return
+		}
+
+		st.maxAttrValueLength = n
+	}
+}
+
+// truncateArgs applies the tripper's value length cap to the attributes in
+// args.
+func (st *SlogTripper) truncateArgs(args []any) []any {
+	if st.maxAttrValueLength == 0 {
+		return args
+	}
+
+	truncated := make([]any, len(args))
+	for i, arg := range args {
+		if attr, ok := arg.(slog.Attr); ok {
+			arg = slog.Attr{Key: attr.Key, Value: st.truncateValue(attr.Value)}
+		}
+
+		truncated[i] = arg
+	}
+
+	return truncated
+}
+
+func (st *SlogTripper) truncateValue(v slog.Value) slog.Value {
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := v.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, attr := range group {
+			attrs[i] = slog.Attr{Key: attr.Key, Value: st.truncateValue(attr.Value)}
+		}
+
+		return slog.GroupValue(attrs...)
+	case slog.KindLogValuer:
+		// Left lazy, for a body the handler may never resolve
+		return slog.AnyValue(truncatedValuer{st: st, v: v})
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.StringValue(st.truncate(err.Error()))
+		}
+	}
+
+	return mapStrings(v, st.truncate)
+}
+
+// truncatedValuer truncates the value of a LogValuer when it's resolved.
+type truncatedValuer struct {
+	st *SlogTripper
+	v  slog.Value
+}
+
+func (t truncatedValuer) LogValue() slog.Value {
+	return t.st.truncateValue(t.v.Resolve())
+}
+
+// truncate cuts s to the tripper's max value length, on a character
+// boundary.
+func (st *SlogTripper) truncate(s string) string {
+	n := st.maxAttrValueLength
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n] + truncatedMarker
+}
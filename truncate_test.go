@@ -0,0 +1,44 @@
+package slogtripper
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithMaxAttrValueLength(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return nil, errors.New("dial tcp: " + strings.Repeat("x", 100))
+			},
+		}),
+		CaptureRequestBody(),
+		CaptureRequestHeaders(),
+		DecodeBodies(),
+		WithMaxAttrValueLength(10),
+	)
+
+	req := Must(http.NewRequest(http.MethodPost, "http://localhost/a/very/long/path", strings.NewReader(`{"name": "ééééééé"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Short", "short")
+
+	_, _ = st.RoundTrip(req)
+
+	for _, expected := range []string{
+		`"url":"http://loc…[truncated]"`,
+		`"body_content":{"name":"ééééé…[truncated]"}`,
+		`"X-Short":"short"`,
+		`"message":"dial tcp: …[truncated]"`,
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected %s: %s", expected, output.String())
+		}
+	}
+}
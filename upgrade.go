@@ -0,0 +1,249 @@
+package slogtripper
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UpgradeHook is called with the connection a 101 Switching Protocols
+// response hands over as its body, returning the connection the caller gets
+// in its place. net/http's transport hands over an io.ReadWriteCloser rather
+// than the net.Conn itself, so that's what's wrapped.
+type UpgradeHook func(req *http.Request, res *http.Response, conn io.ReadWriteCloser) io.ReadWriteCloser
+
+// WithUpgradeHook calls f with the connection of every response switching
+// protocols, such as a WebSocket handshake, so what's sent over it afterwards
+// can be watched. Hooks run even when logging is disabled, in the order they
+// were added, each wrapping the connection returned by the one before.
+func WithUpgradeHook(f UpgradeHook) Option {
+	return func(st *SlogTripper) {
+		if f == nil {
+			st.invalid("nil upgrade hook")
+			return
+		}
+
+		st.upgradeHooks = append(st.upgradeHooks[:len(st.upgradeHooks):len(st.upgradeHooks)], f)
+	}
+}
+
+// LogUpgradedConn logs an "HTTP Upgraded Connection Closed" record when the
+// connection of a response switching protocols is closed, giving how long it
+// was open and the bytes read and written over it, and for a WebSocket the
+// frames too.
+func LogUpgradedConn() Option {
+	return func(st *SlogTripper) {
+		WithUpgradeHook(st.newUpgradedConn)(st)
+	}
+}
+
+// switchedProtocols reports whether res hands over its connection, in which
+// case its body is the connection and mustn't be read or wrapped as a body,
+// which would hide its Write method.
+func switchedProtocols(res *http.Response) bool {
+	return res.StatusCode == http.StatusSwitchingProtocols
+}
+
+// wrappableBody reports whether res has a body which can be wrapped.
+func wrappableBody(res *http.Response) bool {
+	return res != nil && hasBody(res.Body) && !switchedProtocols(res)
+}
+
+// upgradeAttr describes the protocol res switched to.
+func upgradeAttr(res *http.Response) slog.Attr {
+	attrs := []any{slog.String("protocol", res.Header.Get("Upgrade"))}
+
+	if v := res.Header.Get("Sec-WebSocket-Protocol"); v != "" {
+		attrs = append(attrs, slog.String("subprotocol", v))
+	}
+
+	if v := res.Header.Values("Sec-WebSocket-Extensions"); len(v) != 0 {
+		attrs = append(attrs, slog.String("extensions", strings.Join(v, ", ")))
+	}
+
+	return slog.Group("upgrade", attrs...)
+}
+
+// sendWithUpgradeHooks sends req through send, handing the connection of a
+// response switching protocols to the upgrade hooks.
+func (st *SlogTripper) sendWithUpgradeHooks(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	res, err := send(req)
+	if err != nil || res == nil || !switchedProtocols(res) {
+		return res, err
+	}
+
+	conn, ok := res.Body.(io.ReadWriteCloser)
+	if !ok {
+		return res, nil
+	}
+
+	for _, f := range st.upgradeHooks {
+		conn = f(req, res, conn)
+	}
+
+	res.Body = conn
+
+	return res, nil
+}
+
+// upgradedConn counts what's sent over a connection handed over by a
+// response switching protocols, logging it once closed.
+type upgradedConn struct {
+	io.ReadWriteCloser
+	st       *SlogTripper
+	ctx      context.Context
+	id       string
+	url      string
+	protocol string
+	opened   time.Time
+
+	mu      sync.Mutex
+	read    int64
+	written int64
+	in      *frameCounter
+	out     *frameCounter
+	once    sync.Once
+}
+
+func (st *SlogTripper) newUpgradedConn(req *http.Request, res *http.Response, conn io.ReadWriteCloser) io.ReadWriteCloser {
+	c := &upgradedConn{
+		ReadWriteCloser: conn,
+		st:              st,
+		ctx:             context.WithoutCancel(req.Context()),
+		url:             req.URL.String(),
+		protocol:        res.Header.Get("Upgrade"),
+		opened:          time.Now(),
+	}
+	c.id, _ = RequestIDFromContext(req.Context())
+
+	if strings.EqualFold(c.protocol, "websocket") {
+		c.in, c.out = new(frameCounter), new(frameCounter)
+	}
+
+	return c
+}
+
+func (c *upgradedConn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+
+	c.mu.Lock()
+	c.read += int64(n)
+	if c.in != nil {
+		c.in.feed(p[:n])
+	}
+	c.mu.Unlock()
+
+	return n, err
+}
+
+func (c *upgradedConn) Write(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Write(p)
+
+	c.mu.Lock()
+	c.written += int64(n)
+	if c.out != nil {
+		c.out.feed(p[:n])
+	}
+	c.mu.Unlock()
+
+	return n, err
+}
+
+func (c *upgradedConn) Close() error {
+	err := c.ReadWriteCloser.Close()
+	c.once.Do(c.report)
+
+	return err
+}
+
+func (c *upgradedConn) report() {
+	if c.st.disabled.Load() {
+		return
+	}
+
+	c.mu.Lock()
+	attrs := []any{
+		slog.String("protocol", c.protocol),
+		slog.Duration("open_for", time.Since(c.opened)),
+		slog.Int64("bytes_read", c.read),
+		slog.Int64("bytes_written", c.written),
+	}
+
+	if c.in != nil {
+		attrs = append(attrs, slog.Int64("frames_read", c.in.frames), slog.Int64("frames_written", c.out.frames))
+	}
+	c.mu.Unlock()
+
+	c.st.log(c.ctx, "HTTP Upgraded Connection Closed",
+		slog.Group(c.st.requestKey, slog.String("id", c.id), slog.String("url", c.url)),
+		slog.Group("connection", attrs...),
+	)
+}
+
+// frameCounter counts the WebSocket frames in a stream fed to it in pieces,
+// skipping over their payloads by the lengths in their headers.
+type frameCounter struct {
+	frames int64
+
+	header    [14]byte
+	headerLen int
+	remaining uint64
+}
+
+func (f *frameCounter) feed(p []byte) {
+	for len(p) > 0 {
+		if f.remaining > 0 {
+			k := min(uint64(len(p)), f.remaining)
+			f.remaining -= k
+			p = p[k:]
+
+			continue
+		}
+
+		f.header[f.headerLen] = p[0]
+		f.headerLen++
+		p = p[1:]
+
+		if f.headerLen < 2 || f.headerLen < f.headerSize() {
+			continue
+		}
+
+		f.frames++
+		f.remaining = f.payloadLen()
+		f.headerLen = 0
+	}
+}
+
+// headerSize returns the size of the frame header being read, once its first
+// two bytes are in.
+func (f *frameCounter) headerSize() int {
+	size := 2
+	switch f.header[1] & 0x7f {
+	case 126:
+		size += 2
+	case 127:
+		size += 8
+	}
+
+	if f.header[1]&0x80 != 0 {
+		size += 4
+	}
+
+	return size
+}
+
+func (f *frameCounter) payloadLen() uint64 {
+	switch n := f.header[1] & 0x7f; n {
+	case 126:
+		return uint64(binary.BigEndian.Uint16(f.header[2:4]))
+	case 127:
+		return binary.BigEndian.Uint64(f.header[2:10])
+	default:
+		return uint64(n)
+	}
+}
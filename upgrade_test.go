@@ -0,0 +1,217 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// fakeConn is the connection a 101 response hands over.
+type fakeConn struct {
+	io.Reader
+	written bytes.Buffer
+	closed  bool
+}
+
+func (c *fakeConn) Write(p []byte) (int, error) {
+	return c.written.Write(p)
+}
+
+func (c *fakeConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func switchingProtocols(conn io.ReadWriteCloser) *MockRoundTripper {
+	return &MockRoundTripper{
+		MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusSwitchingProtocols,
+				Header: http.Header{
+					"Upgrade":                  {"websocket"},
+					"Connection":               {"Upgrade"},
+					"Sec-Websocket-Protocol":   {"graphql-ws"},
+					"Sec-Websocket-Extensions": {"permessage-deflate", "x-webkit-deflate-frame"},
+				},
+				ContentLength: -1,
+				Body:          conn,
+			}, nil
+		},
+	}
+}
+
+func TestSwitchingProtocols(t *testing.T) {
+	var output syncBuffer
+
+	conn := &fakeConn{Reader: strings.NewReader("frames")}
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(switchingProtocols(conn)),
+		CaptureResponseBody(),
+		WithTransferSizes(),
+		DetectBodyLeaks(0),
+	)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/ws", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Body != io.ReadCloser(conn) {
+		t.Fatalf("Expected the connection handed over untouched, got %T", res.Body)
+	}
+
+	// Nothing waits on the connection being read or closed
+	lines := output.Lines()
+	if len(lines) != 1 {
+		t.Fatalf("Expected the upgrade logged, got %d records", len(lines))
+	}
+
+	var record struct {
+		Response struct {
+			StatusCode  int     `json:"status_code"`
+			BodyContent *string `json:"body_content"`
+			Upgrade     struct {
+				Protocol    string `json:"protocol"`
+				Subprotocol string `json:"subprotocol"`
+				Extensions  string `json:"extensions"`
+			} `json:"upgrade"`
+		} `json:"response"`
+	}
+
+	if err := json.Unmarshal(lines[0], &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Response.StatusCode != http.StatusSwitchingProtocols || record.Response.BodyContent != nil {
+		t.Errorf("Expected the upgrade logged without a body: %s", lines[0])
+	}
+
+	upgrade := record.Response.Upgrade
+	if upgrade.Protocol != "websocket" || upgrade.Subprotocol != "graphql-ws" || upgrade.Extensions != "permessage-deflate, x-webkit-deflate-frame" {
+		t.Errorf("Unexpected upgrade: %+v", upgrade)
+	}
+}
+
+func TestUpgradeHook(t *testing.T) {
+	conn := &fakeConn{Reader: strings.NewReader("")}
+
+	var hooked *http.Response
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(switchingProtocols(conn)),
+		WithUpgradeHook(func(req *http.Request, res *http.Response, c io.ReadWriteCloser) io.ReadWriteCloser {
+			hooked = res
+			return struct{ io.ReadWriteCloser }{c}
+		}),
+	)
+	st.SetEnabled(false)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/ws", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hooked != res {
+		t.Fatal("Expected the hook called while logging is disabled")
+	}
+
+	if _, ok := res.Body.(struct{ io.ReadWriteCloser }); !ok {
+		t.Errorf("Expected the hook's connection handed over, got %T", res.Body)
+	}
+}
+
+func TestLogUpgradedConn(t *testing.T) {
+	var output syncBuffer
+
+	// A text frame, a 200 byte binary frame and a close frame from the server
+	var server bytes.Buffer
+	server.Write([]byte{0x81, 5})
+	server.WriteString("hello")
+	server.Write([]byte{0x82, 126, 0, 200})
+	server.Write(make([]byte, 200))
+	server.Write([]byte{0x88, 0})
+
+	conn := &fakeConn{Reader: iotest.OneByteReader(&server)}
+	n := int64(server.Len())
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+		WithRoundTripper(switchingProtocols(conn)),
+		LogUpgradedConn(),
+	)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/ws", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws := res.Body.(io.ReadWriteCloser)
+	if _, err := io.ReadAll(ws); err != nil {
+		t.Fatal(err)
+	}
+
+	// A masked frame from the client
+	ws.Write([]byte{0x81, 0x80 | 2, 1, 2, 3, 4, 'h', 'i'})
+	ws.Close()
+	ws.Close()
+
+	if !conn.closed || conn.written.Len() != 8 {
+		t.Fatal("Expected the connection written to and closed")
+	}
+
+	lines := output.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("Expected the connection logged once closed, got %d records", len(lines))
+	}
+
+	var record struct {
+		Msg     string `json:"msg"`
+		Request struct {
+			ID string `json:"id"`
+		} `json:"request"`
+		Connection struct {
+			Protocol      string `json:"protocol"`
+			BytesRead     int64  `json:"bytes_read"`
+			BytesWritten  int64  `json:"bytes_written"`
+			FramesRead    int64  `json:"frames_read"`
+			FramesWritten int64  `json:"frames_written"`
+		} `json:"connection"`
+	}
+
+	if err := json.Unmarshal(lines[1], &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Msg != "HTTP Upgraded Connection Closed" || record.Request.ID == "" {
+		t.Errorf("Unexpected record: %s", lines[1])
+	}
+
+	c := record.Connection
+	if c.Protocol != "websocket" || c.BytesRead != n || c.BytesWritten != 8 || c.FramesRead != 3 || c.FramesWritten != 1 {
+		t.Errorf("Unexpected connection: %+v", c)
+	}
+}
+
+func TestFrameCounter(t *testing.T) {
+	var f frameCounter
+
+	f.feed([]byte{0x82, 127, 0, 0, 0, 0, 0, 1, 0, 0})
+	f.feed(make([]byte, 1<<16))
+	f.feed([]byte{0x89, 0, 0x8a})
+
+	if f.frames != 2 {
+		t.Errorf("Expected two whole frames, got %d", f.frames)
+	}
+
+	f.feed([]byte{0})
+	if f.frames != 3 {
+		t.Errorf("Expected the third frame once its header was in, got %d", f.frames)
+	}
+}
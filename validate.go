@@ -0,0 +1,108 @@
+package slogtripper
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// ResponseValidator checks a response against what the caller expects of it,
+// returning why it doesn't conform. Its body can be read freely.
+type ResponseValidator func(res *http.Response) error
+
+// WithResponseValidator checks every response with f, to catch upstreams
+// drifting from their contract. A response that fails is logged at Warn as
+// an "HTTP Response Invalid" record with the error, and the violations if
+// it joins several, but is returned to the caller untouched. It can be given
+// more than once.
+//
+// Bodies are read into memory to be validated, and those longer than
+// WithMaxBodySize aren't validated.
+func WithResponseValidator(f ResponseValidator) Option {
+	return func(st *SlogTripper) {
+		if f == nil {
+			st.invalid("nil response validator")
+			return
+		}
+
+		st.validators = append(st.validators[:len(st.validators):len(st.validators)], f)
+	}
+}
+
+// sendValidated sends req through send, validating the response.
+func (st *SlogTripper) sendValidated(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	res, err := send(req)
+	if err != nil || res == nil {
+		return res, err
+	}
+
+	// An event stream may never end, nor a switched connection, so neither
+	// can be read to be validated
+	if sseStream(res) || switchedProtocols(res) {
+		return res, nil
+	}
+
+	var content []byte
+	if res.Body != nil {
+		var truncated bool
+		var body io.ReadCloser
+
+		content, truncated, body, err = captureBody(res.Body, st.maxBodySize, true)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Body = body
+		if truncated {
+			return res, nil
+		}
+	}
+
+	for _, validate := range st.validators {
+		v := *res
+		v.Body = io.NopCloser(bytes.NewReader(content))
+
+		if err := validate(&v); err != nil {
+			st.logInvalid(req, res, err)
+		}
+	}
+
+	return res, nil
+}
+
+// logInvalid logs the reason res failed validation.
+func (st *SlogTripper) logInvalid(req *http.Request, res *http.Response, err error) {
+	if st.disabled.Load() {
+		return
+	}
+
+	requestGroup := []any{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+	}
+
+	if id, ok := RequestIDFromContext(req.Context()); ok {
+		requestGroup = append(requestGroup, slog.String("id", id))
+	}
+
+	args := []any{
+		slog.Group(st.requestKey, requestGroup...),
+		slog.Group(st.responseKey,
+			slog.Int("status_code", res.StatusCode),
+			slog.String("content_type", res.Header.Get("Content-Type")),
+		),
+		slog.String("error", err.Error()),
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var violations []string
+		for _, e := range joined.Unwrap() {
+			violations = append(violations, e.Error())
+		}
+
+		args = append(args, slog.Any("violations", violations))
+	}
+
+	st.logAt(req.Context(), slog.LevelWarn, "HTTP Response Invalid", args...)
+}
@@ -0,0 +1,80 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithResponseValidator(t *testing.T) {
+	var buf bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"id":"abc"}`)),
+				}, nil
+			},
+		}),
+		WithResponseValidator(func(res *http.Response) error {
+			var user struct {
+				ID   int    `json:"id"`
+				Name string `json:"name"`
+			}
+
+			var errs []error
+			if err := json.NewDecoder(res.Body).Decode(&user); err != nil {
+				errs = append(errs, err)
+			}
+
+			if user.Name == "" {
+				errs = append(errs, errors.New("missing name"))
+			}
+
+			return errors.Join(errs...)
+		}),
+	)
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/users/abc", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The caller still gets the whole body
+	if body, _ := io.ReadAll(res.Body); string(body) != `{"id":"abc"}` {
+		t.Errorf("Unexpected body %q", body)
+	}
+
+	var record struct {
+		Level   string `json:"level"`
+		Msg     string `json:"msg"`
+		Request struct {
+			URL string `json:"url"`
+		} `json:"request"`
+		Response struct {
+			StatusCode int `json:"status_code"`
+		} `json:"response"`
+		Violations []string `json:"violations"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Level != "WARN" || record.Msg != "HTTP Response Invalid" || record.Request.URL != "http://localhost/users/abc" || record.Response.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected record: %s", buf.String())
+	}
+
+	if len(record.Violations) != 2 || record.Violations[1] != "missing name" {
+		t.Errorf("Unexpected violations: %q", record.Violations)
+	}
+}
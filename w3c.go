@@ -0,0 +1,200 @@
+package slogtripper
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultW3CFields are the fields WithW3CLog writes when given none.
+var DefaultW3CFields = []string{"date", "time", "cs-method", "cs-uri-stem", "cs-uri-query", "sc-status", "sc-bytes", "time-taken"}
+
+// WithW3CLog writes a line for every round trip to w in the W3C Extended Log
+// File Format, alongside the slog records, for analyzers which only read
+// those. The #Version, #Date and #Fields directives come first. fields
+// chooses the columns, from date, time, time-taken, c-ip, cs-method, cs-uri,
+// cs-uri-stem, cs-uri-query, cs-host, cs-bytes, sc-status, sc-bytes and
+// cs(Header) or sc(Header) for any header, defaulting to DefaultW3CFields.
+// Header values are redacted and masked as in the records. Like hooks, lines
+// are written even when logging is disabled.
+func WithW3CLog(w io.Writer, fields ...string) Option {
+	return func(st *SlogTripper) {
+		if w == nil {
+			st.invalid("nil W3C log writer")
+			return
+		}
+
+		if len(fields) == 0 {
+			fields = DefaultW3CFields
+		}
+
+		for _, field := range fields {
+			if !isW3CField(field) {
+				st.invalid("unknown W3C log field %q", field)
+				return
+			}
+		}
+
+		l := &w3cLog{st: st, w: w, fields: fields}
+		OnResponse(l.write)(st)
+	}
+}
+
+type w3cLog struct {
+	st *SlogTripper
+
+	mu      sync.Mutex
+	w       io.Writer
+	fields  []string
+	started bool
+}
+
+func isW3CField(field string) bool {
+	switch field {
+	case "date", "time", "time-taken", "c-ip", "cs-method", "cs-uri", "cs-uri-stem",
+		"cs-uri-query", "cs-host", "cs-bytes", "sc-status", "sc-bytes":
+		return true
+	}
+
+	_, ok := w3cHeader(field)
+
+	return ok
+}
+
+// w3cHeader returns the header named by a cs(Header) or sc(Header) field.
+func w3cHeader(field string) (string, bool) {
+	if !strings.HasPrefix(field, "cs(") && !strings.HasPrefix(field, "sc(") || !strings.HasSuffix(field, ")") {
+		return "", false
+	}
+
+	name := field[3 : len(field)-1]
+
+	return name, name != ""
+}
+
+func (l *w3cLog) write(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	now := time.Now().UTC()
+
+	values := make([]string, len(l.fields))
+	for i, field := range l.fields {
+		values[i] = w3cValue(l.field(field, now, req, res, elapsed))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.started {
+		fmt.Fprintf(l.w, "#Version: 1.0\n#Date: %s\n#Fields: %s\n", now.Format("2006-01-02 15:04:05"), strings.Join(l.fields, " "))
+		l.started = true
+	}
+
+	io.WriteString(l.w, strings.Join(values, " ")+"\n")
+}
+
+func (l *w3cLog) field(field string, now time.Time, req *http.Request, res *http.Response, elapsed time.Duration) string {
+	switch field {
+	case "date":
+		return now.Format("2006-01-02")
+	case "time":
+		return now.Format("15:04:05")
+	case "time-taken":
+		return strconv.FormatFloat(elapsed.Seconds(), 'f', 3, 64)
+	}
+
+	if name, ok := w3cHeader(field); ok {
+		var value string
+		switch {
+		case field[0] == 'c' && req != nil:
+			value = req.Header.Get(name)
+		case field[0] == 's' && res != nil:
+			value = res.Header.Get(name)
+		}
+
+		if value == "" {
+			return ""
+		}
+
+		return l.st.headerValue(name, value)
+	}
+
+	if strings.HasPrefix(field, "sc-") {
+		if res == nil {
+			return ""
+		}
+
+		switch field {
+		case "sc-status":
+			return strconv.Itoa(res.StatusCode)
+		case "sc-bytes":
+			if res.ContentLength >= 0 {
+				return strconv.FormatInt(res.ContentLength, 10)
+			}
+		}
+
+		return ""
+	}
+
+	if req == nil {
+		return ""
+	}
+
+	switch field {
+	case "c-ip":
+		if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+			return host
+		}
+	case "cs-method":
+		return req.Method
+	case "cs-host":
+		if req.Host != "" {
+			return req.Host
+		}
+
+		if req.URL != nil {
+			return req.URL.Host
+		}
+	case "cs-bytes":
+		if req.ContentLength >= 0 {
+			return strconv.FormatInt(req.ContentLength, 10)
+		}
+	}
+
+	if req.URL == nil {
+		return ""
+	}
+
+	switch field {
+	case "cs-uri":
+		return req.URL.String()
+	case "cs-uri-stem":
+		return req.URL.Path
+	case "cs-uri-query":
+		return req.URL.RawQuery
+	}
+
+	return ""
+}
+
+// w3cValue makes v safe for a space separated line, with "-" for no value
+// and "+" for spaces, as IIS writes them.
+func w3cValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '+'
+		case r < ' ' || r == 0x7f:
+			return -1
+		}
+
+		return r
+	}, v)
+}
@@ -0,0 +1,93 @@
+package slogtripper
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestWithW3CLog(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path == "/down" {
+					return nil, errors.New("connection refused")
+				}
+
+				return &http.Response{StatusCode: http.StatusOK, ContentLength: 42}, nil
+			},
+		}),
+		WithW3CLog(&output, "date", "time", "cs-method", "cs-uri-stem", "cs-uri-query", "sc-status", "sc-bytes", "cs(User-Agent)"),
+	)
+
+	for _, path := range []string{"/search?q=go", "/down"} {
+		req := Must(http.NewRequest(http.MethodGet, "http://localhost"+path, nil))
+		req.Header.Set("User-Agent", "Go Client")
+
+		_, _ = st.RoundTrip(req)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 3 directives and 2 lines: %s", output.String())
+	}
+
+	if lines[0] != "#Version: 1.0" || lines[2] != "#Fields: date time cs-method cs-uri-stem cs-uri-query sc-status sc-bytes cs(User-Agent)" {
+		t.Errorf("Unexpected directives: %s", output.String())
+	}
+
+	for i, expected := range []string{
+		` GET /search q=go 200 42 Go\+Client$`,
+		` GET /down - - - Go\+Client$`,
+	} {
+		if !regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}` + expected).MatchString(lines[3+i]) {
+			t.Errorf("Unexpected line: %s", lines[3+i])
+		}
+	}
+}
+
+func TestWithW3CLogUnknownField(t *testing.T) {
+	if _, err := NewSlogTripperE(WithW3CLog(io.Discard, "cs-nope")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected an invalid option error, got %v", err)
+	}
+}
+
+func TestWithW3CLogRedacted(t *testing.T) {
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Set-Cookie": {"session=secret"}}}, nil
+			},
+		}),
+		WithW3CLog(&output, "cs(Authorization)", "cs(Cookie)", "sc(Set-Cookie)", "cs(X-Token)"),
+		CaptureCookies(),
+		CaptureJWTClaims(),
+		WithMaskers(GitHubTokenMasker()),
+	)
+
+	req := Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("X-Token", "ghp_"+strings.Repeat("a", 36))
+
+	_, _ = st.RoundTrip(req)
+
+	if strings.Contains(output.String(), "secret") || strings.Contains(output.String(), "ghp_") {
+		t.Errorf("Expected header values redacted and masked: %s", output.String())
+	}
+
+	if lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n"); lines[len(lines)-1] != "[REDACTED] [REDACTED] [REDACTED] [REDACTED]" {
+		t.Errorf("Unexpected line: %s", lines[len(lines)-1])
+	}
+}
@@ -0,0 +1,181 @@
+package slogtripper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// WebhookConfig configures WithWebhook.
+type WebhookConfig struct {
+	// URL is where events are POSTed
+	URL string
+	// Client posts events, defaulting to one of its own with a 10s timeout
+	Client *http.Client
+	// SampleRate is the fraction of round trips posted, from 0 to 1,
+	// defaulting to all of them
+	SampleRate float64
+	// QueueSize bounds the events waiting to be posted, defaulting to 1000
+	QueueSize int
+}
+
+// WebhookEvent is the JSON summary of a round trip WithWebhook posts.
+type WebhookEvent struct {
+	ID         string        `json:"id"`
+	StartedAt  time.Time     `json:"started_at"`
+	Method     string        `json:"method"`
+	URL        string        `json:"url"`
+	Host       string        `json:"host"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	TimeTaken  time.Duration `json:"time_taken"`
+}
+
+// WithWebhook POSTs a WebhookEvent for each logged round trip, or a sample
+// of them, to c.URL, for services collecting HTTP audits which don't read
+// logs. Round trips are still posted when the logger's level drops their
+// records. Events are posted one at a time by a background worker, and
+// dropped when its queue is full or the post fails; see DroppedWebhooks.
+// The posts themselves aren't logged, even when c.Client goes through this
+// tripper. Call Close to post what's queued and stop the worker.
+func WithWebhook(c WebhookConfig) Option {
+	return func(st *SlogTripper) {
+		if u, err := url.Parse(c.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			st.invalid("webhook URL %q isn't absolute", c.URL)
+			return
+		}
+
+		if c.SampleRate < 0 || c.SampleRate > 1 {
+			st.invalid("webhook sample rate %v outside 0 to 1", c.SampleRate)
+			return
+		}
+
+		if c.QueueSize < 0 {
+			st.invalid("negative webhook queue size %d", c.QueueSize)
+			return
+		}
+
+		if c.Client == nil {
+			c.Client = &http.Client{Timeout: 10 * time.Second}
+		}
+
+		if c.SampleRate == 0 {
+			c.SampleRate = 1
+		}
+
+		if c.QueueSize == 0 {
+			c.QueueSize = 1000
+		}
+
+		st.webhook = &webhook{
+			config: c,
+			queue:  make(chan WebhookEvent, c.QueueSize),
+			done:   make(chan struct{}),
+		}
+	}
+}
+
+type webhook struct {
+	config WebhookConfig
+	queue  chan WebhookEvent
+	done   chan struct{}
+
+	dropped atomic.Uint64
+}
+
+// add queues an event for the round trip, if it's sampled.
+func (w *webhook) add(req *http.Request, res *http.Response, err error, id string, start time.Time, elapsed time.Duration) {
+	if w.config.SampleRate < 1 && rand.Float64() >= w.config.SampleRate {
+		return
+	}
+
+	event := WebhookEvent{
+		ID:        id,
+		StartedAt: start,
+		Host:      requestHost(req),
+		TimeTaken: elapsed,
+	}
+
+	if req != nil {
+		event.Method = req.Method
+		if req.URL != nil {
+			event.URL = req.URL.String()
+		}
+	}
+
+	if res != nil {
+		event.StatusCode = res.StatusCode
+	}
+
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	select {
+	case w.queue <- event:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// run posts queued events until stop is closed, then posts what's left.
+func (w *webhook) run(stop <-chan struct{}) {
+	defer close(w.done)
+
+	for {
+		select {
+		case event := <-w.queue:
+			w.post(event)
+		case <-stop:
+			for {
+				select {
+				case event := <-w.queue:
+					w.post(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *webhook) post(event WebhookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		w.dropped.Add(1)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(Silence(context.Background()), http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		w.dropped.Add(1)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := w.config.Client.Do(req)
+	if err != nil {
+		w.dropped.Add(1)
+		return
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		w.dropped.Add(1)
+	}
+}
+
+// DroppedWebhooks returns how many events WithWebhook has dropped, because
+// its queue was full or they couldn't be posted.
+func (st *SlogTripper) DroppedWebhooks() uint64 {
+	if st.webhook == nil {
+		return 0
+	}
+
+	return st.webhook.dropped.Load()
+}
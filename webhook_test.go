@@ -0,0 +1,113 @@
+package slogtripper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWithWebhook(t *testing.T) {
+	var mu sync.Mutex
+	var events []WebhookEvent
+
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event WebhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error(err)
+		}
+
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer sink.Close()
+
+	var output bytes.Buffer
+
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Host == "localhost" {
+					return &http.Response{StatusCode: http.StatusTeapot, Body: http.NoBody}, nil
+				}
+
+				return sink.Client().Transport.RoundTrip(r)
+			},
+		}),
+	)
+
+	// Posted through the tripper being watched, which mustn't post about it
+	st = st.With(WithWebhook(WebhookConfig{URL: sink.URL, Client: &http.Client{Transport: st}}))
+
+	res, err := st.RoundTrip(Must(http.NewRequest(http.MethodDelete, "http://localhost/things/1", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected one event, got %d", len(events))
+	}
+
+	if e := events[0]; e.ID == "" || e.Method != http.MethodDelete || e.URL != "http://localhost/things/1" ||
+		e.Host != "localhost" || e.StatusCode != http.StatusTeapot {
+		t.Errorf("Unexpected event %+v", e)
+	}
+
+	if output.Len() != 0 {
+		t.Errorf("Expected nothing logged at the logger's level: %s", output.Bytes())
+	}
+
+	if st.DroppedWebhooks() != 0 {
+		t.Errorf("Expected nothing dropped, got %d", st.DroppedWebhooks())
+	}
+}
+
+func TestWithWebhookDropped(t *testing.T) {
+	st := NewSlogTripper(
+		WithLogger(slog.New(slog.NewJSONHandler(io.Discard, nil))),
+		WithRoundTripper(&MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Host == "audit.example" {
+					return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+				}
+
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+			},
+		}),
+	)
+	st = st.With(WithWebhook(WebhookConfig{URL: "http://audit.example/events", Client: &http.Client{Transport: st}}))
+
+	if _, err := st.RoundTrip(Must(http.NewRequest(http.MethodGet, "http://localhost/", nil))); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	if st.DroppedWebhooks() != 1 {
+		t.Errorf("Expected the failed post counted, got %d", st.DroppedWebhooks())
+	}
+}
+
+func TestWithWebhookInvalid(t *testing.T) {
+	for _, c := range []WebhookConfig{
+		{URL: "/events"},
+		{URL: "http://audit.example", SampleRate: 1.5},
+		{URL: "http://audit.example", QueueSize: -1},
+	} {
+		if _, err := NewSlogTripperE(WithWebhook(c)); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%+v: expected an invalid option, got %v", c, err)
+		}
+	}
+}